@@ -0,0 +1,77 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// The number of raw bytes shown per line of the hexdump.
+const dumpWidth = 16
+
+// A slog.Handler printing the trace records of a BinaryReader as an
+// annotated hexdump, with one line per field read:
+//
+//	00000000  46 49 4c 45                                       Header.Magic = [70 73 76 69]
+type dumpHandler struct {
+	w   io.Writer
+	err error
+}
+
+func (d *dumpHandler) Enabled(context.Context, slog.Level) bool {
+	return d.err == nil
+}
+
+func (d *dumpHandler) Handle(_ context.Context, rec slog.Record) error {
+	var (
+		path, raw, enum string
+		offset          int64
+		value           interface{}
+	)
+	rec.Attrs(func(a slog.Attr) bool {
+		switch a.Key {
+		case "path":
+			path = a.Value.String()
+		case "offset":
+			offset = a.Value.Int64()
+		case "raw":
+			raw = a.Value.String()
+		case "value":
+			value = a.Value.Any()
+		case "enum":
+			enum = a.Value.String()
+		}
+		return true
+	})
+	data, _ := hex.DecodeString(raw)
+	var b strings.Builder
+	for i, c := range data {
+		if i == dumpWidth {
+			b.WriteString("...")
+			break
+		} else if i > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%02x", c)
+	}
+	if enum != "" {
+		value = fmt.Sprintf("%v (%s)", value, enum)
+	}
+	_, d.err = fmt.Fprintf(d.w, "%08x  %-*s  %s = %v\n", offset, dumpWidth*3+2, b.String(), path, value)
+	return d.err
+}
+
+func (d *dumpHandler) WithAttrs([]slog.Attr) slog.Handler {
+	return d
+}
+
+func (d *dumpHandler) WithGroup(string) slog.Handler {
+	return d
+}
@@ -0,0 +1,143 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+// The bindump command decodes a binary file according to tagged struct
+// definitions, printing the result as JSON, as JSON including the offset,
+// size and raw bytes of every field, or as an annotated hexdump.
+// This allows for exploring a file format by iterating on its struct
+// definitions without writing a program for it:
+//
+//	bindump -types header.go -type Header -output dump file.bin
+//
+// The Go file given by -types is only parsed, not compiled, so it can
+// only use the basic Go types, arrays, slices, pointers, binary.UUID,
+// binary.Uint128, big.Int and the struct types it declares itself.
+// Custom Reader implementations and typeof tags aren't available. The
+// root type defaults to the first struct type declared in the file.
+//
+// Formats registered with binary.RegisterFormat can be decoded by name
+// with -format instead, which requires building a version of the
+// command importing the packages registering them.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/quarnster/util/encoding/binary"
+	"io"
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// Runs the command with the given arguments, writing the decoded
+// data to out and any usage information to errOut.
+func run(args []string, out, errOut io.Writer) error {
+	var (
+		fs       = flag.NewFlagSet("bindump", flag.ContinueOnError)
+		typesSrc = fs.String("types", "", "Go `file` declaring the struct types to decode")
+		typeName = fs.String("type", "", "`name` of the root type, defaults to the first struct declared")
+		format   = fs.String("format", "", "`name` of a registered format to decode")
+		list     = fs.Bool("list", false, "list the registered formats")
+		endian   = fs.String("endian", "little", "byte order of the data, little or big")
+		offset   = fs.Int64("offset", 0, "`offset` to start decoding at")
+		output   = fs.String("output", "json", "output format, json, map or dump")
+	)
+	fs.SetOutput(errOut)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: bindump [flags] file")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *list {
+		_, err := fmt.Fprintln(out, strings.Join(binary.Formats(), "\n"))
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("Expected a single file to decode")
+	}
+
+	var (
+		t   reflect.Type
+		err error
+	)
+	switch {
+	case *format != "" && *typesSrc != "":
+		return fmt.Errorf("Only one of -format and -types can be given")
+	case *format != "":
+		t, err = binary.LookupFormat(*format)
+	case *typesSrc != "":
+		var b *typeBuilder
+		if b, err = parseTypes(*typesSrc, nil); err == nil {
+			t, err = b.Type(*typeName)
+		}
+	default:
+		return fmt.Errorf("Either -format or -types must be given")
+	}
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	br := binary.BinaryReader{Reader: binary.NewBufferedReader(f, 0)}
+	switch *endian {
+	case "little":
+		br.Endianess = binary.LittleEndian
+	case "big":
+		br.Endianess = binary.BigEndian
+	default:
+		return fmt.Errorf("Unknown byte order: %s", *endian)
+	}
+	if _, err := br.Seek(*offset, 0); err != nil {
+		return err
+	}
+	return decode(&br, t, *output, out)
+}
+
+// Decodes a value of type t from br and writes it to out in the
+// given output format.
+func decode(br *binary.BinaryReader, t reflect.Type, output string, out io.Writer) error {
+	v := reflect.New(t)
+	switch output {
+	case "json":
+		if err := br.ReadInterface(v.Interface()); err != nil {
+			return err
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "\t")
+		return enc.Encode(v.Interface())
+	case "map":
+		m, err := binary.DecodeToMap(br, v.Interface())
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "\t")
+		return enc.Encode(m)
+	case "dump":
+		d := &dumpHandler{w: out}
+		br.Trace = slog.New(d)
+		if err := br.ReadInterface(v.Interface()); err != nil {
+			return err
+		}
+		return d.err
+	}
+	return fmt.Errorf("Unknown output format: %s", output)
+}
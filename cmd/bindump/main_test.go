@@ -0,0 +1,77 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	var (
+		dir   = t.TempDir()
+		types = filepath.Join(dir, "types.go")
+		data  = filepath.Join(dir, "data.bin")
+	)
+	if err := os.WriteFile(types, []byte(testTypes), 0644); err != nil {
+		t.Fatal(err)
+	}
+	content := append([]byte{0xff, 'F', 'I', 'L', 'E', 1, 1, 2, 0, 0xfe, 0xff}, make([]byte, 16)...)
+	if err := os.WriteFile(data, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := run([]string{"-types", types, "-type", "File", "-offset", "1", data}, &out, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	for _, exp := range []string{`"Kind": 1`, `"X": 2`, `"Y": -2`, `"ID": "00000000-0000-0000-0000-000000000000"`} {
+		if !strings.Contains(out.String(), exp) {
+			t.Errorf("Expected %s in the output:\n%s", exp, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := run([]string{"-types", types, "-type", "File", "-offset", "1", "-output", "dump", data}, &out, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	for _, exp := range []string{
+		"00000001  46 49 4c 45",
+		"Magic = [70 73 76 69]",
+		"00000007  02 00",
+		"Entries[0].X = 2",
+	} {
+		if !strings.Contains(out.String(), exp) {
+			t.Errorf("Expected %s in the output:\n%s", exp, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := run([]string{"-types", types, "-type", "File", "-offset", "1", "-output", "map", data}, &out, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"hex": "46494c45"`) {
+		t.Errorf("Expected the raw bytes of Magic in the output:\n%s", out.String())
+	}
+
+	for _, args := range [][]string{
+		{data},
+		{"-types", types},
+		{"-types", types, "-format", "x", data},
+		{"-format", "missing", data},
+		{"-types", types, "-endian", "middle", data},
+		{"-types", types, "-output", "xml", data},
+		{"-types", types, "-type", "File", data},
+	} {
+		out.Reset()
+		if err := run(args, &out, io.Discard); err == nil {
+			t.Errorf("Expected an error running with %v", args)
+		}
+	}
+}
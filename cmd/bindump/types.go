@@ -0,0 +1,198 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"github.com/quarnster/util/encoding/binary"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"math/big"
+	"reflect"
+	"strconv"
+)
+
+type (
+	// Builds reflect types at run time from the type declarations
+	// of a Go source file, so that files can be decoded without
+	// compiling the struct definitions into the tool.
+	typeBuilder struct {
+		decls map[string]*ast.TypeSpec
+		types map[string]reflect.Type
+		// The types currently being built, to detect recursion
+		busy map[string]bool
+		// The names of the declared types, in declaration order
+		names []string
+	}
+)
+
+var (
+	basicTypes = map[string]reflect.Type{
+		"bool":    reflect.TypeOf(false),
+		"byte":    reflect.TypeOf(byte(0)),
+		"uint8":   reflect.TypeOf(uint8(0)),
+		"uint16":  reflect.TypeOf(uint16(0)),
+		"uint32":  reflect.TypeOf(uint32(0)),
+		"uint64":  reflect.TypeOf(uint64(0)),
+		"uint":    reflect.TypeOf(uint(0)),
+		"int8":    reflect.TypeOf(int8(0)),
+		"int16":   reflect.TypeOf(int16(0)),
+		"int32":   reflect.TypeOf(int32(0)),
+		"int64":   reflect.TypeOf(int64(0)),
+		"int":     reflect.TypeOf(int(0)),
+		"float32": reflect.TypeOf(float32(0)),
+		"float64": reflect.TypeOf(float64(0)),
+		"string":  reflect.TypeOf(""),
+	}
+	qualifiedTypes = map[string]reflect.Type{
+		"binary.UUID":    reflect.TypeOf(binary.UUID{}),
+		"binary.Uint128": reflect.TypeOf(binary.Uint128{}),
+		"big.Int":        reflect.TypeOf(big.Int{}),
+	}
+)
+
+// Parses the Go source src, read from filename if src is nil, and
+// returns a typeBuilder for the types declared in it.
+func parseTypes(filename string, src interface{}) (*typeBuilder, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, 0)
+	if err != nil {
+		return nil, err
+	}
+	b := &typeBuilder{
+		decls: make(map[string]*ast.TypeSpec),
+		types: make(map[string]reflect.Type),
+		busy:  make(map[string]bool),
+	}
+	ast.Inspect(f, func(n ast.Node) bool {
+		if ts, ok := n.(*ast.TypeSpec); ok {
+			b.decls[ts.Name.Name] = ts
+			b.names = append(b.names, ts.Name.Name)
+		}
+		return true
+	})
+	return b, nil
+}
+
+// Returns the type declared by the given name, or the first declared
+// struct type if name is empty.
+func (b *typeBuilder) Type(name string) (reflect.Type, error) {
+	if name == "" {
+		for _, n := range b.names {
+			if _, ok := b.decls[n].Type.(*ast.StructType); ok {
+				name = n
+				break
+			}
+		}
+		if name == "" {
+			return nil, fmt.Errorf("No struct types declared")
+		}
+	}
+	if _, ok := b.decls[name]; !ok {
+		return nil, fmt.Errorf("No type declared by name %s", name)
+	}
+	return b.named(name)
+}
+
+func (b *typeBuilder) named(name string) (reflect.Type, error) {
+	if t, ok := b.types[name]; ok {
+		return t, nil
+	} else if b.busy[name] {
+		return nil, fmt.Errorf("Recursive type %s isn't supported", name)
+	}
+	b.busy[name] = true
+	defer delete(b.busy, name)
+	t, err := b.build(b.decls[name].Type)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", name, err)
+	}
+	b.types[name] = t
+	return t, nil
+}
+
+func (b *typeBuilder) build(e ast.Expr) (reflect.Type, error) {
+	switch e := e.(type) {
+	case *ast.Ident:
+		if _, ok := b.decls[e.Name]; ok {
+			return b.named(e.Name)
+		} else if t, ok := basicTypes[e.Name]; ok {
+			return t, nil
+		}
+		return nil, fmt.Errorf("Unknown type %s", e.Name)
+	case *ast.SelectorExpr:
+		if x, ok := e.X.(*ast.Ident); ok {
+			if t, ok := qualifiedTypes[x.Name+"."+e.Sel.Name]; ok {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("Unsupported type %s", types.ExprString(e))
+	case *ast.StarExpr:
+		t, err := b.build(e.X)
+		if err != nil {
+			return nil, err
+		}
+		return reflect.PtrTo(t), nil
+	case *ast.ArrayType:
+		t, err := b.build(e.Elt)
+		if err != nil {
+			return nil, err
+		} else if e.Len == nil {
+			return reflect.SliceOf(t), nil
+		}
+		lit, ok := e.Len.(*ast.BasicLit)
+		if !ok || lit.Kind != token.INT {
+			return nil, fmt.Errorf("Array lengths must be integer literals, not %s", types.ExprString(e.Len))
+		}
+		n, err := strconv.ParseInt(lit.Value, 0, 32)
+		if err != nil {
+			return nil, err
+		}
+		return reflect.ArrayOf(int(n), t), nil
+	case *ast.StructType:
+		var fields []reflect.StructField
+		for _, f := range e.Fields.List {
+			t, err := b.build(f.Type)
+			if err != nil {
+				return nil, err
+			}
+			var tag string
+			if f.Tag != nil {
+				if tag, err = strconv.Unquote(f.Tag.Value); err != nil {
+					return nil, err
+				}
+			}
+			if len(f.Names) == 0 {
+				// An embedded field, which is named after its type
+				name := embeddedName(f.Type)
+				if !ast.IsExported(name) {
+					return nil, fmt.Errorf("Embedded type %s must be exported to be decoded", name)
+				}
+				fields = append(fields, reflect.StructField{Name: name, Type: t, Tag: reflect.StructTag(tag), Anonymous: true})
+				continue
+			}
+			for _, n := range f.Names {
+				if !n.IsExported() {
+					return nil, fmt.Errorf("Field %s must be exported to be decoded", n.Name)
+				}
+				fields = append(fields, reflect.StructField{Name: n.Name, Type: t, Tag: reflect.StructTag(tag)})
+			}
+		}
+		return reflect.StructOf(fields), nil
+	}
+	return nil, fmt.Errorf("Unsupported type %s", types.ExprString(e))
+}
+
+// Returns the name of an embedded field of the given type.
+func embeddedName(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.StarExpr:
+		return embeddedName(e.X)
+	case *ast.SelectorExpr:
+		return e.Sel.Name
+	}
+	return types.ExprString(e)
+}
@@ -0,0 +1,73 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testTypes = `package test
+
+import "github.com/quarnster/util/encoding/binary"
+
+type (
+	Kind uint8
+	Header struct {
+		Magic [4]byte ` + "`match:\"FILE\"`" + `
+		Kind  Kind
+	}
+	File struct {
+		Header
+		Count   uint8
+		Entries []Entry ` + "`length:\"Count\"`" + `
+		ID      binary.UUID
+	}
+	Entry struct {
+		X, Y int16
+	}
+)
+`
+
+func TestParseTypes(t *testing.T) {
+	b, err := parseTypes("test.go", testTypes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	typ, err := b.Type("File")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f, ok := typ.FieldByName("Kind"); !ok || f.Type.Kind() != reflect.Uint8 {
+		t.Errorf("Expected a promoted uint8 Kind field, got %v", f)
+	}
+	if f, ok := typ.FieldByName("Entries"); !ok || f.Tag.Get("length") != "Count" || f.Type.Elem().NumField() != 2 {
+		t.Errorf("Unexpected Entries field: %v", f)
+	}
+	if def, err := b.Type(""); err != nil || def.NumField() != 2 {
+		t.Errorf("Expected Header to be the default type, got %v, %v", def, err)
+	}
+	if _, err := b.Type("Missing"); err == nil {
+		t.Error("Expected an error for an undeclared type")
+	}
+}
+
+func TestParseTypesUnsupported(t *testing.T) {
+	for _, src := range []string{
+		"package test\ntype T struct { x uint8 }",
+		"package test\ntype T struct { A [N]uint8 }",
+		"package test\ntype T struct { A map[string]int }",
+		"package test\ntype T struct { Next *T }",
+		"package test\ntype T struct { A os.File }",
+	} {
+		b, err := parseTypes("test.go", src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.Type("T"); err == nil {
+			t.Errorf("Expected an error building %q", src)
+		}
+	}
+}
@@ -7,6 +7,7 @@ package container
 import (
 	"fmt"
 	"github.com/quarnster/util"
+	"reflect"
 	"sort"
 )
 
@@ -15,29 +16,74 @@ var (
 	ErrIndexOOB         = fmt.Errorf("Index is out of bounds")
 	ErrNotManipulatable = fmt.Errorf("Filtered arrays are not directly manipulatable")
 	ErrMustBeObservable = fmt.Errorf("The inner array must satisfy the Observable interface. Consider wrapping it with the ObservableArray type.")
+	ErrNotSorted        = fmt.Errorf("Inserting at the given index would break the sort order")
 )
 
 type (
+	// Sent by an ObservableArray after an element has been removed,
+	// along with the element.
 	RemovedData struct {
 		Index int
 		Data  interface{}
 	}
 
+	// Sent by an ObservableArray after an element has been inserted,
+	// along with the element, so that observers don't have to Get it
+	// from an array which might have changed again since.
 	InsertedData struct {
 		Index int
+		Data  interface{}
 	}
 
+	// Sent by an ObservableArray after it has been sorted,
+	// as any of its elements might have moved.
+	ReorderedData struct{}
+
 	Array interface {
 		Insert(index int, data interface{}) error
 		Remove(i int) (olddata interface{}, err error)
 		Get(index int) interface{}
 		Len() int
+		// Sorts the array in place.
+		Sort(cmp Compare) error
+		// Sorts the array in place, keeping the original order
+		// of equal elements.
+		StableSort(cmp Compare) error
+		IsSorted(cmp Compare) bool
+		// Returns a copy of the array's data as a plain slice.
+		ToSlice() []interface{}
+		// Copies the array's data into dst, returning the number
+		// of elements copied, which is the minimum of len(dst)
+		// and Len().
+		CopyInto(dst []interface{}) int
+	}
+	// An Array which can be searched. LowerBound and UpperBound
+	// require the array to be sorted according to cmp.
+	SearchableArray interface {
+		Array
+		// Returns the index of the first element equal to data,
+		// or -1 if there is no such element.
+		IndexOf(data interface{}) int
+		Contains(data interface{}) bool
+		// Returns the index of the first element not ordered
+		// before data.
+		LowerBound(data interface{}, cmp Compare) int
+		// Returns the index of the first element ordered after data.
+		UpperBound(data interface{}, cmp Compare) int
 	}
 	IntArray struct {
 		BasicArray
 	}
+	// SortedArray keeps the inner array sorted according to Compare,
+	// which allows it to use binary searches for IndexOf and Contains.
+	SortedArray struct {
+		Array
+		Compare Compare
+	}
 	BasicArray struct {
 		model []interface{}
+		// Whether model is shared with a snapshot
+		shared bool
 	}
 	BoundsCheckingArray struct {
 		Array
@@ -54,19 +100,88 @@ type (
 	}
 )
 
+// Creates a new BasicArray holding a copy of data.
+func NewBasicArrayFrom(data []interface{}) *BasicArray {
+	return &BasicArray{model: append([]interface{}(nil), data...)}
+}
+
+// Creates a new BasicArray holding a copy of data.
+func NewBasicArrayOf[T any](data []T) *BasicArray {
+	a := &BasicArray{model: make([]interface{}, len(data))}
+	for i, v := range data {
+		a.model[i] = v
+	}
+	return a
+}
+
+// Creates a new IntArray holding a copy of data.
+func NewIntArrayFrom(data []int) *IntArray {
+	return &IntArray{*NewBasicArrayOf(data)}
+}
+
+// Returns a copy of the data in a as a slice of type T. An error is
+// returned if any of the elements isn't of type T.
+func ToSliceOf[T any](a Array) ([]T, error) {
+	ret := make([]T, a.Len())
+	for i := range ret {
+		v, ok := a.Get(i).(T)
+		if !ok {
+			return nil, fmt.Errorf("Element %d is a %T, not a %T", i, a.Get(i), v)
+		}
+		ret[i] = v
+	}
+	return ret, nil
+}
+
 func NewFilteredArray(inner Array, accept Acceptable) (Array, error) {
 	if _, ok := inner.(util.Observable); !ok {
 		return nil, ErrMustBeObservable
 	}
 	fa := filteredArray{accept: accept, Array: inner}
 	inner.(util.Observable).AddObserver(&fa)
-	for i := 0; i < inner.Len(); i++ {
-		if !accept(inner.Get(i)) {
+	fa.rebuild()
+	return &fa, nil
+}
+
+// Creates a new SortedArray wrapping inner, which must already
+// be sorted according to cmp.
+func NewSortedArray(inner Array, cmp Compare) (*SortedArray, error) {
+	if !inner.IsSorted(cmp) {
+		return nil, ErrNotSorted
+	}
+	return &SortedArray{inner, cmp}, nil
+}
+
+func compareInt(a, b interface{}) ComparisonResult {
+	switch a, b := a.(int), b.(int); {
+	case a < b:
+		return Less
+	case a > b:
+		return Greater
+	}
+	return Equal
+}
+
+func lowerBound(a Array, data interface{}, cmp Compare) int {
+	return sort.Search(a.Len(), func(i int) bool {
+		return cmp(a.Get(i), data) != Less
+	})
+}
+
+func upperBound(a Array, data interface{}, cmp Compare) int {
+	return sort.Search(a.Len(), func(i int) bool {
+		return cmp(a.Get(i), data) == Greater
+	})
+}
+
+func (fa *filteredArray) rebuild() {
+	fa.indices.model = fa.indices.model[:0]
+	for i := 0; i < fa.Array.Len(); i++ {
+		if !fa.accept(fa.Array.Get(i)) {
 			continue
 		}
 		fa.indices.Insert(fa.indices.Len(), i)
 	}
-	return &fa, nil
 }
 
 func (b *BoundsCheckingArray) Insert(index int, data interface{}) error {
@@ -104,10 +219,12 @@ func (a *BasicArray) Insert(index int, data interface{}) error {
 	nmodel[index] = data
 	copy(nmodel[index+1:], a.model[index:])
 	a.model = nmodel
+	a.shared = false
 	return nil
 }
 
 func (a *BasicArray) Remove(i int) (olddata interface{}, err error) {
+	a.unshare()
 	olddata = a.model[i]
 	copy(a.model[i:], a.model[i+1:])
 	a.model = a.model[:len(a.model)-1]
@@ -122,11 +239,120 @@ func (a *BasicArray) Len() int {
 	return len(a.model)
 }
 
+func (a *BasicArray) Sort(cmp Compare) error {
+	a.unshare()
+	sort.Slice(a.model, func(i, j int) bool {
+		return cmp(a.model[i], a.model[j]) == Less
+	})
+	return nil
+}
+
+func (a *BasicArray) StableSort(cmp Compare) error {
+	a.unshare()
+	sort.SliceStable(a.model, func(i, j int) bool {
+		return cmp(a.model[i], a.model[j]) == Less
+	})
+	return nil
+}
+
+func (a *BasicArray) IsSorted(cmp Compare) bool {
+	return sort.SliceIsSorted(a.model, func(i, j int) bool {
+		return cmp(a.model[i], a.model[j]) == Less
+	})
+}
+
+func (a *BasicArray) ToSlice() []interface{} {
+	return append([]interface{}(nil), a.model...)
+}
+
+func (a *BasicArray) CopyInto(dst []interface{}) int {
+	return copy(dst, a.model)
+}
+
+// Returns the index of the first element equal to data according to
+// ==, or -1 if there is no such element. Values of types which can't
+// be compared with ==, such as slices and maps, are never found.
+func (a *BasicArray) IndexOf(data interface{}) int {
+	if t := reflect.TypeOf(data); t != nil && !t.Comparable() {
+		return -1
+	}
+	for i, v := range a.model {
+		if v == data {
+			return i
+		}
+	}
+	return -1
+}
+
+func (a *BasicArray) Contains(data interface{}) bool {
+	return a.IndexOf(data) != -1
+}
+
+func (a *BasicArray) LowerBound(data interface{}, cmp Compare) int {
+	return lowerBound(a, data, cmp)
+}
+
+func (a *BasicArray) UpperBound(data interface{}, cmp Compare) int {
+	return upperBound(a, data, cmp)
+}
+
+// Inserts data at the given index, provided that doing so
+// keeps the array sorted.
+func (s *SortedArray) Insert(index int, data interface{}) error {
+	if index < 0 || index > s.Len() {
+		return ErrIndexOOB
+	}
+	if (index > 0 && s.Compare(s.Get(index-1), data) == Greater) ||
+		(index < s.Len() && s.Compare(data, s.Get(index)) == Greater) {
+		return ErrNotSorted
+	}
+	return s.Array.Insert(index, data)
+}
+
+// Inserts data after any equal elements already in the array,
+// returning the index it was inserted at.
+func (s *SortedArray) Add(data interface{}) (int, error) {
+	i := s.UpperBound(data, s.Compare)
+	return i, s.Array.Insert(i, data)
+}
+
+// Sorting by anything but Compare would break the order the other
+// methods rely on, so cmp is ignored and the array is sorted by
+// Compare instead. This only has an effect if the inner array has
+// been modified directly.
+func (s *SortedArray) Sort(cmp Compare) error {
+	return s.Array.Sort(s.Compare)
+}
+
+// Like Sort, the array is sorted by Compare whatever cmp is.
+func (s *SortedArray) StableSort(cmp Compare) error {
+	return s.Array.StableSort(s.Compare)
+}
+
+func (s *SortedArray) IndexOf(data interface{}) int {
+	if i := s.LowerBound(data, s.Compare); i < s.Len() && s.Compare(s.Get(i), data) == Equal {
+		return i
+	}
+	return -1
+}
+
+func (s *SortedArray) Contains(data interface{}) bool {
+	return s.IndexOf(data) != -1
+}
+
+func (s *SortedArray) LowerBound(data interface{}, cmp Compare) int {
+	return lowerBound(s, data, cmp)
+}
+
+func (s *SortedArray) UpperBound(data interface{}, cmp Compare) int {
+	return upperBound(s, data, cmp)
+}
+
 func (a *ObservableArray) Insert(index int, data interface{}) error {
 	if err := a.Array.Insert(index, data); err != nil {
 		return err
 	}
-	a.NotifyObservers(InsertedData{index})
+	a.NotifyObservers(InsertedData{index, data})
 	return nil
 }
 
@@ -138,32 +364,42 @@ func (a *ObservableArray) Remove(i int) (olddata interface{}, err error) {
 	return
 }
 
+func (a *ObservableArray) Sort(cmp Compare) error {
+	if err := a.Array.Sort(cmp); err != nil {
+		return err
+	}
+	a.NotifyObservers(ReorderedData{})
+	return nil
+}
+
+func (a *ObservableArray) StableSort(cmp Compare) error {
+	if err := a.Array.StableSort(cmp); err != nil {
+		return err
+	}
+	a.NotifyObservers(ReorderedData{})
+	return nil
+}
+
 func (fa *filteredArray) Changed(data interface{}) {
 	switch d := data.(type) {
 	case RemovedData:
-		for idx := 0; idx < fa.indices.Len(); {
-			curr := fa.indices.Get(idx).(int)
-			if curr == d.Index {
-				fa.indices.Remove(idx)
-				continue
-			}
-			if curr > d.Index {
-				fa.indices.model[idx] = curr - 1
-			}
-			idx++
+		idx := fa.indices.LowerBound(d.Index, compareInt)
+		if idx < fa.indices.Len() && fa.indices.model[idx] == d.Index {
+			fa.indices.Remove(idx)
+		}
+		for ; idx < fa.indices.Len(); idx++ {
+			fa.indices.model[idx] = fa.indices.model[idx].(int) - 1
 		}
 	case InsertedData:
-		data := fa.Array.Get(d.Index)
-		if !fa.accept(data) {
-			return
+		idx := fa.indices.LowerBound(d.Index, compareInt)
+		for i := idx; i < fa.indices.Len(); i++ {
+			fa.indices.model[i] = fa.indices.model[i].(int) + 1
 		}
-		idx := sort.Search(fa.indices.Len(), func(i int) bool {
-			return fa.indices.Get(i).(int) <= d.Index
-		})
-		if idx < fa.Len() {
-			idx++
+		if fa.accept(d.Data) {
+			fa.indices.Insert(idx, d.Index)
 		}
-		fa.indices.Insert(idx, d.Index)
+	case ReorderedData:
+		fa.rebuild()
 	}
 }
 
@@ -175,6 +411,40 @@ func (fa *filteredArray) Remove(index int) (interface{}, error) {
 	return nil, ErrNotManipulatable
 }
 
+func (fa *filteredArray) Sort(cmp Compare) error {
+	return ErrNotManipulatable
+}
+
+func (fa *filteredArray) StableSort(cmp Compare) error {
+	return ErrNotManipulatable
+}
+
+func (fa *filteredArray) IsSorted(cmp Compare) bool {
+	for i := fa.Len() - 1; i > 0; i-- {
+		if cmp(fa.Get(i), fa.Get(i-1)) == Less {
+			return false
+		}
+	}
+	return true
+}
+
+func (fa *filteredArray) ToSlice() []interface{} {
+	ret := make([]interface{}, fa.Len())
+	fa.CopyInto(ret)
+	return ret
+}
+
+func (fa *filteredArray) CopyInto(dst []interface{}) int {
+	n := fa.Len()
+	if len(dst) < n {
+		n = len(dst)
+	}
+	for i := 0; i < n; i++ {
+		dst[i] = fa.Get(i)
+	}
+	return n
+}
+
 func (fa *filteredArray) Get(index int) interface{} {
 	return fa.Array.Get(fa.indices.Get(index).(int))
 }
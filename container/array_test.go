@@ -167,3 +167,261 @@ func TestFilteredArray(t *testing.T) {
 	}
 
 }
+
+func compareInts(a, b int) container.ComparisonResult {
+	switch {
+	case a < b:
+		return container.Less
+	case a > b:
+		return container.Greater
+	}
+	return container.Equal
+}
+
+type reorderCounter struct {
+	count int
+}
+
+func (r *reorderCounter) Changed(data interface{}) {
+	if _, ok := data.(container.ReorderedData); ok {
+		r.count++
+	}
+}
+
+func TestArraySort(t *testing.T) {
+	var (
+		a        = &container.ObservableArray{Array: &container.IntArray{}}
+		reorders reorderCounter
+		cmp      = func(a, b interface{}) container.ComparisonResult {
+			return compareInts(a.(int), b.(int))
+		}
+	)
+	a.AddObserver(&reorders)
+	for i, v := range []int{5, 3, 8, 1, 9, 2} {
+		a.Insert(i, v)
+	}
+	even, _ := container.NewFilteredArray(a, func(data interface{}) bool {
+		return data.(int)%2 == 0
+	})
+	if a.IsSorted(cmp) {
+		t.Error("Didn't expect the array to be sorted")
+	}
+	if err := a.Sort(cmp); err != nil {
+		t.Error(err)
+	}
+	if !a.IsSorted(cmp) {
+		t.Error("Expected the array to be sorted")
+	}
+	if reorders.count != 1 {
+		t.Errorf("Expected a single reordered event, but got %d", reorders.count)
+	}
+	for i, exp := range []int{1, 2, 3, 5, 8, 9} {
+		if v := a.Get(i); v != exp {
+			t.Errorf("%d: Expected %d, but got %v", i, exp, v)
+		}
+	}
+	for i, exp := range []int{2, 8} {
+		if v := even.Get(i); v != exp {
+			t.Errorf("%d: Expected %d in the filtered array, but got %v", i, exp, v)
+		}
+	}
+	if err := even.Sort(cmp); err != container.ErrNotManipulatable {
+		t.Errorf("Expected %s, but got %v", container.ErrNotManipulatable, err)
+	}
+}
+
+func TestArrayStableSort(t *testing.T) {
+	type pair struct {
+		key, val int
+	}
+	a := &container.BoundsCheckingArray{Array: &container.BasicArray{}}
+	for i, v := range []pair{{2, 0}, {1, 1}, {2, 2}, {1, 3}, {0, 4}} {
+		a.Insert(i, v)
+	}
+	cmp := func(a, b interface{}) container.ComparisonResult {
+		return compareInts(a.(pair).key, b.(pair).key)
+	}
+	if err := a.StableSort(cmp); err != nil {
+		t.Error(err)
+	}
+	for i, exp := range []int{4, 1, 3, 0, 2} {
+		if v := a.Get(i).(pair).val; v != exp {
+			t.Errorf("%d: Expected %d, but got %d", i, exp, v)
+		}
+	}
+}
+
+func TestArrayFromSlice(t *testing.T) {
+	a := container.NewBasicArrayFrom(data)
+	if l := a.Len(); l != len(data) {
+		t.Errorf("Expected %d but got %d", len(data), l)
+	}
+	s := a.ToSlice()
+	for i, v := range data {
+		if s[i] != v {
+			t.Errorf("%d: Expected %v, but got %v", i, v, s[i])
+		}
+	}
+	s[0] = "changed"
+	if a.Get(0) != data[0] {
+		t.Error("Expected ToSlice to return a copy")
+	}
+	dst := make([]interface{}, 3)
+	if n := a.CopyInto(dst); n != 3 {
+		t.Errorf("Expected 3 elements to be copied, but got %d", n)
+	}
+	for i, v := range dst {
+		if v != data[i] {
+			t.Errorf("%d: Expected %v, but got %v", i, data[i], v)
+		}
+	}
+}
+
+func TestIntArrayFromSlice(t *testing.T) {
+	ints := []int{4, 8, 15, 16, 23, 42}
+	a := container.NewIntArrayFrom(ints)
+	if err := a.Insert(0, "hello"); err != container.ErrNotInt {
+		t.Errorf("Expected %s, but got %v", container.ErrNotInt, err)
+	}
+	if s, err := container.ToSliceOf[int](a); err != nil {
+		t.Error(err)
+	} else if len(s) != len(ints) {
+		t.Errorf("Expected %d but got %d", len(ints), len(s))
+	} else {
+		for i := range ints {
+			if s[i] != ints[i] {
+				t.Errorf("%d: Expected %d, but got %d", i, ints[i], s[i])
+			}
+		}
+	}
+	if _, err := container.ToSliceOf[string](a); err == nil {
+		t.Error("Expected an error but didn't get one")
+	}
+	b := container.NewBasicArrayOf([]string{"hello", "world"})
+	if s, err := container.ToSliceOf[string](b); err != nil || len(s) != 2 || s[1] != "world" {
+		t.Errorf("Unexpected result %v, %v", s, err)
+	}
+}
+
+func TestSearchableArray(t *testing.T) {
+	var (
+		cmp = func(a, b interface{}) container.ComparisonResult {
+			return compareInts(a.(int), b.(int))
+		}
+		a container.SearchableArray = container.NewIntArrayFrom([]int{1, 2, 2, 2, 5, 8})
+	)
+	s, err := container.NewSortedArray(a, cmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sa := range []container.SearchableArray{a, s} {
+		tests := []struct {
+			data, index, lower, upper int
+		}{
+			{0, -1, 0, 0},
+			{1, 0, 0, 1},
+			{2, 1, 1, 4},
+			{3, -1, 4, 4},
+			{8, 5, 5, 6},
+			{9, -1, 6, 6},
+		}
+		for _, test := range tests {
+			if i := sa.IndexOf(test.data); i != test.index {
+				t.Errorf("%T IndexOf(%d): Expected %d, but got %d", sa, test.data, test.index, i)
+			}
+			if c := sa.Contains(test.data); c != (test.index != -1) {
+				t.Errorf("%T Contains(%d): Expected %v, but got %v", sa, test.data, test.index != -1, c)
+			}
+			if i := sa.LowerBound(test.data, cmp); i != test.lower {
+				t.Errorf("%T LowerBound(%d): Expected %d, but got %d", sa, test.data, test.lower, i)
+			}
+			if i := sa.UpperBound(test.data, cmp); i != test.upper {
+				t.Errorf("%T UpperBound(%d): Expected %d, but got %d", sa, test.data, test.upper, i)
+			}
+		}
+	}
+	if err := s.Insert(0, 3); err != container.ErrNotSorted {
+		t.Errorf("Expected %s, but got %v", container.ErrNotSorted, err)
+	}
+	if i, err := s.Add(3); err != nil || i != 4 {
+		t.Errorf("Expected 3 to be added at 4, but got %d, %v", i, err)
+	}
+	reverse := func(a, b interface{}) container.ComparisonResult {
+		return compareInts(b.(int), a.(int))
+	}
+	for _, sort := range []func(container.Compare) error{s.Sort, s.StableSort} {
+		if err := sort(reverse); err != nil {
+			t.Error(err)
+		} else if !s.IsSorted(cmp) || s.IndexOf(8) != s.Len()-1 {
+			t.Errorf("Expected the array to stay sorted by its Compare, but got %v", s.ToSlice())
+		}
+	}
+	if _, err := container.NewSortedArray(container.NewIntArrayFrom([]int{2, 1}), cmp); err != container.ErrNotSorted {
+		t.Errorf("Expected %s, but got %v", container.ErrNotSorted, err)
+	}
+}
+
+type changeRecorder struct {
+	changes []interface{}
+}
+
+func (r *changeRecorder) Changed(data interface{}) {
+	r.changes = append(r.changes, data)
+}
+
+func TestObservableArrayData(t *testing.T) {
+	var (
+		a   = &container.ObservableArray{Array: &container.BasicArray{}}
+		rec changeRecorder
+	)
+	a.AddObserver(&rec)
+	a.Insert(0, "a")
+	a.Insert(0, "b")
+	a.Remove(1)
+	exp := []interface{}{
+		container.InsertedData{Index: 0, Data: "a"},
+		container.InsertedData{Index: 0, Data: "b"},
+		container.RemovedData{Index: 1, Data: "a"},
+	}
+	if len(rec.changes) != len(exp) {
+		t.Fatalf("Expected %v, but got %v", exp, rec.changes)
+	}
+	for i := range exp {
+		if rec.changes[i] != exp[i] {
+			t.Errorf("%d: Expected %+v, but got %+v", i, exp[i], rec.changes[i])
+		}
+	}
+}
+
+func TestBasicArrayIndexOfUncomparable(t *testing.T) {
+	s := []int{1}
+	a := container.NewBasicArrayFrom([]interface{}{1, s, map[int]int{}, "a"})
+	if i := a.IndexOf(s); i != -1 {
+		t.Errorf("Expected slices not to be found, but got %d", i)
+	}
+	if a.Contains(map[int]int{}) {
+		t.Error("Expected maps not to be found")
+	}
+	if i := a.IndexOf("a"); i != 3 {
+		t.Errorf("Expected 3, but got %d", i)
+	}
+}
+
+func TestFilteredArrayInsertMiddle(t *testing.T) {
+	inner := &container.ObservableArray{Array: &container.BasicArray{}}
+	a, _ := container.NewFilteredArray(inner, func(data interface{}) bool {
+		_, ok := data.(string)
+		return ok
+	})
+	for _, d := range data {
+		inner.Insert(inner.Len(), d)
+	}
+	inner.Insert(0, "first")
+	inner.Insert(3, 42)
+	inner.Insert(inner.Len()-1, "middle")
+	for i, exp := range []string{"first", "hello", "middle", "world"} {
+		if v := a.Get(i); v != exp {
+			t.Errorf("%d: Expected %s, but got %v", i, exp, v)
+		}
+	}
+}
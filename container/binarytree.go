@@ -22,6 +22,10 @@ type (
 	Tree    struct {
 		Compare Compare
 		Root    Node
+		// Whether the nodes are shared with a snapshot
+		shared bool
+		// Operation counts, if enabled
+		stats *counters
 	}
 )
 
@@ -96,11 +100,15 @@ func (n *Node) delete(child int, parent *Node) {
 }
 
 func (t *Tree) Find(data interface{}) (child int, parent, node *Node) {
-	return t.Root.Find(data, t.Compare)
+	if t.stats != nil {
+		t.stats.lookups.Add(1)
+	}
+	return t.Root.Find(data, t.compare())
 }
 
 func (t *Tree) Add(data interface{}) error {
-	child, p, n := t.Find(data)
+	t.unshare()
+	child, p, n := t.Root.Find(data, t.compare())
 	if n != nil {
 		if n.Data == data {
 			return fmt.Errorf("Data already exists in the tree")
@@ -112,15 +120,22 @@ func (t *Tree) Add(data interface{}) error {
 	} else {
 		panic("Both parent and child was null")
 	}
+	if t.stats != nil {
+		t.stats.inserts.Add(1)
+	}
 	return nil
 }
 
 func (t *Tree) Delete(data interface{}) error {
-	child, p, n := t.Find(data)
+	t.unshare()
+	child, p, n := t.Root.Find(data, t.compare())
 	if n == nil || (p == nil && n.Data == nil) {
 		return fmt.Errorf("Unable to find that node")
 	} else {
 		n.delete(child, p)
+		if t.stats != nil {
+			t.stats.removes.Add(1)
+		}
 		return nil
 	}
 }
@@ -0,0 +1,251 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"fmt"
+	"iter"
+)
+
+// Graph is a directed or undirected graph with nodes of type T.
+// Nodes and edges are kept in the order they were added, which
+// makes the traversals deterministic.
+type Graph[T comparable] struct {
+	directed bool
+	nodes    []T
+	edges    map[T][]T
+}
+
+// Creates a new empty graph. In an undirected graph every edge
+// goes both ways.
+func NewGraph[T comparable](directed bool) *Graph[T] {
+	return &Graph[T]{directed: directed, edges: make(map[T][]T)}
+}
+
+func (g *Graph[T]) Directed() bool {
+	return g.directed
+}
+
+// Adds n to the graph unless it's already in it.
+func (g *Graph[T]) AddNode(n T) {
+	if _, ok := g.edges[n]; !ok {
+		g.edges[n] = nil
+		g.nodes = append(g.nodes, n)
+	}
+}
+
+func (g *Graph[T]) HasNode(n T) bool {
+	_, ok := g.edges[n]
+	return ok
+}
+
+// Removes n and all the edges to and from it.
+func (g *Graph[T]) RemoveNode(n T) {
+	if !g.HasNode(n) {
+		return
+	}
+	for _, m := range g.nodes {
+		g.edges[m] = without(g.edges[m], n)
+	}
+	delete(g.edges, n)
+	g.nodes = without(g.nodes, n)
+}
+
+// Returns the nodes of the graph in the order they were added.
+func (g *Graph[T]) Nodes() []T {
+	return append([]T(nil), g.nodes...)
+}
+
+func without[T comparable](s []T, v T) []T {
+	for i := range s {
+		if s[i] == v {
+			return append(s[:i:i], s[i+1:]...)
+		}
+	}
+	return s
+}
+
+func (g *Graph[T]) addEdge(from, to T) {
+	for _, n := range g.edges[from] {
+		if n == to {
+			return
+		}
+	}
+	g.edges[from] = append(g.edges[from], to)
+}
+
+// Adds an edge between from and to, adding the nodes
+// too if they aren't in the graph already.
+func (g *Graph[T]) AddEdge(from, to T) {
+	g.AddNode(from)
+	g.AddNode(to)
+	g.addEdge(from, to)
+	if !g.directed {
+		g.addEdge(to, from)
+	}
+}
+
+// Removes the edge between from and to, returning
+// whether there was one.
+func (g *Graph[T]) RemoveEdge(from, to T) bool {
+	if !g.HasEdge(from, to) {
+		return false
+	}
+	g.edges[from] = without(g.edges[from], to)
+	if !g.directed {
+		g.edges[to] = without(g.edges[to], from)
+	}
+	return true
+}
+
+func (g *Graph[T]) HasEdge(from, to T) bool {
+	for _, n := range g.edges[from] {
+		if n == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the nodes that n has edges to.
+func (g *Graph[T]) Neighbours(n T) []T {
+	return append([]T(nil), g.edges[n]...)
+}
+
+// Returns an iterator over the nodes reachable from start,
+// including start itself, in depth first order.
+func (g *Graph[T]) DFS(start T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		if !g.HasNode(start) {
+			return
+		}
+		var (
+			visited = map[T]bool{}
+			stack   = []T{start}
+		)
+		for len(stack) > 0 {
+			n := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if visited[n] {
+				continue
+			}
+			visited[n] = true
+			if !yield(n) {
+				return
+			}
+			// Pushed in reverse so that the first neighbour
+			// is visited first.
+			e := g.edges[n]
+			for i := len(e) - 1; i >= 0; i-- {
+				if !visited[e[i]] {
+					stack = append(stack, e[i])
+				}
+			}
+		}
+	}
+}
+
+// Returns an iterator over the nodes reachable from start,
+// including start itself, in breadth first order.
+func (g *Graph[T]) BFS(start T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		if !g.HasNode(start) {
+			return
+		}
+		var (
+			visited = map[T]bool{start: true}
+			queue   = []T{start}
+		)
+		for len(queue) > 0 {
+			n := queue[0]
+			queue = queue[1:]
+			if !yield(n) {
+				return
+			}
+			for _, m := range g.edges[n] {
+				if !visited[m] {
+					visited[m] = true
+					queue = append(queue, m)
+				}
+			}
+		}
+	}
+}
+
+// Returns the nodes of a directed graph ordered such that every
+// node comes before the nodes it has edges to, such as for ordering
+// dependencies. An error is returned if the graph isn't directed
+// or contains a cycle.
+func (g *Graph[T]) TopologicalSort() ([]T, error) {
+	if !g.directed {
+		return nil, fmt.Errorf("Can't sort an undirected graph topologically")
+	}
+	var (
+		indegree = make(map[T]int, len(g.nodes))
+		queue    []T
+		ret      = make([]T, 0, len(g.nodes))
+	)
+	for _, n := range g.nodes {
+		for _, m := range g.edges[n] {
+			indegree[m]++
+		}
+	}
+	for _, n := range g.nodes {
+		if indegree[n] == 0 {
+			queue = append(queue, n)
+		}
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		ret = append(ret, n)
+		for _, m := range g.edges[n] {
+			if indegree[m]--; indegree[m] == 0 {
+				queue = append(queue, m)
+			}
+		}
+	}
+	if len(ret) != len(g.nodes) {
+		return nil, fmt.Errorf("The graph contains a cycle")
+	}
+	return ret, nil
+}
+
+// Returns whether the graph contains a cycle. In an undirected graph
+// an edge and its way back doesn't count as a cycle, but a self loop
+// does.
+func (g *Graph[T]) HasCycle() bool {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[T]int, len(g.nodes))
+	var visit func(n, parent T, hasParent bool) bool
+	visit = func(n, parent T, hasParent bool) bool {
+		state[n] = visiting
+		for _, m := range g.edges[n] {
+			if !g.directed && hasParent && m == parent {
+				continue
+			}
+			switch state[m] {
+			case visiting:
+				return true
+			case unvisited:
+				if visit(m, n, true) {
+					return true
+				}
+			}
+		}
+		state[n] = done
+		return false
+	}
+	for _, n := range g.nodes {
+		if state[n] == unvisited && visit(n, n, false) {
+			return true
+		}
+	}
+	return false
+}
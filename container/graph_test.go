@@ -0,0 +1,85 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestGraphTraversal(t *testing.T) {
+	g := NewGraph[string](true)
+	for _, e := range [][2]string{{"a", "b"}, {"a", "c"}, {"b", "d"}, {"c", "d"}, {"d", "e"}, {"x", "a"}} {
+		g.AddEdge(e[0], e[1])
+	}
+	if exp, got := []string{"a", "b", "d", "e", "c"}, slices.Collect(g.DFS("a")); !reflect.DeepEqual(exp, got) {
+		t.Errorf("DFS: Expected %v, but got %v", exp, got)
+	}
+	if exp, got := []string{"a", "b", "c", "d", "e"}, slices.Collect(g.BFS("a")); !reflect.DeepEqual(exp, got) {
+		t.Errorf("BFS: Expected %v, but got %v", exp, got)
+	}
+	for n := range g.BFS("a") {
+		if n == "c" {
+			break
+		}
+	}
+	if got := slices.Collect(g.DFS("missing")); len(got) != 0 {
+		t.Errorf("Expected nothing, but got %v", got)
+	}
+	if order, err := g.TopologicalSort(); err != nil {
+		t.Error(err)
+	} else if exp := []string{"x", "a", "b", "c", "d", "e"}; !reflect.DeepEqual(exp, order) {
+		t.Errorf("Expected %v, but got %v", exp, order)
+	}
+	if g.HasCycle() {
+		t.Error("Didn't expect a cycle")
+	}
+	g.AddEdge("e", "a")
+	if !g.HasCycle() {
+		t.Error("Expected a cycle")
+	}
+	if _, err := g.TopologicalSort(); err == nil {
+		t.Error("Expected an error for a graph with a cycle")
+	}
+	if !g.RemoveEdge("e", "a") || g.RemoveEdge("e", "a") {
+		t.Error("Expected the edge to be removed exactly once")
+	}
+	g.RemoveNode("d")
+	if g.HasNode("d") || g.HasEdge("b", "d") {
+		t.Error("Expected d and its edges to be removed")
+	}
+	if exp, got := []string{"a", "b", "c", "e", "x"}, g.Nodes(); !reflect.DeepEqual(exp, got) {
+		t.Errorf("Expected %v, but got %v", exp, got)
+	}
+}
+
+func TestGraphUndirected(t *testing.T) {
+	g := NewGraph[int](false)
+	g.AddEdge(1, 2)
+	g.AddEdge(2, 3)
+	g.AddEdge(3, 4)
+	if !g.HasEdge(2, 1) {
+		t.Error("Expected edges to go both ways")
+	}
+	if g.HasCycle() {
+		t.Error("Didn't expect a cycle")
+	}
+	if _, err := g.TopologicalSort(); err == nil {
+		t.Error("Expected an error for an undirected graph")
+	}
+	g.AddEdge(4, 2)
+	if !g.HasCycle() {
+		t.Error("Expected a cycle")
+	}
+	g.RemoveEdge(2, 4)
+	if g.HasCycle() || g.HasEdge(4, 2) {
+		t.Error("Expected the cycle to be removed")
+	}
+	g.AddEdge(5, 5)
+	if !g.HasCycle() {
+		t.Error("Expected a self loop to be a cycle")
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"fmt"
+	"github.com/quarnster/util"
+	"sort"
+)
+
+var ErrNoSuchHandle = fmt.Errorf("No element with that handle")
+
+type (
+	// A stable identifier of an element in a HandleArray, which
+	// stays the same while other elements are inserted, removed or
+	// the array is sorted. The zero Handle never identifies an
+	// element.
+	Handle uint64
+
+	// Sent by a HandleArray when an element has been inserted.
+	HandleInserted struct {
+		Handle Handle
+		Index  int
+		Data   interface{}
+	}
+
+	// Sent by a HandleArray when an element has been removed.
+	HandleRemoved struct {
+		Handle Handle
+		Index  int
+		Data   interface{}
+	}
+
+	// HandleArray is an observable Array which assigns each element a
+	// Handle when it's inserted, by which the element can later be
+	// accessed regardless of how its index has changed in the meantime.
+	// This allows observers and views to keep track of elements across
+	// reorders, and avoids acting on stale indices when several parties
+	// modify the array.
+	//
+	// Its change events are HandleInserted and HandleRemoved rather than
+	// InsertedData and RemovedData, and ReorderedData when sorted.
+	HandleArray struct {
+		util.BasicObservable
+		data    []interface{}
+		handles []Handle
+		next    Handle
+		// Maps handles to indices. Rebuilt lazily when dirty, as
+		// inserting or removing anywhere but at the end shifts the
+		// indices of the following elements.
+		index map[Handle]int
+		dirty bool
+	}
+)
+
+// Creates a new empty HandleArray.
+func NewHandleArray() *HandleArray {
+	return &HandleArray{index: make(map[Handle]int)}
+}
+
+// Returns the index of the element with the given handle,
+// or -1 if there is no such element.
+func (a *HandleArray) IndexOf(h Handle) int {
+	if a.dirty {
+		a.index = make(map[Handle]int, len(a.handles))
+		for i, h := range a.handles {
+			a.index[h] = i
+		}
+		a.dirty = false
+	}
+	if i, ok := a.index[h]; ok {
+		return i
+	}
+	return -1
+}
+
+// Returns the handle of the element at the given index.
+func (a *HandleArray) HandleAt(index int) Handle {
+	return a.handles[index]
+}
+
+// Inserts data at the given index, returning its handle.
+func (a *HandleArray) InsertHandle(index int, data interface{}) (Handle, error) {
+	if index < 0 || index > len(a.data) {
+		return 0, ErrIndexOOB
+	}
+	a.next++
+	h := a.next
+	a.data = append(a.data, nil)
+	copy(a.data[index+1:], a.data[index:])
+	a.data[index] = data
+	a.handles = append(a.handles, 0)
+	copy(a.handles[index+1:], a.handles[index:])
+	a.handles[index] = h
+	if index == len(a.handles)-1 && !a.dirty {
+		a.index[h] = index
+	} else {
+		a.dirty = true
+	}
+	a.NotifyObservers(HandleInserted{h, index, data})
+	return h, nil
+}
+
+// Appends data to the end of the array, returning its handle.
+func (a *HandleArray) Add(data interface{}) Handle {
+	h, _ := a.InsertHandle(len(a.data), data)
+	return h
+}
+
+func (a *HandleArray) Insert(index int, data interface{}) error {
+	_, err := a.InsertHandle(index, data)
+	return err
+}
+
+func (a *HandleArray) Remove(index int) (interface{}, error) {
+	if index < 0 || index >= len(a.data) {
+		return nil, ErrIndexOOB
+	}
+	var (
+		data = a.data[index]
+		h    = a.handles[index]
+	)
+	a.data = append(a.data[:index], a.data[index+1:]...)
+	a.handles = append(a.handles[:index], a.handles[index+1:]...)
+	if index == len(a.handles) && !a.dirty {
+		delete(a.index, h)
+	} else {
+		a.dirty = true
+	}
+	a.NotifyObservers(HandleRemoved{h, index, data})
+	return data, nil
+}
+
+// Removes the element with the given handle.
+func (a *HandleArray) RemoveHandle(h Handle) (interface{}, error) {
+	i := a.IndexOf(h)
+	if i == -1 {
+		return nil, ErrNoSuchHandle
+	}
+	return a.Remove(i)
+}
+
+func (a *HandleArray) Get(index int) interface{} {
+	return a.data[index]
+}
+
+// Returns the element with the given handle, and whether
+// there is such an element.
+func (a *HandleArray) GetHandle(h Handle) (interface{}, bool) {
+	if i := a.IndexOf(h); i != -1 {
+		return a.data[i], true
+	}
+	return nil, false
+}
+
+func (a *HandleArray) Len() int {
+	return len(a.data)
+}
+
+// Sorts the elements, and their handles along with them.
+func (a *HandleArray) sort(cmp Compare, stable bool) {
+	s := handleSorter{a, cmp}
+	if stable {
+		sort.Stable(s)
+	} else {
+		sort.Sort(s)
+	}
+	a.dirty = true
+	a.NotifyObservers(ReorderedData{})
+}
+
+func (a *HandleArray) Sort(cmp Compare) error {
+	a.sort(cmp, false)
+	return nil
+}
+
+func (a *HandleArray) StableSort(cmp Compare) error {
+	a.sort(cmp, true)
+	return nil
+}
+
+func (a *HandleArray) IsSorted(cmp Compare) bool {
+	return sort.IsSorted(handleSorter{a, cmp})
+}
+
+func (a *HandleArray) ToSlice() []interface{} {
+	return append([]interface{}(nil), a.data...)
+}
+
+func (a *HandleArray) CopyInto(dst []interface{}) int {
+	return copy(dst, a.data)
+}
+
+// Sorts the data and handles of a HandleArray in tandem.
+type handleSorter struct {
+	a   *HandleArray
+	cmp Compare
+}
+
+func (s handleSorter) Len() int {
+	return len(s.a.data)
+}
+
+func (s handleSorter) Less(i, j int) bool {
+	return s.cmp(s.a.data[i], s.a.data[j]) == Less
+}
+
+func (s handleSorter) Swap(i, j int) {
+	s.a.data[i], s.a.data[j] = s.a.data[j], s.a.data[i]
+	s.a.handles[i], s.a.handles[j] = s.a.handles[j], s.a.handles[i]
+}
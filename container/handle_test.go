@@ -0,0 +1,67 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package container_test
+
+import (
+	"github.com/quarnster/util/container"
+	"reflect"
+	"testing"
+)
+
+func TestHandleArray(t *testing.T) {
+	var (
+		a   = container.NewHandleArray()
+		rec eventRecorder
+		cmp = func(a, b interface{}) container.ComparisonResult {
+			return compareInts(a.(int), b.(int))
+		}
+	)
+	a.AddObserver(&rec)
+	h3 := a.Add(3)
+	h1 := a.Add(1)
+	h2, err := a.InsertHandle(0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h1 == h2 || h1 == h3 || h2 == h3 || h1 == 0 {
+		t.Errorf("Expected unique non-zero handles, got %d %d %d", h1, h2, h3)
+	}
+	if i := a.IndexOf(h3); i != 1 {
+		t.Errorf("Expected 3 to have moved to index 1, not %d", i)
+	}
+	if err := a.Sort(cmp); err != nil {
+		t.Fatal(err)
+	}
+	for i, h := range []container.Handle{h1, h2, h3} {
+		if a.IndexOf(h) != i || a.HandleAt(i) != h || a.Get(i) != i+1 {
+			t.Errorf("%d: Unexpected handle %d at index %d", i, h, a.IndexOf(h))
+		}
+	}
+	if v, ok := a.GetHandle(h2); !ok || v != 2 {
+		t.Errorf("Expected 2, but got %v", v)
+	}
+	if v, err := a.RemoveHandle(h2); err != nil || v != 2 {
+		t.Errorf("Expected 2 to be removed, got %v, %v", v, err)
+	}
+	if _, err := a.RemoveHandle(h2); err != container.ErrNoSuchHandle {
+		t.Errorf("Expected %s, but got %v", container.ErrNoSuchHandle, err)
+	}
+	if _, ok := a.GetHandle(h2); ok {
+		t.Error("Didn't expect to find a removed handle")
+	}
+	if a.IndexOf(h3) != 1 {
+		t.Errorf("Expected 3 at index 1, not %d", a.IndexOf(h3))
+	}
+	exp := []interface{}{
+		container.HandleInserted{h3, 0, 3},
+		container.HandleInserted{h1, 1, 1},
+		container.HandleInserted{h2, 0, 2},
+		container.ReorderedData{},
+		container.HandleRemoved{h2, 1, 2},
+	}
+	if !reflect.DeepEqual(rec.events, exp) {
+		t.Errorf("Expected the events %v, but got %v", exp, rec.events)
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package container
+
+import "iter"
+
+type (
+	// A half open interval [Start, End). Intervals where End isn't
+	// greater than Start are empty, and contain no points.
+	Interval struct {
+		Start, End int
+	}
+
+	// An interval stored in an IntervalTree, and its payload.
+	IntervalEntry[T comparable] struct {
+		Interval
+		Payload T
+	}
+
+	// IntervalTree stores intervals with a payload each, and finds the
+	// intervals containing a point or overlapping another interval in
+	// O(log n + m) time, where m is the number of intervals found. The
+	// same interval may be stored several times with different
+	// payloads. Query results are ordered by Start, then by End.
+	IntervalTree[T comparable] struct {
+		root *intervalNode[T]
+		len  int
+	}
+
+	// A node of the AVL tree backing an IntervalTree, sorted by
+	// interval and augmented with the largest End of its subtree.
+	intervalNode[T comparable] struct {
+		IntervalEntry[T]
+		children [2]*intervalNode[T]
+		height   int
+		maxEnd   int
+	}
+)
+
+// Returns whether p lies within the interval.
+func (i Interval) Contains(p int) bool {
+	return i.Start <= p && p < i.End
+}
+
+// Returns whether the interval and o share at least one point.
+func (i Interval) Overlaps(o Interval) bool {
+	return i.Start < o.End && o.Start < i.End && !i.empty() && !o.empty()
+}
+
+func (i Interval) empty() bool {
+	return i.End <= i.Start
+}
+
+func (i Interval) compare(o Interval) ComparisonResult {
+	switch {
+	case i.Start < o.Start, i.Start == o.Start && i.End < o.End:
+		return Less
+	case i == o:
+		return Equal
+	}
+	return Greater
+}
+
+func (n *intervalNode[T]) getHeight() int {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+// Updates the height and maxEnd of n from its children.
+func (n *intervalNode[T]) update() {
+	n.height, n.maxEnd = 1, n.End
+	for _, c := range n.children {
+		if c == nil {
+			continue
+		}
+		if c.height+1 > n.height {
+			n.height = c.height + 1
+		}
+		if c.maxEnd > n.maxEnd {
+			n.maxEnd = c.maxEnd
+		}
+	}
+}
+
+// Rotates the subtree rooted at n so that its child on the given side
+// becomes the new root, which is returned.
+func (n *intervalNode[T]) rotate(side int) *intervalNode[T] {
+	c := n.children[side]
+	n.children[side] = c.children[1-side]
+	c.children[1-side] = n
+	n.update()
+	c.update()
+	return c
+}
+
+func (n *intervalNode[T]) balance() *intervalNode[T] {
+	n.update()
+	switch d := n.children[0].getHeight() - n.children[1].getHeight(); {
+	case d > 1:
+		if l := n.children[0]; l.children[1].getHeight() > l.children[0].getHeight() {
+			n.children[0] = l.rotate(1)
+		}
+		return n.rotate(0)
+	case d < -1:
+		if r := n.children[1]; r.children[0].getHeight() > r.children[1].getHeight() {
+			n.children[1] = r.rotate(0)
+		}
+		return n.rotate(1)
+	}
+	return n
+}
+
+func (n *intervalNode[T]) insert(e IntervalEntry[T]) *intervalNode[T] {
+	if n == nil {
+		ret := &intervalNode[T]{IntervalEntry: e}
+		ret.update()
+		return ret
+	}
+	side := 1
+	if e.compare(n.Interval) == Less {
+		side = 0
+	}
+	n.children[side] = n.children[side].insert(e)
+	return n.balance()
+}
+
+// Removes the smallest node of the subtree rooted at n, returning
+// the new root of the subtree and the removed node.
+func (n *intervalNode[T]) removeMin() (*intervalNode[T], *intervalNode[T]) {
+	if n.children[0] == nil {
+		return n.children[1], n
+	}
+	var min *intervalNode[T]
+	n.children[0], min = n.children[0].removeMin()
+	return n.balance(), min
+}
+
+func (n *intervalNode[T]) remove(e IntervalEntry[T]) (*intervalNode[T], bool) {
+	if n == nil {
+		return nil, false
+	}
+	var ok bool
+	switch e.compare(n.Interval) {
+	case Less:
+		n.children[0], ok = n.children[0].remove(e)
+	case Greater:
+		n.children[1], ok = n.children[1].remove(e)
+	default:
+		if n.Payload != e.Payload {
+			// Equal intervals can end up on either side
+			if n.children[0], ok = n.children[0].remove(e); !ok {
+				n.children[1], ok = n.children[1].remove(e)
+			}
+			break
+		}
+		if n.children[0] == nil || n.children[1] == nil {
+			if n.children[0] != nil {
+				return n.children[0], true
+			}
+			return n.children[1], true
+		}
+		var min *intervalNode[T]
+		n.children[1], min = n.children[1].removeMin()
+		min.children = n.children
+		return min.balance(), true
+	}
+	return n.balance(), ok
+}
+
+// Appends the entries of the subtree rooted at n overlapping iv to ret.
+func (n *intervalNode[T]) overlapping(iv Interval, ret []IntervalEntry[T]) []IntervalEntry[T] {
+	if n == nil || n.maxEnd <= iv.Start {
+		// Nothing in this subtree ends after the query starts
+		return ret
+	}
+	ret = n.children[0].overlapping(iv, ret)
+	if n.Overlaps(iv) {
+		ret = append(ret, n.IntervalEntry)
+	}
+	if n.Start < iv.End {
+		ret = n.children[1].overlapping(iv, ret)
+	}
+	return ret
+}
+
+func (n *intervalNode[T]) all(yield func(Interval, T) bool) bool {
+	if n == nil {
+		return true
+	}
+	return n.children[0].all(yield) && yield(n.Interval, n.Payload) && n.children[1].all(yield)
+}
+
+// Creates a new empty IntervalTree.
+func NewIntervalTree[T comparable]() *IntervalTree[T] {
+	return &IntervalTree[T]{}
+}
+
+// Returns the number of intervals in the tree.
+func (t *IntervalTree[T]) Len() int {
+	return t.len
+}
+
+// Adds the interval iv with the given payload to the tree.
+func (t *IntervalTree[T]) Insert(iv Interval, payload T) {
+	t.root = t.root.insert(IntervalEntry[T]{iv, payload})
+	t.len++
+}
+
+// Removes the interval iv with the given payload from the tree,
+// returning whether it was found.
+func (t *IntervalTree[T]) Delete(iv Interval, payload T) bool {
+	var ok bool
+	if t.root, ok = t.root.remove(IntervalEntry[T]{iv, payload}); ok {
+		t.len--
+	}
+	return ok
+}
+
+// Returns the intervals containing the point p.
+func (t *IntervalTree[T]) Stab(p int) []IntervalEntry[T] {
+	return t.root.overlapping(Interval{p, p + 1}, nil)
+}
+
+// Returns the intervals overlapping iv.
+func (t *IntervalTree[T]) Overlapping(iv Interval) []IntervalEntry[T] {
+	if iv.empty() {
+		return nil
+	}
+	return t.root.overlapping(iv, nil)
+}
+
+// Returns all the intervals in the tree and their payloads, in order.
+func (t *IntervalTree[T]) All() iter.Seq2[Interval, T] {
+	return func(yield func(Interval, T) bool) {
+		t.root.all(yield)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package container_test
+
+import (
+	"github.com/quarnster/util/container"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestIntervalTree(t *testing.T) {
+	tr := container.NewIntervalTree[string]()
+	tr.Insert(container.Interval{0, 10}, "a")
+	tr.Insert(container.Interval{5, 15}, "b")
+	tr.Insert(container.Interval{20, 30}, "c")
+	tr.Insert(container.Interval{5, 15}, "d")
+	tr.Insert(container.Interval{7, 7}, "empty")
+
+	payloads := func(es []container.IntervalEntry[string]) (ret []string) {
+		for _, e := range es {
+			ret = append(ret, e.Payload)
+		}
+		return
+	}
+	tests := []struct {
+		point int
+		exp   []string
+	}{
+		{-1, nil},
+		{0, []string{"a"}},
+		{7, []string{"a", "b", "d"}},
+		{10, []string{"b", "d"}},
+		{15, nil},
+		{29, []string{"c"}},
+	}
+	for _, test := range tests {
+		if got := payloads(tr.Stab(test.point)); !reflect.DeepEqual(got, test.exp) {
+			t.Errorf("Stab(%d): Expected %v, got %v", test.point, test.exp, got)
+		}
+	}
+	if got, exp := payloads(tr.Overlapping(container.Interval{12, 21})), []string{"b", "d", "c"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected %v, got %v", exp, got)
+	}
+	if tr.Delete(container.Interval{5, 15}, "x") {
+		t.Error("Deleted an interval that isn't in the tree")
+	}
+	if !tr.Delete(container.Interval{5, 15}, "b") {
+		t.Error("Expected the interval to be deleted")
+	}
+	if got, exp := payloads(tr.Stab(7)), []string{"a", "d"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected %v, got %v", exp, got)
+	}
+	if tr.Len() != 4 {
+		t.Errorf("Expected 4 intervals, got %d", tr.Len())
+	}
+}
+
+func TestIntervalTreeRandom(t *testing.T) {
+	var (
+		rnd = rand.New(rand.NewSource(1337))
+		tr  = container.NewIntervalTree[int]()
+		ref []container.IntervalEntry[int]
+	)
+	check := func() {
+		from := rnd.Intn(1000)
+		iv := container.Interval{from, from + rnd.Intn(100)}
+		var exp []int
+		for _, e := range ref {
+			if e.Overlaps(iv) {
+				exp = append(exp, e.Payload)
+			}
+		}
+		var got []int
+		for _, e := range tr.Overlapping(iv) {
+			got = append(got, e.Payload)
+		}
+		sort.Ints(exp)
+		sort.Ints(got)
+		if !reflect.DeepEqual(got, exp) {
+			t.Fatalf("Overlapping(%v): Expected %v, got %v", iv, exp, got)
+		}
+	}
+	for i := 0; i < 2000; i++ {
+		if len(ref) > 0 && rnd.Intn(3) == 0 {
+			j := rnd.Intn(len(ref))
+			if !tr.Delete(ref[j].Interval, ref[j].Payload) {
+				t.Fatalf("Unable to delete %v", ref[j])
+			}
+			ref = append(ref[:j], ref[j+1:]...)
+		} else {
+			from := rnd.Intn(1000)
+			e := container.IntervalEntry[int]{container.Interval{from, from + rnd.Intn(50)}, i}
+			tr.Insert(e.Interval, e.Payload)
+			ref = append(ref, e)
+		}
+		check()
+	}
+	if tr.Len() != len(ref) {
+		t.Errorf("Expected %d intervals, got %d", len(ref), tr.Len())
+	}
+	last := container.Interval{-1, -1}
+	for iv := range tr.All() {
+		if iv.Start < last.Start || iv.Start == last.Start && iv.End < last.End {
+			t.Fatalf("%v is ordered after %v", iv, last)
+		}
+		last = iv
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"github.com/quarnster/util"
+	"sync"
+)
+
+type (
+	// Sent by an ObservableMap when one of its keys is set or deleted.
+	// Old is the zero value if the key didn't exist before and New
+	// is the zero value if the key was deleted.
+	MapChange[K comparable, V any] struct {
+		Key     K
+		Old     V
+		New     V
+		Existed bool
+		Deleted bool
+	}
+
+	// ObservableMap is a map which notifies its observers with a
+	// MapChange whenever a key is set or deleted.
+	ObservableMap[K comparable, V any] struct {
+		util.BasicObservable
+		data map[K]V
+	}
+
+	// LockedObservableMap is an ObservableMap which is safe for
+	// concurrent use. Changes are delivered to the observers in the
+	// order they were made, and the map is not locked while the
+	// observers are notified, so they're free to read from it.
+	// They must however not modify the map, nor add or remove
+	// observers, from within their Changed callback.
+	LockedObservableMap[K comparable, V any] struct {
+		m      ObservableMap[K, V]
+		lock   sync.RWMutex
+		notify sync.Mutex
+	}
+)
+
+// Creates a new empty ObservableMap.
+func NewObservableMap[K comparable, V any]() *ObservableMap[K, V] {
+	return &ObservableMap[K, V]{data: make(map[K]V)}
+}
+
+// Returns the value of key, and whether the key exists in the map.
+func (m *ObservableMap[K, V]) Get(key K) (V, bool) {
+	v, ok := m.data[key]
+	return v, ok
+}
+
+func (m *ObservableMap[K, V]) set(key K, value V) MapChange[K, V] {
+	if m.data == nil {
+		m.data = make(map[K]V)
+	}
+	old, existed := m.data[key]
+	m.data[key] = value
+	return MapChange[K, V]{Key: key, Old: old, New: value, Existed: existed}
+}
+
+func (m *ObservableMap[K, V]) delete(key K) (MapChange[K, V], bool) {
+	old, existed := m.data[key]
+	if !existed {
+		return MapChange[K, V]{}, false
+	}
+	delete(m.data, key)
+	return MapChange[K, V]{Key: key, Old: old, Existed: true, Deleted: true}, true
+}
+
+func (m *ObservableMap[K, V]) Set(key K, value V) {
+	m.NotifyObservers(m.set(key, value))
+}
+
+// Deletes key from the map, returning whether it existed.
+// Observers are only notified if it did.
+func (m *ObservableMap[K, V]) Delete(key K) bool {
+	c, ok := m.delete(key)
+	if ok {
+		m.NotifyObservers(c)
+	}
+	return ok
+}
+
+func (m *ObservableMap[K, V]) Len() int {
+	return len(m.data)
+}
+
+// Returns the keys of the map, in no particular order.
+func (m *ObservableMap[K, V]) Keys() []K {
+	ret := make([]K, 0, len(m.data))
+	for k := range m.data {
+		ret = append(ret, k)
+	}
+	return ret
+}
+
+// Creates a new empty LockedObservableMap.
+func NewLockedObservableMap[K comparable, V any]() *LockedObservableMap[K, V] {
+	return &LockedObservableMap[K, V]{m: ObservableMap[K, V]{data: make(map[K]V)}}
+}
+
+func (m *LockedObservableMap[K, V]) AddObserver(obs util.Observer) {
+	m.notify.Lock()
+	defer m.notify.Unlock()
+	m.m.AddObserver(obs)
+}
+
+func (m *LockedObservableMap[K, V]) RemoveObserver(obs util.Observer) {
+	m.notify.Lock()
+	defer m.notify.Unlock()
+	m.m.RemoveObserver(obs)
+}
+
+func (m *LockedObservableMap[K, V]) NotifyObservers(data interface{}) {
+	m.notify.Lock()
+	defer m.notify.Unlock()
+	m.m.NotifyObservers(data)
+}
+
+func (m *LockedObservableMap[K, V]) Get(key K) (V, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.m.Get(key)
+}
+
+func (m *LockedObservableMap[K, V]) Set(key K, value V) {
+	m.notify.Lock()
+	defer m.notify.Unlock()
+	m.lock.Lock()
+	c := m.m.set(key, value)
+	m.lock.Unlock()
+	m.m.NotifyObservers(c)
+}
+
+func (m *LockedObservableMap[K, V]) Delete(key K) bool {
+	m.notify.Lock()
+	defer m.notify.Unlock()
+	m.lock.Lock()
+	c, ok := m.m.delete(key)
+	m.lock.Unlock()
+	if ok {
+		m.m.NotifyObservers(c)
+	}
+	return ok
+}
+
+func (m *LockedObservableMap[K, V]) Len() int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.m.Len()
+}
+
+func (m *LockedObservableMap[K, V]) Keys() []K {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.m.Keys()
+}
@@ -0,0 +1,101 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"sync"
+	"testing"
+)
+
+type mapRecorder struct {
+	changes []MapChange[string, int]
+}
+
+func (r *mapRecorder) Changed(data interface{}) {
+	r.changes = append(r.changes, data.(MapChange[string, int]))
+}
+
+func TestObservableMap(t *testing.T) {
+	var (
+		m   = NewObservableMap[string, int]()
+		rec mapRecorder
+	)
+	m.AddObserver(&rec)
+	m.Set("a", 1)
+	m.Set("a", 2)
+	m.Set("b", 3)
+	if !m.Delete("a") {
+		t.Error("Expected a to be deleted")
+	}
+	if m.Delete("a") {
+		t.Error("Didn't expect a to be deleted twice")
+	}
+	exp := []MapChange[string, int]{
+		{Key: "a", New: 1},
+		{Key: "a", Old: 1, New: 2, Existed: true},
+		{Key: "b", New: 3},
+		{Key: "a", Old: 2, Existed: true, Deleted: true},
+	}
+	if len(rec.changes) != len(exp) {
+		t.Fatalf("Expected %d changes, but got %d: %v", len(exp), len(rec.changes), rec.changes)
+	}
+	for i := range exp {
+		if rec.changes[i] != exp[i] {
+			t.Errorf("%d: Expected %+v, but got %+v", i, exp[i], rec.changes[i])
+		}
+	}
+	if v, ok := m.Get("b"); !ok || v != 3 {
+		t.Errorf("Expected 3, but got %d, %v", v, ok)
+	}
+	if _, ok := m.Get("a"); ok {
+		t.Error("Didn't expect a to exist")
+	}
+	if l := m.Len(); l != 1 {
+		t.Errorf("Expected a length of 1, but got %d", l)
+	}
+	var zero ObservableMap[string, int]
+	zero.Set("x", 1)
+	if k := zero.Keys(); len(k) != 1 || k[0] != "x" {
+		t.Errorf("Unexpected keys %v", k)
+	}
+}
+
+func TestLockedObservableMap(t *testing.T) {
+	var (
+		m   = NewLockedObservableMap[string, int]()
+		rec mapRecorder
+		wg  sync.WaitGroup
+	)
+	m.AddObserver(&rec)
+	keys := []string{"a", "b", "c", "d"}
+	for _, k := range keys {
+		wg.Add(1)
+		go func(k string) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				m.Set(k, i)
+				m.Get(k)
+			}
+			m.Delete(k)
+		}(k)
+	}
+	wg.Wait()
+	if l := m.Len(); l != 0 {
+		t.Errorf("Expected an empty map, but got %d keys", l)
+	}
+	if exp := len(keys) * 101; len(rec.changes) != exp {
+		t.Errorf("Expected %d changes, but got %d", exp, len(rec.changes))
+	}
+	last := make(map[string]int)
+	for _, c := range rec.changes {
+		if c.Deleted {
+			continue
+		}
+		if v, ok := last[c.Key]; ok && c.Old != v {
+			t.Errorf("Changes to %s delivered out of order: %+v after %d", c.Key, c, v)
+		}
+		last[c.Key] = c.New
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"fmt"
+	"sort"
+)
+
+var (
+	ErrNoSuchIndex    = fmt.Errorf("No index by that name")
+	ErrDuplicateIndex = fmt.Errorf("An index by that name already exists")
+	ErrDuplicateItem  = fmt.Errorf("The item is already in the container")
+)
+
+type (
+	// MultiIndex is a set of items that can be queried through several
+	// named indexes, which are all kept up to date as items are
+	// inserted and removed:
+	//
+	//	users := NewMultiIndex[*User]()
+	//	users.AddHashIndex("email", func(u *User) interface{} { return u.Email })
+	//	users.AddOrderedIndex("age", func(a, b *User) ComparisonResult { ... })
+	//	users.Insert(u)
+	//	found, _ := users.Lookup("email", "x@example.com")
+	//
+	// The keys of an item must not change while it's in the container.
+	// To change them, remove the item, modify it and insert it again.
+	MultiIndex[T comparable] struct {
+		// The items, mapped to the order they were inserted in
+		items   map[T]uint64
+		seq     uint64
+		hashed  map[string]*hashIndex[T]
+		ordered map[string]*orderedIndex[T]
+	}
+
+	// An index grouping items by a key.
+	hashIndex[T comparable] struct {
+		key   func(T) interface{}
+		items map[interface{}][]T
+	}
+
+	// An index keeping items sorted.
+	orderedIndex[T comparable] struct {
+		cmp   func(a, b T) ComparisonResult
+		items []T
+	}
+)
+
+// Creates a new empty MultiIndex.
+func NewMultiIndex[T comparable]() *MultiIndex[T] {
+	return &MultiIndex[T]{
+		items:   make(map[T]uint64),
+		hashed:  make(map[string]*hashIndex[T]),
+		ordered: make(map[string]*orderedIndex[T]),
+	}
+}
+
+// Returns the items in the order they were inserted.
+func (m *MultiIndex[T]) inOrder() []T {
+	ret := make([]T, 0, len(m.items))
+	for it := range m.items {
+		ret = append(ret, it)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return m.items[ret[i]] < m.items[ret[j]]
+	})
+	return ret
+}
+
+func (m *MultiIndex[T]) hasIndex(name string) bool {
+	_, h := m.hashed[name]
+	_, o := m.ordered[name]
+	return h || o
+}
+
+// Adds an index grouping the items by the key returned by key,
+// which must be comparable. Items already in the container are
+// added to the new index.
+func (m *MultiIndex[T]) AddHashIndex(name string, key func(T) interface{}) error {
+	if m.hasIndex(name) {
+		return ErrDuplicateIndex
+	}
+	idx := &hashIndex[T]{key: key, items: make(map[interface{}][]T)}
+	for _, it := range m.inOrder() {
+		idx.insert(it)
+	}
+	m.hashed[name] = idx
+	return nil
+}
+
+// Adds an index keeping the items sorted according to cmp. Items
+// already in the container are added to the new index.
+func (m *MultiIndex[T]) AddOrderedIndex(name string, cmp func(a, b T) ComparisonResult) error {
+	if m.hasIndex(name) {
+		return ErrDuplicateIndex
+	}
+	idx := &orderedIndex[T]{cmp: cmp}
+	for _, it := range m.inOrder() {
+		idx.insert(it)
+	}
+	m.ordered[name] = idx
+	return nil
+}
+
+// Inserts item into the container and all of its indexes.
+func (m *MultiIndex[T]) Insert(item T) error {
+	if _, ok := m.items[item]; ok {
+		return ErrDuplicateItem
+	}
+	m.items[item] = m.seq
+	m.seq++
+	for _, idx := range m.hashed {
+		idx.insert(item)
+	}
+	for _, idx := range m.ordered {
+		idx.insert(item)
+	}
+	return nil
+}
+
+// Removes item from the container and all of its indexes, returning
+// whether it was in the container.
+func (m *MultiIndex[T]) Remove(item T) bool {
+	if _, ok := m.items[item]; !ok {
+		return false
+	}
+	delete(m.items, item)
+	for _, idx := range m.hashed {
+		idx.remove(item)
+	}
+	for _, idx := range m.ordered {
+		idx.remove(item)
+	}
+	return true
+}
+
+func (m *MultiIndex[T]) Contains(item T) bool {
+	_, ok := m.items[item]
+	return ok
+}
+
+func (m *MultiIndex[T]) Len() int {
+	return len(m.items)
+}
+
+// Returns the items having the given key in the named hash index,
+// in the order they were inserted.
+func (m *MultiIndex[T]) Lookup(index string, key interface{}) ([]T, error) {
+	idx, ok := m.hashed[index]
+	if !ok {
+		return nil, ErrNoSuchIndex
+	}
+	return append([]T(nil), idx.items[key]...), nil
+}
+
+// Returns all the items, sorted according to the named ordered index.
+// Equal items are in the order they were inserted.
+func (m *MultiIndex[T]) Ordered(index string) ([]T, error) {
+	idx, ok := m.ordered[index]
+	if !ok {
+		return nil, ErrNoSuchIndex
+	}
+	return append([]T(nil), idx.items...), nil
+}
+
+// Returns the items ordered at or after from, but before to, in the
+// named ordered index.
+func (m *MultiIndex[T]) Range(index string, from, to T) ([]T, error) {
+	idx, ok := m.ordered[index]
+	if !ok {
+		return nil, ErrNoSuchIndex
+	}
+	lo, hi := idx.lowerBound(from), idx.lowerBound(to)
+	if hi < lo {
+		hi = lo
+	}
+	return append([]T(nil), idx.items[lo:hi]...), nil
+}
+
+func (h *hashIndex[T]) insert(item T) {
+	k := h.key(item)
+	h.items[k] = append(h.items[k], item)
+}
+
+func (h *hashIndex[T]) remove(item T) {
+	k := h.key(item)
+	s := without(h.items[k], item)
+	if len(s) == 0 {
+		delete(h.items, k)
+	} else {
+		h.items[k] = s
+	}
+}
+
+func (o *orderedIndex[T]) lowerBound(item T) int {
+	return sort.Search(len(o.items), func(i int) bool {
+		return o.cmp(o.items[i], item) != Less
+	})
+}
+
+func (o *orderedIndex[T]) upperBound(item T) int {
+	return sort.Search(len(o.items), func(i int) bool {
+		return o.cmp(o.items[i], item) == Greater
+	})
+}
+
+func (o *orderedIndex[T]) insert(item T) {
+	i := o.upperBound(item)
+	var zero T
+	o.items = append(o.items, zero)
+	copy(o.items[i+1:], o.items[i:])
+	o.items[i] = item
+}
+
+func (o *orderedIndex[T]) remove(item T) {
+	for i, hi := o.lowerBound(item), o.upperBound(item); i < hi; i++ {
+		if o.items[i] == item {
+			o.items = append(o.items[:i], o.items[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package container_test
+
+import (
+	"github.com/quarnster/util/container"
+	"reflect"
+	"testing"
+)
+
+type user struct {
+	name string
+	team string
+	age  int
+}
+
+func TestMultiIndex(t *testing.T) {
+	var (
+		m     = container.NewMultiIndex[*user]()
+		alice = &user{"alice", "red", 30}
+		bob   = &user{"bob", "blue", 25}
+		carol = &user{"carol", "red", 25}
+		dave  = &user{"dave", "blue", 40}
+		byAge = func(a, b *user) container.ComparisonResult {
+			return compareInts(a.age, b.age)
+		}
+	)
+	m.AddHashIndex("team", func(u *user) interface{} { return u.team })
+	for _, u := range []*user{alice, bob, carol} {
+		if err := m.Insert(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.Insert(bob); err != container.ErrDuplicateItem {
+		t.Errorf("Expected %s, but got %v", container.ErrDuplicateItem, err)
+	}
+	// Added after the fact, so populated from the existing items
+	if err := m.AddOrderedIndex("age", byAge); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddHashIndex("age", nil); err != container.ErrDuplicateIndex {
+		t.Errorf("Expected %s, but got %v", container.ErrDuplicateIndex, err)
+	}
+	m.Insert(dave)
+
+	check := func(what string, got []*user, err error, exp ...*user) {
+		t.Helper()
+		if err != nil {
+			t.Errorf("%s: %s", what, err)
+		} else if !reflect.DeepEqual(got, exp) {
+			t.Errorf("%s: Expected %v, but got %v", what, exp, got)
+		}
+	}
+	got, err := m.Lookup("team", "red")
+	check("red", got, err, alice, carol)
+	got, err = m.Ordered("age")
+	check("age", got, err, bob, carol, alice, dave)
+	got, err = m.Range("age", &user{age: 25}, &user{age: 35})
+	check("range", got, err, bob, carol, alice)
+
+	if !m.Remove(carol) || m.Remove(carol) {
+		t.Error("Expected carol to be removed exactly once")
+	}
+	got, err = m.Lookup("team", "red")
+	check("red", got, err, alice)
+	got, err = m.Ordered("age")
+	check("age", got, err, bob, alice, dave)
+	if m.Len() != 3 || m.Contains(carol) || !m.Contains(dave) {
+		t.Errorf("Unexpected contents: %d %v %v", m.Len(), m.Contains(carol), m.Contains(dave))
+	}
+	if _, err := m.Lookup("age", 25); err != container.ErrNoSuchIndex {
+		t.Errorf("Expected %s, but got %v", container.ErrNoSuchIndex, err)
+	}
+}
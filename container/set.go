@@ -0,0 +1,152 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+)
+
+// Sets with at most this many elements are kept in a slice rather
+// than in a map, as a linear search is faster than hashing for so
+// few elements and avoids allocating the map.
+const smallSetSize = 8
+
+type (
+	// Set is a set of ints, strings or other ordered values which,
+	// unlike a BasicArray, stores its elements without boxing them in
+	// interface{} values. The zero value is an empty set ready to use.
+	Set[T cmp.Ordered] struct {
+		small []T
+		large map[T]struct{}
+	}
+
+	IntSet    = Set[int]
+	StringSet = Set[string]
+)
+
+// Creates a new set holding the given values.
+func NewSet[T cmp.Ordered](values ...T) *Set[T] {
+	s := &Set[T]{}
+	for _, v := range values {
+		s.Add(v)
+	}
+	return s
+}
+
+// Creates a new IntSet holding the given values.
+func NewIntSet(values ...int) *IntSet {
+	return NewSet(values...)
+}
+
+// Creates a new StringSet holding the given values.
+func NewStringSet(values ...string) *StringSet {
+	return NewSet(values...)
+}
+
+func (s *Set[T]) Len() int {
+	if s.large != nil {
+		return len(s.large)
+	}
+	return len(s.small)
+}
+
+func (s *Set[T]) Contains(v T) bool {
+	if s.large != nil {
+		_, ok := s.large[v]
+		return ok
+	}
+	return slices.Contains(s.small, v)
+}
+
+// Adds v to the set, returning whether it wasn't already in it.
+func (s *Set[T]) Add(v T) bool {
+	if s.Contains(v) {
+		return false
+	}
+	if s.large == nil && len(s.small) < smallSetSize {
+		s.small = append(s.small, v)
+		return true
+	}
+	if s.large == nil {
+		s.large = make(map[T]struct{}, 2*smallSetSize)
+		for _, v := range s.small {
+			s.large[v] = struct{}{}
+		}
+		s.small = nil
+	}
+	s.large[v] = struct{}{}
+	return true
+}
+
+// Removes v from the set, returning whether it was in it.
+func (s *Set[T]) Remove(v T) bool {
+	if s.large != nil {
+		_, ok := s.large[v]
+		delete(s.large, v)
+		return ok
+	}
+	if i := slices.Index(s.small, v); i != -1 {
+		s.small = slices.Delete(s.small, i, i+1)
+		return true
+	}
+	return false
+}
+
+// Returns the elements of the set in no particular order.
+func (s *Set[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		if s.large == nil {
+			for _, v := range s.small {
+				if !yield(v) {
+					return
+				}
+			}
+			return
+		}
+		for v := range s.large {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Returns the elements of the set in ascending order.
+func (s *Set[T]) Sorted() []T {
+	ret := make([]T, 0, s.Len())
+	for v := range s.All() {
+		ret = append(ret, v)
+	}
+	slices.Sort(ret)
+	return ret
+}
+
+// Returns a new set holding the elements of both s and other.
+func (s *Set[T]) Union(other *Set[T]) *Set[T] {
+	ret := &Set[T]{}
+	for v := range s.All() {
+		ret.Add(v)
+	}
+	for v := range other.All() {
+		ret.Add(v)
+	}
+	return ret
+}
+
+// Returns a new set holding the elements found in both s and other.
+func (s *Set[T]) Intersect(other *Set[T]) *Set[T] {
+	if other.Len() < s.Len() {
+		s, other = other, s
+	}
+	ret := &Set[T]{}
+	for v := range s.All() {
+		if other.Contains(v) {
+			ret.Add(v)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,77 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package container_test
+
+import (
+	"github.com/quarnster/util/container"
+	"reflect"
+	"testing"
+)
+
+func TestIntSet(t *testing.T) {
+	var s container.IntSet
+	// Enough to grow out of the small set representation
+	for i := 0; i < 20; i += 2 {
+		if !s.Add(i) {
+			t.Errorf("Expected %d to be added", i)
+		}
+	}
+	if s.Add(4) {
+		t.Error("Didn't expect 4 to be added twice")
+	}
+	if s.Len() != 10 || !s.Contains(18) || s.Contains(3) {
+		t.Errorf("Unexpected contents: %v", s.Sorted())
+	}
+	if !s.Remove(18) || s.Remove(18) || s.Contains(18) {
+		t.Error("Expected 18 to be removed exactly once")
+	}
+	var (
+		odd = container.NewIntSet(1, 3, 5, 6)
+		u   = s.Union(odd)
+		i   = s.Intersect(odd)
+	)
+	if exp := []int{0, 1, 2, 3, 4, 5, 6, 8, 10, 12, 14, 16}; !reflect.DeepEqual(u.Sorted(), exp) {
+		t.Errorf("Expected the union %v, but got %v", exp, u.Sorted())
+	}
+	if exp := []int{6}; !reflect.DeepEqual(i.Sorted(), exp) {
+		t.Errorf("Expected the intersection %v, but got %v", exp, i.Sorted())
+	}
+}
+
+func TestStringSet(t *testing.T) {
+	var (
+		a = container.NewStringSet("b", "a", "c")
+		b = container.NewStringSet("c", "d")
+	)
+	if !a.Remove("b") || a.Remove("x") {
+		t.Error("Unexpected result removing from a small set")
+	}
+	if exp := []string{"a", "c", "d"}; !reflect.DeepEqual(a.Union(b).Sorted(), exp) {
+		t.Errorf("Expected %v, but got %v", exp, a.Union(b).Sorted())
+	}
+	if exp := []string{"c"}; !reflect.DeepEqual(a.Intersect(b).Sorted(), exp) {
+		t.Errorf("Expected %v, but got %v", exp, a.Intersect(b).Sorted())
+	}
+	n := 0
+	for range a.All() {
+		n++
+	}
+	if n != a.Len() {
+		t.Errorf("Expected to iterate over %d elements, but got %d", a.Len(), n)
+	}
+}
+
+func BenchmarkSmallIntSet(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var s container.IntSet
+		for j := 0; j < 4; j++ {
+			s.Add(j)
+		}
+		if !s.Contains(3) {
+			b.Fatal("Expected 3 in the set")
+		}
+	}
+}
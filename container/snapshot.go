@@ -0,0 +1,126 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package container
+
+import "fmt"
+
+var ErrImmutable = fmt.Errorf("Snapshots can't be modified")
+
+type (
+	// An immutable view of the contents of a BasicArray at the time
+	// Snapshot was called. It implements the Array interface, but all
+	// attempts to modify it fail with ErrImmutable.
+	ArraySnapshot struct {
+		model []interface{}
+	}
+
+	// An immutable view of the contents of a Tree at the time Snapshot
+	// was called. The nodes returned by Find must not be modified.
+	TreeSnapshot struct {
+		root    Node
+		compare Compare
+	}
+)
+
+// Returns a snapshot of the array's current contents. The snapshot
+// shares its storage with the array until the array is next modified,
+// at which point the array copies its data rather than modifying it in
+// place. This makes taking a snapshot cheap, even when done often.
+//
+// Snapshot must be called from the goroutine modifying the array, but
+// the snapshot itself may then be read from any goroutine, such as by
+// a renderer iterating over it while the array keeps changing.
+func (a *BasicArray) Snapshot() *ArraySnapshot {
+	a.shared = true
+	return &ArraySnapshot{a.model}
+}
+
+// Makes sure that the array's data isn't shared with a snapshot
+// before it's modified in place.
+func (a *BasicArray) unshare() {
+	if a.shared {
+		a.model = append([]interface{}(nil), a.model...)
+		a.shared = false
+	}
+}
+
+func (s *ArraySnapshot) Insert(index int, data interface{}) error {
+	return ErrImmutable
+}
+
+func (s *ArraySnapshot) Remove(index int) (interface{}, error) {
+	return nil, ErrImmutable
+}
+
+func (s *ArraySnapshot) Get(index int) interface{} {
+	return s.model[index]
+}
+
+func (s *ArraySnapshot) Len() int {
+	return len(s.model)
+}
+
+func (s *ArraySnapshot) Sort(cmp Compare) error {
+	return ErrImmutable
+}
+
+func (s *ArraySnapshot) StableSort(cmp Compare) error {
+	return ErrImmutable
+}
+
+func (s *ArraySnapshot) IsSorted(cmp Compare) bool {
+	return (&BasicArray{model: s.model}).IsSorted(cmp)
+}
+
+func (s *ArraySnapshot) ToSlice() []interface{} {
+	return append([]interface{}(nil), s.model...)
+}
+
+func (s *ArraySnapshot) CopyInto(dst []interface{}) int {
+	return copy(dst, s.model)
+}
+
+// Returns a deep copy of the subtree rooted at n.
+func (n *Node) clone() *Node {
+	if n == nil {
+		return nil
+	}
+	return &Node{n.Data, [2]*Node{n.Children[0].clone(), n.Children[1].clone()}}
+}
+
+// Returns a snapshot of the tree's current contents. Like with
+// BasicArray.Snapshot, the nodes are shared with the tree until it's
+// next modified, at which point the tree copies them first.
+func (t *Tree) Snapshot() *TreeSnapshot {
+	t.shared = true
+	return &TreeSnapshot{t.Root, t.Compare}
+}
+
+// Makes sure that the tree's nodes aren't shared with a snapshot
+// before they're modified in place.
+func (t *Tree) unshare() {
+	if t.shared {
+		t.Root = *t.Root.clone()
+		t.shared = false
+	}
+}
+
+func (s *TreeSnapshot) Find(data interface{}) (child int, parent, node *Node) {
+	return s.root.Find(data, s.compare)
+}
+
+// Returns whether the snapshot holds an element equal to data.
+func (s *TreeSnapshot) Contains(data interface{}) bool {
+	if s.root.Data == nil {
+		return false
+	}
+	_, _, n := s.Find(data)
+	return n != nil
+}
+
+// Returns the snapshot's elements in order.
+func (s *TreeSnapshot) Slice() []interface{} {
+	return s.root.appendTo(nil)
+}
@@ -0,0 +1,78 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestArraySnapshot(t *testing.T) {
+	a := NewIntArrayFrom([]int{3, 1, 2})
+	s := a.Snapshot()
+	a.Sort(compareInt)
+	a.Remove(0)
+	a.Insert(0, 10)
+	if exp := []interface{}{3, 1, 2}; !reflect.DeepEqual(s.ToSlice(), exp) {
+		t.Errorf("Expected the snapshot to hold %v, but it holds %v", exp, s.ToSlice())
+	}
+	if exp := []interface{}{10, 2, 3}; !reflect.DeepEqual(a.ToSlice(), exp) {
+		t.Errorf("Expected the array to hold %v, but it holds %v", exp, a.ToSlice())
+	}
+	if err := s.Insert(0, 1); err != ErrImmutable {
+		t.Errorf("Expected %s, but got %v", ErrImmutable, err)
+	}
+	if s.IsSorted(compareInt) {
+		t.Error("Didn't expect the snapshot to be sorted")
+	}
+}
+
+func TestArraySnapshotConcurrent(t *testing.T) {
+	var (
+		a  = &BasicArray{}
+		wg sync.WaitGroup
+	)
+	for i := 0; i < 100; i++ {
+		a.Insert(i, i)
+	}
+	for i := 0; i < 10; i++ {
+		s := a.Snapshot()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < s.Len(); j++ {
+				if s.Get(j) != j {
+					t.Errorf("Unexpected value at %d: %v", j, s.Get(j))
+					return
+				}
+			}
+		}()
+		a.Remove(a.Len() - 1)
+	}
+	wg.Wait()
+}
+
+func TestTreeSnapshot(t *testing.T) {
+	tree := Tree{Compare: compareInt}
+	for _, v := range []int{5, 3, 8, 1} {
+		tree.Add(v)
+	}
+	s := tree.Snapshot()
+	tree.Delete(5)
+	tree.Add(7)
+	if exp := []interface{}{1, 3, 5, 8}; !reflect.DeepEqual(s.Slice(), exp) {
+		t.Errorf("Expected the snapshot to hold %v, but it holds %v", exp, s.Slice())
+	}
+	if exp := []interface{}{1, 3, 7, 8}; !reflect.DeepEqual(tree.Slice(), exp) {
+		t.Errorf("Expected the tree to hold %v, but it holds %v", exp, tree.Slice())
+	}
+	if !s.Contains(5) || s.Contains(7) {
+		t.Error("Unexpected snapshot contents")
+	}
+	if (&Tree{Compare: compareInt}).Snapshot().Contains(1) {
+		t.Error("Didn't expect an empty snapshot to contain anything")
+	}
+}
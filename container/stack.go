@@ -0,0 +1,131 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"fmt"
+	"github.com/quarnster/util"
+)
+
+const (
+	// Pushing onto a full stack drops the element at its bottom.
+	DropOldest StackOverflow = iota
+	// Pushing onto a full stack fails with ErrStackFull.
+	RejectPush
+)
+
+var (
+	ErrStackEmpty = fmt.Errorf("The stack is empty")
+	ErrStackFull  = fmt.Errorf("The stack is full")
+)
+
+type (
+	// What a bounded Stack does when pushing onto it while it's full.
+	StackOverflow int
+
+	// Sent by a Stack after Value has been pushed onto it.
+	StackPushed[T any] struct {
+		Value T
+	}
+
+	// Sent by a Stack after Value has been popped off it.
+	StackPopped[T any] struct {
+		Value T
+	}
+
+	// Sent by a bounded Stack after Value has been dropped from its
+	// bottom to make room for a pushed element. The StackPushed event
+	// for that element follows.
+	StackDropped[T any] struct {
+		Value T
+	}
+
+	// Sent by a Stack after it has been cleared.
+	StackCleared struct{}
+
+	// Stack is an observable last in, first out stack, optionally
+	// bounded to a maximum depth, such as for undo stacks and
+	// navigation histories. The zero value is an empty unbounded
+	// stack ready to use.
+	Stack[T any] struct {
+		util.BasicObservable
+		data     []T
+		max      int
+		overflow StackOverflow
+	}
+)
+
+// Creates a new empty Stack holding at most max elements, or any
+// number of elements if max is 0. The overflow policy decides what
+// happens when pushing onto a full stack.
+func NewStack[T any](max int, overflow StackOverflow) *Stack[T] {
+	return &Stack[T]{max: max, overflow: overflow}
+}
+
+// Pushes v onto the stack. Returns ErrStackFull if the stack is full
+// and its overflow policy is RejectPush.
+func (s *Stack[T]) Push(v T) error {
+	if s.max > 0 && len(s.data) >= s.max {
+		if s.overflow == RejectPush {
+			return ErrStackFull
+		}
+		dropped := s.data[0]
+		var zero T
+		s.data[0] = zero
+		s.data = s.data[1:]
+		s.NotifyObservers(StackDropped[T]{dropped})
+	}
+	s.data = append(s.data, v)
+	s.NotifyObservers(StackPushed[T]{v})
+	return nil
+}
+
+// Pops the top element off the stack, or returns ErrStackEmpty if
+// there is none.
+func (s *Stack[T]) Pop() (T, error) {
+	var (
+		zero T
+		n    = len(s.data)
+	)
+	if n == 0 {
+		return zero, ErrStackEmpty
+	}
+	v := s.data[n-1]
+	s.data[n-1] = zero
+	s.data = s.data[:n-1]
+	s.NotifyObservers(StackPopped[T]{v})
+	return v, nil
+}
+
+// Returns the top element of the stack without popping it, and
+// whether there is one.
+func (s *Stack[T]) Peek() (T, bool) {
+	if n := len(s.data); n > 0 {
+		return s.data[n-1], true
+	}
+	var zero T
+	return zero, false
+}
+
+// Removes all the elements of the stack.
+func (s *Stack[T]) Clear() {
+	s.data = nil
+	s.NotifyObservers(StackCleared{})
+}
+
+func (s *Stack[T]) Len() int {
+	return len(s.data)
+}
+
+// Returns the maximum number of elements the stack holds, or 0 if
+// it's unbounded.
+func (s *Stack[T]) Max() int {
+	return s.max
+}
+
+// Returns a copy of the stack's elements, from the bottom to the top.
+func (s *Stack[T]) ToSlice() []T {
+	return append([]T(nil), s.data...)
+}
@@ -0,0 +1,84 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package container_test
+
+import (
+	"github.com/quarnster/util/container"
+	"reflect"
+	"testing"
+)
+
+func TestStack(t *testing.T) {
+	var (
+		s   container.Stack[string]
+		rec eventRecorder
+	)
+	s.AddObserver(&rec)
+	if _, err := s.Pop(); err != container.ErrStackEmpty {
+		t.Errorf("Expected %s, but got %v", container.ErrStackEmpty, err)
+	}
+	if _, ok := s.Peek(); ok {
+		t.Error("Didn't expect to peek at an empty stack")
+	}
+	for _, v := range []string{"a", "b", "c"} {
+		if err := s.Push(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if v, ok := s.Peek(); !ok || v != "c" || s.Len() != 3 {
+		t.Errorf("Expected c on top of 3 elements, got %q and %d", v, s.Len())
+	}
+	if v, err := s.Pop(); err != nil || v != "c" {
+		t.Errorf("Expected c to be popped, got %q, %v", v, err)
+	}
+	s.Clear()
+	exp := []interface{}{
+		container.StackPushed[string]{"a"},
+		container.StackPushed[string]{"b"},
+		container.StackPushed[string]{"c"},
+		container.StackPopped[string]{"c"},
+		container.StackCleared{},
+	}
+	if !reflect.DeepEqual(rec.events, exp) {
+		t.Errorf("Expected the events %v, but got %v", exp, rec.events)
+	}
+}
+
+func TestStackBounded(t *testing.T) {
+	var (
+		drop   = container.NewStack[int](2, container.DropOldest)
+		reject = container.NewStack[int](2, container.RejectPush)
+		rec    eventRecorder
+	)
+	drop.AddObserver(&rec)
+	for i := 1; i <= 3; i++ {
+		if err := drop.Push(i); err != nil {
+			t.Fatal(err)
+		}
+		if err := reject.Push(i); i < 3 && err != nil {
+			t.Fatal(err)
+		} else if i == 3 && err != container.ErrStackFull {
+			t.Errorf("Expected %s, but got %v", container.ErrStackFull, err)
+		}
+	}
+	if s := drop.ToSlice(); !reflect.DeepEqual(s, []int{2, 3}) {
+		t.Errorf("Expected the oldest element to be dropped, got %v", s)
+	}
+	if s := reject.ToSlice(); !reflect.DeepEqual(s, []int{1, 2}) {
+		t.Errorf("Expected the push to be rejected, got %v", s)
+	}
+	exp := []interface{}{
+		container.StackPushed[int]{1},
+		container.StackPushed[int]{2},
+		container.StackDropped[int]{1},
+		container.StackPushed[int]{3},
+	}
+	if !reflect.DeepEqual(rec.events, exp) {
+		t.Errorf("Expected the events %v, but got %v", exp, rec.events)
+	}
+	if drop.Max() != 2 {
+		t.Errorf("Expected a max of 2, not %d", drop.Max())
+	}
+}
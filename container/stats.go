@@ -0,0 +1,149 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"sync/atomic"
+)
+
+type (
+	// Operation counts of a container, for diagnosing containers that
+	// are hot in production. Lookups are the Find calls of a Tree and
+	// the Get calls of an Array, and comparisons are the calls made to
+	// the Compare function, including those made by lookups.
+	Stats struct {
+		Inserts     uint64
+		Removes     uint64
+		Lookups     uint64
+		Comparisons uint64
+		Sorts       uint64
+	}
+
+	// The counters are atomic so that Stats can be called from
+	// a monitoring goroutine while the container is in use.
+	counters struct {
+		inserts     atomic.Uint64
+		removes     atomic.Uint64
+		lookups     atomic.Uint64
+		comparisons atomic.Uint64
+		sorts       atomic.Uint64
+	}
+
+	// CountingArray counts the operations made on the inner array,
+	// which are retrieved with Stats.
+	CountingArray struct {
+		Array
+		c counters
+	}
+)
+
+func (c *counters) stats() Stats {
+	if c == nil {
+		return Stats{}
+	}
+	return Stats{
+		Inserts:     c.inserts.Load(),
+		Removes:     c.removes.Load(),
+		Lookups:     c.lookups.Load(),
+		Comparisons: c.comparisons.Load(),
+		Sorts:       c.sorts.Load(),
+	}
+}
+
+func (c *counters) reset() {
+	c.inserts.Store(0)
+	c.removes.Store(0)
+	c.lookups.Store(0)
+	c.comparisons.Store(0)
+	c.sorts.Store(0)
+}
+
+// Returns cmp wrapped to count the comparisons it makes.
+func (c *counters) compare(cmp Compare) Compare {
+	return func(a, b interface{}) ComparisonResult {
+		c.comparisons.Add(1)
+		return cmp(a, b)
+	}
+}
+
+// Creates a new CountingArray counting the operations made on inner.
+func NewCountingArray(inner Array) *CountingArray {
+	return &CountingArray{Array: inner}
+}
+
+// Returns the operation counts since the array was created or the
+// counts were last reset.
+func (a *CountingArray) Stats() Stats {
+	return a.c.stats()
+}
+
+func (a *CountingArray) ResetStats() {
+	a.c.reset()
+}
+
+func (a *CountingArray) Insert(index int, data interface{}) error {
+	if err := a.Array.Insert(index, data); err != nil {
+		return err
+	}
+	a.c.inserts.Add(1)
+	return nil
+}
+
+func (a *CountingArray) Remove(index int) (interface{}, error) {
+	olddata, err := a.Array.Remove(index)
+	if err == nil {
+		a.c.removes.Add(1)
+	}
+	return olddata, err
+}
+
+func (a *CountingArray) Get(index int) interface{} {
+	a.c.lookups.Add(1)
+	return a.Array.Get(index)
+}
+
+func (a *CountingArray) Sort(cmp Compare) error {
+	a.c.sorts.Add(1)
+	return a.Array.Sort(a.c.compare(cmp))
+}
+
+func (a *CountingArray) StableSort(cmp Compare) error {
+	a.c.sorts.Add(1)
+	return a.Array.StableSort(a.c.compare(cmp))
+}
+
+func (a *CountingArray) IsSorted(cmp Compare) bool {
+	return a.Array.IsSorted(a.c.compare(cmp))
+}
+
+// Turns on counting the operations made on the tree, which are
+// retrieved with Stats. Counting is off by default, as it slows
+// down every operation.
+func (t *Tree) EnableStats() {
+	if t.stats == nil {
+		t.stats = &counters{}
+	}
+}
+
+// Returns the operation counts since EnableStats was called or the
+// counts were last reset. All counts are zero if counting is off.
+func (t *Tree) Stats() Stats {
+	return t.stats.stats()
+}
+
+func (t *Tree) ResetStats() {
+	if t.stats != nil {
+		t.stats.reset()
+	}
+}
+
+// Returns the tree's Compare, counting its comparisons if stats are
+// enabled.
+func (t *Tree) compare() Compare {
+	if t.stats == nil {
+		return t.Compare
+	}
+	return t.stats.compare(t.Compare)
+}
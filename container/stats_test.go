@@ -0,0 +1,76 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package container_test
+
+import (
+	"github.com/quarnster/util/container"
+	"testing"
+)
+
+func TestCountingArray(t *testing.T) {
+	var (
+		a   = container.NewCountingArray(&container.BoundsCheckingArray{&container.IntArray{}})
+		cmp = func(a, b interface{}) container.ComparisonResult {
+			return compareInts(a.(int), b.(int))
+		}
+	)
+	for i, v := range []int{3, 1, 2} {
+		if err := a.Insert(i, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := a.Insert(10, 4); err != container.ErrIndexOOB {
+		t.Errorf("Expected %s, but got %v", container.ErrIndexOOB, err)
+	}
+	a.Get(0)
+	a.Remove(0)
+	a.Sort(cmp)
+	s := a.Stats()
+	if s.Inserts != 3 || s.Removes != 1 || s.Lookups != 1 || s.Sorts != 1 || s.Comparisons == 0 {
+		t.Errorf("Unexpected stats %+v", s)
+	}
+	a.ResetStats()
+	if s := a.Stats(); s != (container.Stats{}) {
+		t.Errorf("Expected the stats to be reset, got %+v", s)
+	}
+}
+
+func TestTreeStats(t *testing.T) {
+	tree := container.Tree{Compare: func(a, b interface{}) container.ComparisonResult {
+		return compareInts(a.(int), b.(int))
+	}}
+	tree.Add(1)
+	if s := tree.Stats(); s != (container.Stats{}) {
+		t.Errorf("Expected no stats before enabling them, got %+v", s)
+	}
+	tree.EnableStats()
+	for _, v := range []int{4, 2, 3} {
+		tree.Add(v)
+	}
+	tree.Find(3)
+	tree.Delete(4)
+	tree.Delete(10)
+	s := tree.Stats()
+	if s.Inserts != 3 || s.Removes != 1 || s.Lookups != 1 {
+		t.Errorf("Unexpected stats %+v", s)
+	}
+	// Adding 4, 2 and 3, finding 3, and deleting 4 and then 10 from
+	// what's left of the tree
+	if exp := uint64(1 + 2 + 3 + 4 + 2 + 3); s.Comparisons != exp {
+		t.Errorf("Expected %d comparisons, but got %d", exp, s.Comparisons)
+	}
+
+	tree.ResetStats()
+	other := container.Tree{Compare: tree.Compare}
+	other.Add(2)
+	other.Add(5)
+	if u := tree.Union(&other); len(u.Slice()) != 4 {
+		t.Errorf("Unexpected union %v", u.Slice())
+	}
+	// 1 and 2, 2 and 2, and 3 and 5, after which 5 is taken as is
+	if s := tree.Stats(); s.Comparisons != 3 {
+		t.Errorf("Expected the union to make 3 comparisons, but got %d", s.Comparisons)
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package container
+
+import (
+	sb "encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// The formats a Tree can be serialized in.
+type TreeFormat uint8
+
+const (
+	// The data in order. This format is portable between trees
+	// using different implementations of the same ordering, and
+	// the tree is rebuilt balanced when loaded.
+	SortedStream TreeFormat = iota
+	// The data in pre-order together with the shape of the tree,
+	// which is restored as is when loaded without doing any
+	// comparisons.
+	PreservedStructure
+)
+
+type (
+	jsonNode[T any] struct {
+		Data     T
+		Children [2]*jsonNode[T] `json:",omitempty"`
+	}
+
+	// The JSON representation of a tree in either format.
+	jsonTree[T any] struct {
+		Format TreeFormat
+		Data   []T          `json:",omitempty"`
+		Root   *jsonNode[T] `json:",omitempty"`
+	}
+)
+
+func toJSONNode(n *Node) *jsonNode[interface{}] {
+	if n == nil || n.Data == nil {
+		return nil
+	}
+	return &jsonNode[interface{}]{
+		Data:     n.Data,
+		Children: [2]*jsonNode[interface{}]{toJSONNode(n.Children[0]), toJSONNode(n.Children[1])},
+	}
+}
+
+func fromJSONNode[T any](n *jsonNode[T]) *Node {
+	if n == nil {
+		return nil
+	}
+	return &Node{
+		Data:     n.Data,
+		Children: [2]*Node{fromJSONNode(n.Children[0]), fromJSONNode(n.Children[1])},
+	}
+}
+
+// Serializes the tree as JSON in the given format.
+func (t *Tree) MarshalJSONFormat(format TreeFormat) ([]byte, error) {
+	jt := jsonTree[interface{}]{Format: format}
+	switch format {
+	case SortedStream:
+		jt.Data = t.Slice()
+	case PreservedStructure:
+		jt.Root = toJSONNode(&t.Root)
+	default:
+		return nil, fmt.Errorf("Unknown tree format: %d", format)
+	}
+	return json.Marshal(jt)
+}
+
+// Serializes the tree as JSON in the SortedStream format.
+func (t *Tree) MarshalJSON() ([]byte, error) {
+	return t.MarshalJSONFormat(SortedStream)
+}
+
+// Creates a new tree out of JSON data in either format, with
+// the data decoded into values of type T.
+func UnmarshalTreeJSON[T any](data []byte, cmp Compare) (*Tree, error) {
+	var jt jsonTree[T]
+	if err := json.Unmarshal(data, &jt); err != nil {
+		return nil, err
+	}
+	switch jt.Format {
+	case SortedStream:
+		s := make([]interface{}, len(jt.Data))
+		for i, v := range jt.Data {
+			s[i] = v
+		}
+		return NewSortedTree(s, cmp)
+	case PreservedStructure:
+		t := &Tree{Compare: cmp}
+		if n := fromJSONNode(jt.Root); n != nil {
+			t.Root = *n
+		}
+		return t, nil
+	}
+	return nil, fmt.Errorf("Unknown tree format: %d", jt.Format)
+}
+
+// Creates a new balanced tree out of data, after checking that it's
+// sorted and doesn't contain any duplicates.
+func NewSortedTree(data []interface{}, cmp Compare) (*Tree, error) {
+	for i := 1; i < len(data); i++ {
+		if cmp(data[i-1], data[i]) != Less {
+			return nil, fmt.Errorf("The data isn't sorted at index %d", i)
+		}
+	}
+	return newBalancedTree(data, cmp), nil
+}
+
+// The bits of the uint8 preceding every node written by WriteFormat
+// in the PreservedStructure format.
+const (
+	HasLeftChild uint8 = 1 << iota
+	HasRightChild
+)
+
+func writePreOrder(w io.Writer, order sb.ByteOrder, n *Node) error {
+	var flags uint8
+	if n.Children[0] != nil {
+		flags |= HasLeftChild
+	}
+	if n.Children[1] != nil {
+		flags |= HasRightChild
+	}
+	if err := sb.Write(w, order, flags); err != nil {
+		return err
+	} else if err := sb.Write(w, order, n.Data); err != nil {
+		return err
+	}
+	for _, c := range n.Children {
+		if c == nil {
+			continue
+		}
+		if err := writePreOrder(w, order, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Serializes the tree in the given format, in a form that can be
+// loaded with the encoding/binary package's ReadTree. The tree's data
+// must be of fixed size types as understood by the standard library's
+// encoding/binary package.
+//
+// The data starts with the format as a uint8 followed by the number
+// of elements as a uint32. With SortedStream the elements then
+// follow in order. With PreservedStructure they follow in pre-order,
+// each prefixed with a uint8 with HasLeftChild and HasRightChild set
+// according to the children of the node.
+func (t *Tree) WriteFormat(w io.Writer, order sb.ByteOrder, format TreeFormat) error {
+	data := t.Slice()
+	if err := sb.Write(w, order, uint8(format)); err != nil {
+		return err
+	} else if err := sb.Write(w, order, uint32(len(data))); err != nil {
+		return err
+	}
+	switch format {
+	case SortedStream:
+		for _, d := range data {
+			if err := sb.Write(w, order, d); err != nil {
+				return err
+			}
+		}
+	case PreservedStructure:
+		if len(data) > 0 {
+			return writePreOrder(w, order, &t.Root)
+		}
+	default:
+		return fmt.Errorf("Unknown tree format: %d", format)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"bytes"
+	sb "encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func compareInt32(a, b interface{}) ComparisonResult {
+	return compareInt(int(a.(int32)), int(b.(int32)))
+}
+
+// Returns a tree with an unbalanced shape, which loading it
+// with SortedStream doesn't preserve.
+func serialTestTree() *Tree {
+	t := &Tree{Compare: compareInt32}
+	for _, v := range []int32{5, 1, 2, 3, 9, 7} {
+		t.Add(v)
+	}
+	return t
+}
+
+func TestTreeJSON(t *testing.T) {
+	tree := serialTestTree()
+	for _, format := range []TreeFormat{SortedStream, PreservedStructure} {
+		data, err := tree.MarshalJSONFormat(format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t2, err := UnmarshalTreeJSON[int32](data, compareInt32)
+		if err != nil {
+			t.Fatalf("%d: %s", format, err)
+		}
+		if a, b := tree.Slice(), t2.Slice(); !reflect.DeepEqual(a, b) {
+			t.Errorf("%d: Expected %v, but got %v", format, a, b)
+		}
+		if same := reflect.DeepEqual(tree.Root, t2.Root); same != (format == PreservedStructure) {
+			t.Errorf("%d: Expected the structure to be preserved: %v, but it was: %v", format, !same, same)
+		}
+	}
+	if data, err := tree.MarshalJSON(); err != nil {
+		t.Error(err)
+	} else if exp := `{"Format":0,"Data":[1,2,3,5,7,9]}`; string(data) != exp {
+		t.Errorf("Expected %s, but got %s", exp, data)
+	}
+	if _, err := UnmarshalTreeJSON[int32]([]byte(`{"Data":[3,2]}`), compareInt32); err == nil {
+		t.Error("Expected an error for unsorted data")
+	}
+}
+
+func TestTreeWriteFormat(t *testing.T) {
+	var buf bytes.Buffer
+	if err := serialTestTree().WriteFormat(&buf, sb.LittleEndian, SortedStream); err != nil {
+		t.Fatal(err)
+	}
+	exp := []byte{0, 6, 0, 0, 0, 1, 0, 0, 0, 2, 0, 0, 0, 3, 0, 0, 0, 5, 0, 0, 0, 7, 0, 0, 0, 9, 0, 0, 0}
+	if !bytes.Equal(buf.Bytes(), exp) {
+		t.Errorf("Expected %v, but got %v", exp, buf.Bytes())
+	}
+	buf.Reset()
+	if err := (&Tree{Compare: compareInt32}).WriteFormat(&buf, sb.BigEndian, PreservedStructure); err != nil {
+		t.Error(err)
+	} else if exp := []byte{1, 0, 0, 0, 0}; !bytes.Equal(buf.Bytes(), exp) {
+		t.Errorf("Expected %v, but got %v", exp, buf.Bytes())
+	}
+	if err := serialTestTree().WriteFormat(&buf, sb.LittleEndian, TreeFormat(9)); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package container
+
+// Appends the data of the subtree rooted at n to s in order.
+func (n *Node) appendTo(s []interface{}) []interface{} {
+	if n.Children[0] != nil {
+		s = n.Children[0].appendTo(s)
+	}
+	if n.Data != nil {
+		s = append(s, n.Data)
+	}
+	if n.Children[1] != nil {
+		s = n.Children[1].appendTo(s)
+	}
+	return s
+}
+
+// Returns the tree's data in order.
+func (t *Tree) Slice() []interface{} {
+	return t.Root.appendTo(nil)
+}
+
+// Builds a balanced subtree out of already sorted data.
+func balanced(data []interface{}) *Node {
+	if len(data) == 0 {
+		return nil
+	}
+	mid := len(data) / 2
+	return &Node{
+		Data:     data[mid],
+		Children: [2]*Node{balanced(data[:mid]), balanced(data[mid+1:])},
+	}
+}
+
+// Creates a new balanced tree out of data which must already be
+// sorted according to cmp and not contain any duplicates.
+func newBalancedTree(data []interface{}, cmp Compare) *Tree {
+	t := &Tree{Compare: cmp}
+	if n := balanced(data); n != nil {
+		t.Root = *n
+	}
+	return t
+}
+
+// Merges the in order data of t and other, keeping the elements
+// for which keep returns true given whether the element is in t
+// and in other respectively. Elements found in both are taken
+// from t.
+func (t *Tree) merge(other *Tree, keep func(inT, inOther bool) bool) *Tree {
+	var (
+		a   = t.Slice()
+		b   = other.Slice()
+		ret = make([]interface{}, 0, len(a)+len(b))
+		cmp = t.compare()
+	)
+	for len(a) > 0 || len(b) > 0 {
+		var (
+			c    ComparisonResult
+			data interface{}
+		)
+		switch {
+		case len(a) == 0:
+			c = Greater
+		case len(b) == 0:
+			c = Less
+		default:
+			c = cmp(a[0], b[0])
+		}
+		switch c {
+		case Less:
+			data, a = a[0], a[1:]
+		case Greater:
+			data, b = b[0], b[1:]
+		default:
+			data, a, b = a[0], a[1:], b[1:]
+		}
+		if keep(c != Greater, c != Less) {
+			ret = append(ret, data)
+		}
+	}
+	return newBalancedTree(ret, t.Compare)
+}
+
+// Returns a new balanced tree with the data that is in either t
+// or other. Both trees must be ordered by the same comparison,
+// and t's Compare is used for the returned tree.
+//
+// The trees are merged in O(n+m) time.
+func (t *Tree) Union(other *Tree) *Tree {
+	return t.merge(other, func(inT, inOther bool) bool {
+		return true
+	})
+}
+
+// Returns a new balanced tree with the data that is in both t
+// and other. See Union for the requirements on the trees.
+func (t *Tree) Intersect(other *Tree) *Tree {
+	return t.merge(other, func(inT, inOther bool) bool {
+		return inT && inOther
+	})
+}
+
+// Returns a new balanced tree with the data that is in t but not
+// in other. See Union for the requirements on the trees.
+func (t *Tree) Difference(other *Tree) *Tree {
+	return t.merge(other, func(inT, inOther bool) bool {
+		return inT && !inOther
+	})
+}
@@ -0,0 +1,63 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Returns the height of the subtree rooted at n.
+func height(n *Node) int {
+	if n == nil {
+		return 0
+	}
+	a, b := height(n.Children[0]), height(n.Children[1])
+	if a > b {
+		return a + 1
+	}
+	return b + 1
+}
+
+func TestTreeSetOperations(t *testing.T) {
+	var a, b Tree
+	a.Compare, b.Compare = compareInt, compareInt
+	// Added in order, which gives completely unbalanced trees
+	for i := 0; i < 20; i++ {
+		if i%2 == 0 {
+			a.Add(i)
+		}
+		if i%3 == 0 {
+			b.Add(i)
+		}
+	}
+	tests := []struct {
+		name string
+		tree *Tree
+		exp  []interface{}
+	}{
+		{"Union", a.Union(&b), []interface{}{0, 2, 3, 4, 6, 8, 9, 10, 12, 14, 15, 16, 18}},
+		{"Intersect", a.Intersect(&b), []interface{}{0, 6, 12, 18}},
+		{"Difference", a.Difference(&b), []interface{}{2, 4, 8, 10, 14, 16}},
+		{"Reverse difference", b.Difference(&a), []interface{}{3, 9, 15}},
+		{"Empty", a.Intersect(&Tree{Compare: compareInt}), nil},
+	}
+	for _, test := range tests {
+		if s := test.tree.Slice(); !reflect.DeepEqual(s, test.exp) {
+			t.Errorf("%s: Expected %v, but got %v", test.name, test.exp, s)
+		}
+		if h, max := height(&test.tree.Root), 4; len(test.exp) > 0 && h > max {
+			t.Errorf("%s: Expected a balanced tree of height at most %d, but got %d", test.name, max, h)
+		}
+	}
+	u := a.Union(&b)
+	if err := u.Add(5); err != nil {
+		t.Error(err)
+	} else if err := u.Delete(12); err != nil {
+		t.Error(err)
+	} else if _, _, n := u.Find(5); n == nil {
+		t.Error("Expected to find 5 in the union")
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"github.com/quarnster/util"
+)
+
+type (
+	// Sent by a WindowedArray when its window has been moved
+	// or resized.
+	WindowChanged struct {
+		Offset, Limit int
+	}
+
+	// WindowedArray is a view of at most Limit elements of an inner
+	// array, starting at Offset, such as the rows currently visible in
+	// a virtualized list. Indices are relative to the start of the
+	// window.
+	//
+	// Changes made to the inner array within the window are sent on to
+	// the WindowedArray's observers as InsertedData and RemovedData with
+	// window relative indices. When the window is full, the elements
+	// shifted into or out of the end of the window as a consequence are
+	// reported as well, so that observers mirroring the window from its
+	// events always agree with Len and Get. Changes before the window
+	// shift its contents as a whole, and are reported as ReorderedData,
+	// while changes after it aren't reported at all.
+	WindowedArray struct {
+		util.BasicObservable
+		inner         Array
+		offset, limit int
+	}
+)
+
+// Creates a new WindowedArray viewing at most limit elements of
+// inner starting at offset. The inner array must be observable.
+func NewWindowedArray(inner Array, offset, limit int) (*WindowedArray, error) {
+	obs, ok := inner.(util.Observable)
+	if !ok {
+		return nil, ErrMustBeObservable
+	} else if offset < 0 || limit < 0 {
+		return nil, ErrIndexOOB
+	}
+	w := &WindowedArray{inner: inner, offset: offset, limit: limit}
+	obs.AddObserver(w)
+	return w, nil
+}
+
+// Returns the index in the inner array of the start of the window.
+func (w *WindowedArray) Offset() int {
+	return w.offset
+}
+
+// Returns the maximum number of elements in the window.
+func (w *WindowedArray) Limit() int {
+	return w.limit
+}
+
+// Moves the window to start at the given index of the inner array.
+// The window may extend past the end of the inner array, in which
+// case it holds fewer than Limit elements.
+func (w *WindowedArray) Move(offset int) error {
+	if offset < 0 {
+		return ErrIndexOOB
+	}
+	if offset != w.offset {
+		w.offset = offset
+		w.NotifyObservers(WindowChanged{w.offset, w.limit})
+	}
+	return nil
+}
+
+// Changes the maximum number of elements in the window.
+func (w *WindowedArray) Resize(limit int) error {
+	if limit < 0 {
+		return ErrIndexOOB
+	}
+	if limit != w.limit {
+		w.limit = limit
+		w.NotifyObservers(WindowChanged{w.offset, w.limit})
+	}
+	return nil
+}
+
+// Stops the WindowedArray from observing the inner array, after
+// which it no longer sends any change events of its own.
+func (w *WindowedArray) Close() {
+	w.inner.(util.Observable).RemoveObserver(w)
+}
+
+func (w *WindowedArray) Changed(data interface{}) {
+	switch d := data.(type) {
+	case InsertedData:
+		if d.Index < w.offset {
+			w.NotifyObservers(ReorderedData{})
+		} else if d.Index < w.offset+w.limit {
+			w.NotifyObservers(InsertedData{d.Index - w.offset, d.Data})
+			if end := w.offset + w.limit; end < w.inner.Len() {
+				// The last element was pushed out of the window
+				w.NotifyObservers(RemovedData{w.limit, w.inner.Get(end)})
+			}
+		}
+	case RemovedData:
+		if d.Index < w.offset {
+			w.NotifyObservers(ReorderedData{})
+		} else if d.Index < w.offset+w.limit {
+			w.NotifyObservers(RemovedData{d.Index - w.offset, d.Data})
+			if last := w.offset + w.limit - 1; last < w.inner.Len() {
+				// The next element was pulled into the window
+				w.NotifyObservers(InsertedData{w.limit - 1, w.inner.Get(last)})
+			}
+		}
+	case ReorderedData:
+		w.NotifyObservers(d)
+	}
+}
+
+func (w *WindowedArray) Len() int {
+	n := w.inner.Len() - w.offset
+	if n < 0 {
+		return 0
+	} else if n > w.limit {
+		return w.limit
+	}
+	return n
+}
+
+func (w *WindowedArray) Get(index int) interface{} {
+	return w.inner.Get(w.offset + index)
+}
+
+// Inserts data into the inner array at the given index of the window.
+func (w *WindowedArray) Insert(index int, data interface{}) error {
+	if index < 0 || index > w.Len() {
+		return ErrIndexOOB
+	}
+	return w.inner.Insert(w.offset+index, data)
+}
+
+// Removes the element at the given index of the window from the
+// inner array.
+func (w *WindowedArray) Remove(index int) (interface{}, error) {
+	if index < 0 || index >= w.Len() {
+		return nil, ErrIndexOOB
+	}
+	return w.inner.Remove(w.offset + index)
+}
+
+func (w *WindowedArray) Sort(cmp Compare) error {
+	return ErrNotManipulatable
+}
+
+func (w *WindowedArray) StableSort(cmp Compare) error {
+	return ErrNotManipulatable
+}
+
+func (w *WindowedArray) IsSorted(cmp Compare) bool {
+	for i := w.Len() - 1; i > 0; i-- {
+		if cmp(w.Get(i), w.Get(i-1)) == Less {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *WindowedArray) ToSlice() []interface{} {
+	ret := make([]interface{}, w.Len())
+	w.CopyInto(ret)
+	return ret
+}
+
+func (w *WindowedArray) CopyInto(dst []interface{}) int {
+	n := w.Len()
+	if len(dst) < n {
+		n = len(dst)
+	}
+	for i := 0; i < n; i++ {
+		dst[i] = w.Get(i)
+	}
+	return n
+}
@@ -0,0 +1,127 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package container_test
+
+import (
+	"github.com/quarnster/util/container"
+	"reflect"
+	"testing"
+)
+
+type eventRecorder struct {
+	events []interface{}
+}
+
+func (r *eventRecorder) Changed(data interface{}) {
+	r.events = append(r.events, data)
+}
+
+func TestWindowedArray(t *testing.T) {
+	a := &container.ObservableArray{Array: container.NewIntArrayFrom([]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})}
+	if _, err := container.NewWindowedArray(&container.BasicArray{}, 0, 1); err != container.ErrMustBeObservable {
+		t.Errorf("Expected %s, but got %v", container.ErrMustBeObservable, err)
+	}
+	w, err := container.NewWindowedArray(a, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rec eventRecorder
+	w.AddObserver(&rec)
+	check := func(exp ...int) {
+		t.Helper()
+		got, _ := container.ToSliceOf[int](w)
+		if len(got) == 0 && len(exp) == 0 {
+			return
+		}
+		if !reflect.DeepEqual(got, exp) {
+			t.Errorf("Expected %v, but got %v", exp, got)
+		}
+	}
+	check(2, 3, 4)
+
+	w.Move(8)
+	check(8, 9)
+	w.Move(12)
+	check()
+	w.Move(4)
+	w.Resize(2)
+	check(4, 5)
+
+	a.Insert(5, 100) // Within the window
+	a.Insert(9, 100) // After the window
+	a.Remove(0)      // Before the window
+	check(100, 5)
+	w.Remove(0)
+	check(5, 6)
+	w.Close()
+	a.Remove(0)
+	exp := []interface{}{
+		container.WindowChanged{8, 3},
+		container.WindowChanged{12, 3},
+		container.WindowChanged{4, 3},
+		container.WindowChanged{4, 2},
+		container.InsertedData{1, 100},
+		container.RemovedData{2, 5},
+		container.ReorderedData{},
+		container.RemovedData{0, 100},
+		container.InsertedData{1, 6},
+	}
+	if !reflect.DeepEqual(rec.events, exp) {
+		t.Errorf("Expected the events %v, but got %v", exp, rec.events)
+	}
+	if err := w.Insert(3, 0); err != container.ErrIndexOOB {
+		t.Errorf("Expected %s, but got %v", container.ErrIndexOOB, err)
+	}
+	if err := w.Sort(nil); err != container.ErrNotManipulatable {
+		t.Errorf("Expected %s, but got %v", container.ErrNotManipulatable, err)
+	}
+}
+
+// Keeps a copy of a WindowedArray up to date from its events alone.
+type windowMirror struct {
+	w    *container.WindowedArray
+	rows []interface{}
+}
+
+func (m *windowMirror) Changed(data interface{}) {
+	switch d := data.(type) {
+	case container.InsertedData:
+		m.rows = append(m.rows[:d.Index], append([]interface{}{d.Data}, m.rows[d.Index:]...)...)
+	case container.RemovedData:
+		m.rows = append(m.rows[:d.Index], m.rows[d.Index+1:]...)
+	default:
+		m.rows = m.w.ToSlice()
+	}
+}
+
+func TestWindowedArrayMirror(t *testing.T) {
+	a := &container.ObservableArray{Array: container.NewIntArrayFrom([]int{0, 1, 2, 3, 4, 5, 6, 7})}
+	w, err := container.NewWindowedArray(a, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := windowMirror{w: w, rows: w.ToSlice()}
+	w.AddObserver(&m)
+	for i, op := range []func(){
+		func() { w.Insert(1, 100) },
+		func() { w.Insert(w.Len(), 101) },
+		func() { w.Remove(0) },
+		func() { a.Insert(0, 102) },
+		func() { w.Remove(w.Len() - 1) },
+		func() { a.Remove(a.Len() - 1) },
+		func() { w.Insert(0, 103) },
+		func() {
+			for w.Len() > 0 {
+				w.Remove(0)
+			}
+		},
+		func() { w.Insert(0, 104) },
+	} {
+		op()
+		if exp := w.ToSlice(); !reflect.DeepEqual(m.rows, exp) || len(m.rows) != w.Len() {
+			t.Errorf("%d: Expected the mirror to hold %v, but it holds %v", i, exp, m.rows)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	"testing"
+)
+
+type (
+	benchFlat struct {
+		A uint8
+		B uint16
+		C uint32
+		D uint64
+		E int32
+		F float32
+		G float64
+		H bool
+	}
+	benchNested struct {
+		Header struct {
+			Magic   [4]byte
+			Version uint16
+		}
+		Entries [16]struct {
+			Offset, Size uint32
+			Flags        struct {
+				Kind uint8
+				Mode uint8
+			}
+		}
+	}
+	benchSlice struct {
+		Count  uint32
+		Values []uint32 `length:"Count"`
+	}
+	benchStrings struct {
+		Name    string `length:"uint8"`
+		Comment string `length:"uint16"`
+		Path    string
+	}
+	benchTags struct {
+		Magic   uint32 `match:"0x46464952"`
+		Version uint8  `in:"1,2,3"`
+		Flags   uint8  `bits:"4"`
+		Kind    uint8  `bits:"4"`
+		Size    uint16 `assert:"Size < 0x1000"`
+		Extra   uint32 `if:"Version >= 2" align:"8"`
+		Data    []byte `length:"Size & 0xff"`
+	}
+)
+
+// The ReadInterface allocation budget of each benchmark case. The
+// budgets document what reading each kind of data is expected to
+// cost and are enforced by TestReadInterfaceAllocs, so when a change
+// legitimately moves them, update them here along with the reason.
+//
+// Every struct read costs one allocation, as it's handed to the
+// expression evaluator by pointer. On top of that strings pay for the
+// resulting string, slices for the slice itself and tag expressions
+// for the identifiers and constants looked up during evaluation.
+var benchCases = []struct {
+	name   string
+	value  func() interface{}
+	data   func() []byte
+	budget float64
+}{
+	{
+		"Flat",
+		func() interface{} { return &benchFlat{} },
+		func() []byte { return make([]byte, 1+2+4+8+4+4+8+1) },
+		1,
+	},
+	{
+		"Nested",
+		func() interface{} { return &benchNested{} },
+		func() []byte { return make([]byte, 6+16*10) },
+		34,
+	},
+	{
+		"LargeSlice",
+		func() interface{} { return &benchSlice{} },
+		func() []byte {
+			data := make([]byte, 4+4096*4)
+			data[1] = 0x10
+			return data
+		},
+		4,
+	},
+	{
+		"Strings",
+		func() interface{} { return &benchStrings{} },
+		func() []byte {
+			var buf bytes.Buffer
+			buf.WriteByte(5)
+			buf.WriteString("hello")
+			buf.Write([]byte{11, 0})
+			buf.WriteString("hello world")
+			buf.WriteString("/usr/local/bin\u0000")
+			return buf.Bytes()
+		},
+		6,
+	},
+	{
+		"TagHeavy",
+		func() interface{} { return &benchTags{} },
+		func() []byte {
+			data := make([]byte, 4+1+1+2+8+4+16)
+			copy(data, "RIFF")
+			data[4] = 2
+			data[6] = 16
+			return data
+		},
+		16,
+	},
+}
+
+func benchmarkReadInterface(b *testing.B, i int) {
+	var (
+		c  = benchCases[i]
+		v  = c.value()
+		rd = bytes.NewReader(c.data())
+		br = BinaryReader{Reader: rd, Endianess: LittleEndian}
+	)
+	b.SetBytes(rd.Size())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rd.Seek(0, 0)
+		if err := br.ReadInterface(v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadInterfaceFlat(b *testing.B) {
+	benchmarkReadInterface(b, 0)
+}
+func BenchmarkReadInterfaceNested(b *testing.B) {
+	benchmarkReadInterface(b, 1)
+}
+func BenchmarkReadInterfaceLargeSlice(b *testing.B) {
+	benchmarkReadInterface(b, 2)
+}
+func BenchmarkReadInterfaceStrings(b *testing.B) {
+	benchmarkReadInterface(b, 3)
+}
+func BenchmarkReadInterfaceTagHeavy(b *testing.B) {
+	benchmarkReadInterface(b, 4)
+}
+
+func TestReadInterfaceAllocs(t *testing.T) {
+	for _, c := range benchCases {
+		var (
+			v  = c.value()
+			rd = bytes.NewReader(c.data())
+			br = BinaryReader{Reader: rd, Endianess: LittleEndian}
+		)
+		if err := br.ReadInterface(v); err != nil {
+			t.Errorf("%s: %s", c.name, err)
+			continue
+		}
+		allocs := testing.AllocsPerRun(100, func() {
+			rd.Seek(0, 0)
+			br.ReadInterface(v)
+		})
+		t.Logf("%s: %v allocations", c.name, allocs)
+		if allocs > c.budget {
+			t.Errorf("%s: %v allocations exceeds the budget of %v", c.name, allocs, c.budget)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+)
+
+// An unsigned 128 bit integer, as found in UUIDs, cryptographic
+// data and database pages. It's read as 16 bytes in the
+// BinaryReader's byte order.
+type Uint128 struct {
+	Hi, Lo uint64
+}
+
+var (
+	bigIntType  = reflect.TypeOf(big.Int{})
+	uint128Type = reflect.TypeOf(Uint128{})
+)
+
+func (u *Uint128) Read(r *BinaryReader) error {
+	var (
+		a, b uint64
+		err  error
+	)
+	if a, err = r.Uint64(); err != nil {
+		return err
+	} else if b, err = r.Uint64(); err != nil {
+		return err
+	}
+	if isLittleEndian(r.Endianess) {
+		u.Hi, u.Lo = b, a
+	} else {
+		u.Hi, u.Lo = a, b
+	}
+	return nil
+}
+
+// Returns the value as a big.Int.
+func (u Uint128) Big() *big.Int {
+	b := new(big.Int).SetUint64(u.Hi)
+	b.Lsh(b, 64)
+	return b.Or(b, new(big.Int).SetUint64(u.Lo))
+}
+
+func (u Uint128) String() string {
+	return u.Big().String()
+}
+
+// Reads an unsigned integer of size bytes, as given by the `size` tag,
+// into the big.Int or *big.Int field f.
+func (r *BinaryReader) readBigInt(f reflect.Value, size int) error {
+	if size < 0 {
+		return fmt.Errorf("Invalid big.Int size: %d", size)
+	}
+	if f.Kind() == reflect.Ptr && f.Type().Elem() == bigIntType {
+		if f.IsNil() {
+			f.Set(reflect.New(bigIntType))
+		}
+		f = f.Elem()
+	}
+	if f.Type() != bigIntType {
+		return fmt.Errorf("The size tag only applies to big.Int fields, not %s", f.Type())
+	}
+	data, err := r.Read(size)
+	if err != nil {
+		return err
+	}
+	defer r.release(data)
+	if isLittleEndian(r.Endianess) {
+		// big.Int.SetBytes expects big endian data, and data is
+		// ours to scribble on
+		for i, j := 0, len(data)-1; i < j; i, j = i+1, j-1 {
+			data[i], data[j] = data[j], data[i]
+		}
+	}
+	f.Addr().Interface().(*big.Int).SetBytes(data)
+	return nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	sb "encoding/binary"
+	"math/big"
+	"testing"
+)
+
+// A ByteOrder that isn't one of the standard library's values.
+type customOrder struct {
+	sb.ByteOrder
+}
+
+func TestBinaryReaderUint128(t *testing.T) {
+	var (
+		data   = []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+		little = Uint128{0x0f0e0d0c0b0a0908, 0x0706050403020100}
+		big    = Uint128{0x0001020304050607, 0x08090a0b0c0d0e0f}
+		native = big
+	)
+	if sb.NativeEndian.Uint16([]byte{1, 0}) == 1 {
+		native = little
+	}
+	for _, test := range []struct {
+		order sb.ByteOrder
+		exp   Uint128
+	}{
+		{LittleEndian, little},
+		{BigEndian, big},
+		{sb.NativeEndian, native},
+		{customOrder{LittleEndian}, little},
+		{customOrder{BigEndian}, big},
+	} {
+		var (
+			br = BinaryReader{Reader: bytes.NewReader(data), Endianess: test.order}
+			v  Uint128
+		)
+		if err := br.ReadInterface(&v); err != nil {
+			t.Fatal(err)
+		} else if v != test.exp {
+			t.Errorf("%s: Expected %#v, but got %#v", test.order, test.exp, v)
+		}
+	}
+	if s := (Uint128{1, 0}).String(); s != "18446744073709551616" {
+		t.Errorf("Unexpected string representation: %s", s)
+	}
+}
+
+func TestBinaryReaderBigInt(t *testing.T) {
+	type Test struct {
+		Length uint8
+		A      big.Int  `size:"3"`
+		B      *big.Int `size:"Length"`
+		C      uint8
+	}
+	var (
+		data = []byte{2, 1, 2, 3, 0xff, 0x01, 9}
+		br   = BinaryReader{Reader: bytes.NewReader(data), Endianess: BigEndian}
+		v    Test
+	)
+	if err := br.ReadInterface(&v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A.Int64() != 0x010203 || v.B == nil || v.B.Int64() != 0xff01 || v.C != 9 {
+		t.Errorf("Unexpected result: %s %s %d", &v.A, v.B, v.C)
+	}
+
+	br = BinaryReader{Reader: bytes.NewReader(data), Endianess: LittleEndian}
+	if err := br.ReadInterface(&v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A.Int64() != 0x030201 || v.B.Int64() != 0x01ff {
+		t.Errorf("Unexpected result: %s %s", &v.A, v.B)
+	}
+
+	type Invalid struct {
+		A uint32 `size:"4"`
+	}
+	br = BinaryReader{Reader: bytes.NewReader(data), Endianess: LittleEndian}
+	if err := br.ReadInterface(&Invalid{}); err == nil {
+		t.Error("Expected an error for a size tag on a non big.Int field")
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+// The binarytest package runs corpora of sample files through the
+// BinaryReader and compares the results against golden JSON files,
+// which makes it easy to validate format definitions:
+//
+//	func init() {
+//		binarytest.Register("png", reflect.TypeOf(PNGFile{}), binary.BigEndian)
+//	}
+//
+//	func TestCorpus(t *testing.T) {
+//		binarytest.Run(t, "testdata")
+//	}
+//
+// With the above, every testdata/png/*.bin file is decoded into a
+// PNGFile and compared against the testdata/png/*.json file of the
+// same name. Running the test with -binarytest.update writes the
+// golden files from the decoded values instead, which is how new
+// samples are added.
+package binarytest
+
+import (
+	"bytes"
+	sb "encoding/binary"
+	"encoding/json"
+	"flag"
+	"github.com/quarnster/util/encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type corpus struct {
+	typ   reflect.Type
+	order sb.ByteOrder
+}
+
+var (
+	update = flag.Bool("binarytest.update", false, "Write the golden files of the corpora rather than comparing against them")
+
+	corporaLock sync.RWMutex
+	corpora     = make(map[string]corpus)
+)
+
+// Registers the struct type that the samples in the corpus of the
+// given name are decoded into, using the given byte order.
+//
+// Registering a corpus under an already registered name
+// replaces the previous one.
+func Register(name string, t reflect.Type, order sb.ByteOrder) {
+	corporaLock.Lock()
+	defer corporaLock.Unlock()
+	corpora[name] = corpus{t, order}
+}
+
+// Runs a subtest for each corpus that is registered and has a
+// directory by its name in dir, and within it a subtest for each
+// sample. A sample fails if it can't be decoded, if it isn't decoded
+// in full, or if the JSON encoding of the decoded value differs from
+// the sample's golden file.
+func Run(t *testing.T, dir string) {
+	corporaLock.RLock()
+	names := make([]string, 0, len(corpora))
+	for name := range corpora {
+		names = append(names, name)
+	}
+	corporaLock.RUnlock()
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(dir, name)); os.IsNotExist(err) {
+			continue
+		}
+		corporaLock.RLock()
+		c := corpora[name]
+		corporaLock.RUnlock()
+		t.Run(name, func(t *testing.T) {
+			samples, err := filepath.Glob(filepath.Join(dir, name, "*.bin"))
+			if err != nil {
+				t.Fatal(err)
+			} else if len(samples) == 0 {
+				t.Fatalf("No samples in %s", filepath.Join(dir, name))
+			}
+			for _, sample := range samples {
+				t.Run(strings.TrimSuffix(filepath.Base(sample), ".bin"), func(t *testing.T) {
+					c.check(t, sample)
+				})
+			}
+		})
+	}
+}
+
+// Decodes the sample and compares it against its golden file.
+func (c corpus) check(t *testing.T, sample string) {
+	data, err := os.ReadFile(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		v  = reflect.New(c.typ)
+		br = binary.BinaryReader{Reader: bytes.NewReader(data), Endianess: c.order}
+	)
+	if err := br.ReadInterface(v.Interface()); err != nil {
+		t.Fatalf("Decoding failed at offset %d: %s", br.Tell(), err)
+	} else if n := br.Tell(); n != int64(len(data)) {
+		t.Errorf("Decoded %d bytes, but the sample is %d bytes", n, len(data))
+	}
+	got, err := json.MarshalIndent(v.Interface(), "", "\t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := strings.TrimSuffix(sample, ".bin") + ".json"
+	if *update {
+		if err := os.WriteFile(golden, append(got, '\n'), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	exp, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Compare the decoded JSON, so that formatting doesn't matter
+	var a, b interface{}
+	if err := json.Unmarshal(exp, &a); err != nil {
+		t.Fatalf("%s: %s", golden, err)
+	} else if err := json.Unmarshal(got, &b); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(a, b) {
+		t.Errorf("Decoded value differs from %s:\nExpected:\n%s\nGot:\n%s", golden, exp, got)
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binarytest
+
+import (
+	"github.com/quarnster/util/encoding/binary"
+	"reflect"
+	"testing"
+)
+
+type (
+	entry struct {
+		Name  string
+		Value uint16
+	}
+	header struct {
+		Magic   [4]byte `match:"HEAD"`
+		Count   uint8
+		Entries []entry `length:"Count"`
+	}
+)
+
+func init() {
+	Register("header", reflect.TypeOf(header{}), binary.BigEndian)
+	// Not present in testdata, so it's skipped
+	Register("missing", reflect.TypeOf(header{}), binary.BigEndian)
+}
+
+func TestCorpus(t *testing.T) {
+	Run(t, "testdata")
+}
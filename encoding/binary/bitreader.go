@@ -14,12 +14,9 @@ type BitReader struct {
 func (b *BitReader) ReadBit() (bool, error) {
 	if b.currPos == 0 {
 		b.currPos = 8
-		buf := make([]byte, 1)
-		n, err := b.Inner.Read(buf)
-		if err != nil {
+		var buf [1]byte
+		if _, err := io.ReadFull(b.Inner, buf[:]); err != nil {
 			return false, err
-		} else if n != 1 {
-			panic("n isn't 1 (shouldn't happen..")
 		}
 		b.currByte = buf[0]
 	}
@@ -0,0 +1,62 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Returns whether fields of type t read themselves via the Reader
+// interface from a sub-stream of a known size, rather than from the
+// BinaryReader directly. This is the case when a `length` or `size`
+// tag gives the field's size in bytes:
+//
+//	type Record struct {
+//		Size    uint16
+//		Payload Custom `length:"Size"`
+//	}
+//
+// Slices, strings and big.Ints, whose tags already mean something
+// else, are excluded.
+func isBounded(t reflect.Type, tag reflect.StructTag) bool {
+	switch t.Kind() {
+	case reflect.Slice, reflect.String, reflect.Ptr, reflect.Interface:
+		return false
+	}
+	if t == bigIntType || !reflect.PtrTo(t).Implements(readerType) || reflect.PtrTo(t).Implements(fieldReaderType) {
+		return false
+	}
+	return tag.Get("length") != "" || tag.Get("size") != ""
+}
+
+// Reads the field f, which implements Reader, from a BinaryReader
+// limited to the next size bytes, so that a custom Read can't read
+// into whatever follows. Any of the size bytes it leaves unread are
+// skipped. Positions in the sub-stream are relative to its start, as
+// with the Reader of a Chunk.
+func (r *BinaryReader) readBounded(f reflect.Value, size int) error {
+	if size < 0 {
+		return fmt.Errorf("Invalid size %d for field of type %s", size, f.Type())
+	}
+	start, err := r.Seek(0, 1)
+	if err != nil {
+		return err
+	}
+	sub := BinaryReader{
+		Reader:    &boundedReader{inner: r.Reader, start: start, end: start + int64(size)},
+		Endianess: r.Endianess,
+		Reuse:     r.Reuse,
+		Trace:     r.Trace,
+		Buffers:   r.Buffers,
+		Vars:      r.Vars,
+		path:      r.path,
+	}
+	if err := sub.ReadInterface(f.Addr().Interface()); err != nil {
+		return err
+	}
+	_, err = r.Seek(start+int64(size), 0)
+	return err
+}
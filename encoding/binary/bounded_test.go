@@ -0,0 +1,74 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	sb "encoding/binary"
+	"io"
+	"testing"
+)
+
+// Reads everything it is given.
+type greedy struct {
+	Data []byte
+}
+
+func (g *greedy) Read(r *BinaryReader) error {
+	for {
+		b, err := r.Uint8()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		g.Data = append(g.Data, b)
+	}
+}
+
+// Reads a single byte, whatever it is given.
+type single struct {
+	Value uint8
+	Pos   int64
+}
+
+func (s *single) Read(r *BinaryReader) (err error) {
+	s.Pos = r.Tell()
+	s.Value, err = r.Uint8()
+	return
+}
+
+func TestBinaryReaderBounded(t *testing.T) {
+	type Record struct {
+		Size   uint8
+		Greedy greedy `length:"Size"`
+		Single single `size:"Size + 1"`
+		Prefix single `length:"uint8"`
+		Tail   uint8
+	}
+	data := []byte{2, 'a', 'b', 7, 8, 9, 3, 10, 11, 12, 0xff}
+	var (
+		rec Record
+		br  = BinaryReader{Reader: bytes.NewReader(data), Endianess: sb.LittleEndian}
+	)
+	if err := br.ReadInterface(&rec); err != nil {
+		t.Fatal(err)
+	}
+	if string(rec.Greedy.Data) != "ab" {
+		t.Errorf("Expected the greedy reader to stop at its bound, got %q", rec.Greedy.Data)
+	}
+	if rec.Single != (single{7, 0}) || rec.Prefix != (single{10, 0}) {
+		t.Errorf("Unexpected values: %+v, %+v", rec.Single, rec.Prefix)
+	}
+	if rec.Tail != 0xff {
+		t.Errorf("Expected the unread remainders to be skipped, but Tail is %d", rec.Tail)
+	}
+
+	// Running out of data within the bound is still an error
+	br = BinaryReader{Reader: bytes.NewReader(data[:6]), Endianess: sb.LittleEndian}
+	if err := br.ReadInterface(&rec); err == nil {
+		t.Error("Expected an error")
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"fmt"
+	"io"
+)
+
+// The default buffer size used by NewBufferedReader.
+const DefaultBufferSize = 4096
+
+// BufferedReader adds buffering to an io.ReadSeeker, much like
+// bufio.Reader does for an io.Reader, but while keeping the seek
+// semantics intact.
+//
+// The BinaryReader reads most data types a couple of bytes at a time,
+// which when reading directly from an os.File means a syscall per
+// field. Wrapping the file in a BufferedReader avoids that:
+//
+//	br := BinaryReader{Reader: NewBufferedReader(f, 0), Endianess: LittleEndian}
+//
+// Seeks that land within the currently buffered data don't touch the
+// underlying reader at all, and Seek(0, 1) always reports the logical
+// position, i.e. taking the buffered but not yet consumed data into
+// account.
+type BufferedReader struct {
+	inner io.ReadSeeker
+	buf   []byte
+	// Absolute position in the inner reader of buf[0]
+	start int64
+	// Read position in buf
+	pos int
+	// Amount of valid data in buf
+	end int
+}
+
+// Creates a new BufferedReader reading from inner with a buffer of
+// the given size. If size is less than or equal to zero,
+// DefaultBufferSize is used.
+//
+// The current position of inner is used as the initial position.
+func NewBufferedReader(inner io.ReadSeeker, size int) *BufferedReader {
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	b := &BufferedReader{inner: inner, buf: make([]byte, size)}
+	b.start, _ = inner.Seek(0, 1)
+	return b
+}
+
+func (b *BufferedReader) fill() error {
+	b.start += int64(b.end)
+	b.pos, b.end = 0, 0
+	n, err := b.inner.Read(b.buf)
+	b.end = n
+	if n > 0 {
+		return nil
+	}
+	if err == nil {
+		err = io.ErrNoProgress
+	}
+	return err
+}
+
+func (b *BufferedReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	n := 0
+	for n < len(p) {
+		if b.pos == b.end {
+			if len(p)-n >= len(b.buf) {
+				// Large read, bypass the buffer
+				b.start += int64(b.end)
+				b.pos, b.end = 0, 0
+				m, err := b.inner.Read(p[n:])
+				b.start += int64(m)
+				n += m
+				if m == 0 || err != nil {
+					if n > 0 && err == io.EOF {
+						err = nil
+					}
+					return n, err
+				}
+				continue
+			}
+			if err := b.fill(); err != nil {
+				if n > 0 && err == io.EOF {
+					err = nil
+				}
+				return n, err
+			}
+		}
+		c := copy(p[n:], b.buf[b.pos:b.end])
+		b.pos += c
+		n += c
+	}
+	return n, nil
+}
+
+func (b *BufferedReader) Seek(offset int64, whence int) (int64, error) {
+	cur := b.start + int64(b.pos)
+	switch whence {
+	case 0:
+	case 1:
+		offset += cur
+	case 2:
+		b.pos, b.end = 0, 0
+		pos, err := b.inner.Seek(offset, 2)
+		if err == nil {
+			b.start = pos
+		}
+		return pos, err
+	default:
+		return cur, fmt.Errorf("Invalid whence: %d", whence)
+	}
+	if offset >= b.start && offset <= b.start+int64(b.end) {
+		b.pos = int(offset - b.start)
+		return offset, nil
+	}
+	pos, err := b.inner.Seek(offset, 0)
+	if err != nil {
+		return cur, err
+	}
+	b.start = pos
+	b.pos, b.end = 0, 0
+	return pos, nil
+}
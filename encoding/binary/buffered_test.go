@@ -0,0 +1,103 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+func TestBufferedReader(t *testing.T) {
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	var (
+		exp = bytes.NewReader(data)
+		b   = NewBufferedReader(bytes.NewReader(data), 64)
+	)
+	for i := 0; i < 1000; i++ {
+		switch rand.Intn(4) {
+		case 0:
+			off := rand.Int63n(int64(len(data)))
+			p1, err1 := exp.Seek(off, 0)
+			p2, err2 := b.Seek(off, 0)
+			if p1 != p2 || (err1 == nil) != (err2 == nil) {
+				t.Fatalf("%d: Seek(%d, 0): %d %v != %d %v", i, off, p1, err1, p2, err2)
+			}
+		case 1:
+			off := rand.Int63n(200) - 100
+			p1, err1 := exp.Seek(off, 1)
+			p2, err2 := b.Seek(off, 1)
+			if (err1 == nil) != (err2 == nil) || (err1 == nil && p1 != p2) {
+				t.Fatalf("%d: Seek(%d, 1): %d %v != %d %v", i, off, p1, err1, p2, err2)
+			}
+		default:
+			var (
+				l      = rand.Intn(150)
+				d1, d2 = make([]byte, l), make([]byte, l)
+			)
+			n1, err1 := io.ReadFull(exp, d1)
+			n2, err2 := io.ReadFull(b, d2)
+			if n1 != n2 || err1 != err2 || !bytes.Equal(d1, d2) {
+				t.Fatalf("%d: Read(%d): %d %v != %d %v", i, l, n1, err1, n2, err2)
+			}
+		}
+		p1, _ := exp.Seek(0, 1)
+		p2, _ := b.Seek(0, 1)
+		if p1 != p2 {
+			t.Fatalf("%d: Position mismatch %d != %d", i, p1, p2)
+		}
+	}
+	p1, _ := exp.Seek(-10, 2)
+	p2, _ := b.Seek(-10, 2)
+	if p1 != p2 {
+		t.Errorf("Position mismatch %d != %d", p1, p2)
+	}
+}
+
+func createStringFile(b *testing.B) *os.File {
+	f, err := os.CreateTemp(b.TempDir(), "strings")
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		f.WriteString("The quick brown fox jumps over the lazy dog\u0000")
+	}
+	return f
+}
+
+func benchmarkStrings(b *testing.B, buffered bool) {
+	type Test struct {
+		Strings [1000]string
+	}
+	var (
+		f  = createStringFile(b)
+		t2 Test
+	)
+	defer f.Close()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.Seek(0, 0)
+		br := BinaryReader{Reader: f, Endianess: LittleEndian}
+		if buffered {
+			br.Reader = NewBufferedReader(f, 0)
+		}
+		if err := br.ReadInterface(&t2); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStringsUnbuffered(b *testing.B) {
+	benchmarkStrings(b, false)
+}
+
+func BenchmarkStringsBuffered(b *testing.B) {
+	benchmarkStrings(b, true)
+}
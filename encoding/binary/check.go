@@ -0,0 +1,306 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"fmt"
+	"github.com/quarnster/parser"
+	"github.com/quarnster/util/encoding/binary/expression"
+	"reflect"
+	"strings"
+)
+
+// Checks the tags of a struct type and of all the struct types
+// reachable from it, so that mistakes in a format definition show
+// up in a unit test rather than when decoding the first file that
+// happens to exercise them:
+//
+//	func TestHeaderTags(t *testing.T) {
+//		if err := binary.CheckType(reflect.TypeOf(Header{})); err != nil {
+//			t.Error(err)
+//		}
+//	}
+//
+// In addition to what Precompile checks, CheckType verifies that:
+//
+//   - identifiers in tag expressions refer to fields of integer or
+//     bool kind which are read before the field whose tag it is, or
+//     to registered constants
+//   - first(Field) and last(Field) refer to slices or arrays, and
+//     sizeof(Field) to fields that are read before it
+//   - `length` is only used on slices, strings and fields reading
+//     themselves, and all slices have one
+//   - `bits`, `size`, `typeof`, `zigzag` and `delta` are used on
+//     fields of the kinds they apply to
+//   - the transforms and enums named by tags are registered
+//
+// Only `assert` and `align`, which are evaluated after the field has
+// been read, may refer to the field itself.
+//
+// Identifiers given in vars are accepted as variables which will be
+// provided via BinaryReader.Vars. The types of interface fields
+// depend on the values read and aren't checked.
+func CheckType(t reflect.Type, vars ...string) error {
+	c := checker{vars: make(map[string]bool), seen: make(map[reflect.Type]bool)}
+	for _, v := range vars {
+		c.vars[v] = true
+	}
+	return c.check(t)
+}
+
+type (
+	checker struct {
+		vars map[string]bool
+		seen map[reflect.Type]bool
+	}
+
+	// A tag expression of a field, along with the name of its tag.
+	tagExpr struct {
+		tag string
+		e   *expr
+	}
+)
+
+func (c *checker) check(t reflect.Type) error {
+	if c.seen[t] {
+		return nil
+	}
+	c.seen[t] = true
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Array, reflect.Slice:
+		return c.check(t.Elem())
+	case reflect.Struct:
+		p := getPlan(t)
+		if p.err != nil {
+			return fmt.Errorf("%s: %s", t, p.err)
+		}
+		for i := range p.fields {
+			fp := &p.fields[i]
+			if err := c.checkField(t, p, fp); err != nil {
+				return fmt.Errorf("%s field %s: %s", t, fp.name, err)
+			}
+			if err := c.check(fp.typ); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the tag expressions of the field, including those of its
+// `length` tag.
+func (fp *fieldPlan) tagExprs() []tagExpr {
+	cond := "if"
+	if fp.minver != "" || fp.maxver != "" {
+		cond = "if, minver or maxver"
+	}
+	ret := []tagExpr{
+		{cond, fp.cond}, {"skip", fp.skip}, {"offset", fp.offset},
+		{"bits", fp.bits}, {"max", fp.max}, {"align", fp.align},
+		{"typeof", fp.typeof}, {"assert", fp.assert}, {"size", fp.size},
+		{"delta", fp.delta},
+	}
+	if fp.fieldReader {
+		// Left for ReadField to interpret
+		return ret
+	}
+	for _, l := range strings.Split(fp.length, ",") {
+		switch l = strings.TrimSpace(l); l {
+		case "", "uint8", "uint16", "uint32", "uint64":
+		default:
+			ret = append(ret, tagExpr{"length", parseExpr(l)})
+		}
+	}
+	return ret
+}
+
+func (c *checker) checkField(t reflect.Type, p *structPlan, fp *fieldPlan) error {
+	for _, te := range fp.tagExprs() {
+		if te.e == nil {
+			continue
+		} else if te.e.err != nil {
+			return fmt.Errorf("Tag %s: %s", te.tag, te.e.err)
+		}
+		self := te.tag == "assert" || te.tag == "align"
+		if err := c.checkExpr(t, p, fp, te.e.node, self); err != nil {
+			return fmt.Errorf("Tag %s: %s", te.tag, err)
+		}
+	}
+
+	switch kind := fp.kind; {
+	case fp.fieldReader, fp.transform != "", fp.bounded, fp.packed:
+	case fp.length != "" && kind != reflect.Slice && kind != reflect.String:
+		return fmt.Errorf("The length tag doesn't apply to %s fields", kind)
+	case fp.length == "" && kind == reflect.Slice && fp.uuid == "":
+		return fmt.Errorf("Slices require a length tag")
+	case strings.Contains(fp.length, ",") && kind != reflect.Slice:
+		return fmt.Errorf("Multiple lengths only apply to slices")
+	}
+	if fp.size != nil && !fp.bounded && fp.typ != bigIntType && fp.typ != reflect.PtrTo(bigIntType) {
+		return fmt.Errorf("The size tag doesn't apply to fields of type %s", fp.typ)
+	}
+	if fp.bits != nil && !fp.packed && !isInteger(fp.kind) && fp.kind != reflect.Bool {
+		return fmt.Errorf("The bits tag doesn't apply to %s fields", fp.kind)
+	}
+	if fp.kind == reflect.Interface && fp.typeof == nil {
+		return fmt.Errorf("Interface fields require a typeof tag")
+	} else if fp.kind != reflect.Interface && fp.typeof != nil {
+		return fmt.Errorf("The typeof tag only applies to interface fields")
+	}
+	if fp.zigzag || fp.delta != nil {
+		k := fp.kind
+		if k == reflect.Slice || k == reflect.Array {
+			k = fp.typ.Elem().Kind()
+		}
+		if !isInteger(k) {
+			return fmt.Errorf("The zigzag and delta tags only apply to integers, not %s", k)
+		}
+	}
+	if fp.fixed != nil && fp.fixed.err != nil {
+		return fmt.Errorf("Tag fixed: %s", fp.fixed.err)
+	}
+	if fp.transform != "" {
+		if _, err := lookupTransform(fp.transform); err != nil {
+			return err
+		}
+	}
+	if fp.enum != "" {
+		if _, err := lookupEnum(fp.enum); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Checks the identifiers of the expression node, which is a tag of
+// the field fp of the struct type t. If self is set, the expression
+// may refer to fp itself.
+func (c *checker) checkExpr(t reflect.Type, p *structPlan, fp *fieldPlan, node *parser.Node, self bool) error {
+	var err error
+	expression.Walk(node, func(n *parser.Node) bool {
+		if err != nil {
+			return false
+		}
+		switch n.Name {
+		case "DotIdentifier":
+			err = c.checkRef(t, p, fp, n, self, false)
+		case "First", "Last":
+			err = c.checkRef(t, p, fp, n.Children[0], self, true)
+			if err == nil && len(n.Children) > 1 {
+				ft, _ := fieldType(t, names(n.Children[0]))
+				for ft = ft.Elem(); ft.Kind() == reflect.Ptr; ft = ft.Elem() {
+				}
+				err = checkInteger(ft, n.Children[1])
+			}
+		case "SizeOf":
+			err = checkOrder(p, fp, n.Children[0].Data(), self)
+		default:
+			return true
+		}
+		return false
+	})
+	return err
+}
+
+// Checks the reference to a field by the DotIdentifier n, which is
+// to a slice or array if slice is set, and otherwise to an integer.
+func (c *checker) checkRef(t reflect.Type, p *structPlan, fp *fieldPlan, n *parser.Node, self, slice bool) error {
+	path := names(n)
+	if _, ok := t.FieldByName(path[0]); !ok && len(path) == 1 && !slice {
+		if _, ok := expression.Const(path[0]); ok || c.vars[path[0]] {
+			return nil
+		}
+	}
+	if err := checkOrder(p, fp, path[0], self); err != nil {
+		return err
+	}
+	if !slice {
+		return checkInteger(t, n)
+	}
+	ft, err := fieldType(t, path)
+	if err != nil {
+		return err
+	} else if k := ft.Kind(); k != reflect.Slice && k != reflect.Array {
+		return fmt.Errorf("%s is a %s, not a slice", n.Data(), k)
+	}
+	return nil
+}
+
+// Checks that the field by the given name is read before fp, or is
+// fp itself if self is set. Fields which aren't in the plan, such as
+// those promoted from embedded structs with tags of their own, are
+// read along with the embedded struct and aren't checked.
+func checkOrder(p *structPlan, fp *fieldPlan, name string, self bool) error {
+	ref := p.lookup(name)
+	switch {
+	case ref == nil:
+		if _, ok := p.promoted(name); !ok {
+			return fmt.Errorf("No field by name %s", name)
+		}
+	case ref.index == fp.index && !self:
+		return fmt.Errorf("Refers to the field itself")
+	case ref.index > fp.index:
+		return fmt.Errorf("Refers to %s, which is read after it", name)
+	}
+	return nil
+}
+
+// Returns whether the field by the given name is promoted from a
+// struct embedded in the plan's struct type.
+func (p *structPlan) promoted(name string) (reflect.StructField, bool) {
+	for i := range p.fields {
+		fp := &p.fields[i]
+		t := fp.typ
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Struct && t.Name() == fp.name {
+			if f2, ok := t.FieldByName(name); ok {
+				return f2, true
+			}
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// Checks that the DotIdentifier n refers to an integer or bool field
+// of the struct type t.
+func checkInteger(t reflect.Type, n *parser.Node) error {
+	ft, err := fieldType(t, names(n))
+	if err != nil {
+		return err
+	} else if k := ft.Kind(); !isInteger(k) && k != reflect.Bool {
+		return fmt.Errorf("%s is a %s, not an integer", n.Data(), k)
+	}
+	return nil
+}
+
+// Returns the type of the field at the given path of names in the
+// struct type t.
+func fieldType(t reflect.Type, path []string) (reflect.Type, error) {
+	for _, name := range path {
+		if t.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("%s is a %s, not a struct", t, t.Kind())
+		}
+		f2, ok := t.FieldByName(name)
+		if !ok {
+			return nil, fmt.Errorf("No field by name %s in struct %s", name, t)
+		}
+		t = f2.Type
+	}
+	return t, nil
+}
+
+// Returns the identifiers of the DotIdentifier or Identifier n.
+func names(n *parser.Node) []string {
+	if n.Name == "Identifier" {
+		return []string{n.Data()}
+	}
+	ret := make([]string, len(n.Children))
+	for i, c := range n.Children {
+		ret[i] = c.Data()
+	}
+	return ret
+}
@@ -0,0 +1,109 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"github.com/quarnster/util/encoding/binary/expression"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckType(t *testing.T) {
+	expression.RegisterConst("CheckBlockSize", 512)
+	type (
+		Record struct {
+			Offset uint32
+			Flags  uint8
+		}
+		Inner struct {
+			Count   uint8
+			Records []Record `length:"Count"`
+			Last    uint32   `if:"last(Records).Offset > CheckBlockSize"`
+		}
+		Good struct {
+			Version uint8  `assert:"Version >= 2"`
+			Size    uint16 `align:"Size"`
+			Name    string `length:"Size - sizeof(Version)"`
+			Inner   Inner  `if:"Version > 2"`
+			Padding []byte `length:"Inner.Count * Scale"`
+			Values  []int  `length:"2" zigzag:"true"`
+		}
+	)
+	if err := CheckType(reflect.TypeOf(Good{}), "Scale"); err != nil {
+		t.Error(err)
+	}
+	if err := CheckType(reflect.TypeOf(Good{})); err == nil || !strings.Contains(err.Error(), "Scale") {
+		t.Errorf("Expected an error about Scale, but got %v", err)
+	}
+
+	tests := []struct {
+		v   interface{}
+		exp string
+	}{
+		{struct {
+			A uint8 `if:"B > 0"`
+		}{}, "No field by name B"},
+		{struct {
+			A uint8 `if:"B > 0"`
+			B uint8
+		}{}, "which is read after it"},
+		{struct {
+			A uint8 `if:"A > 0"`
+		}{}, "itself"},
+		{struct {
+			A string
+			B uint8 `if:"A == 1"`
+		}{}, "not an integer"},
+		{struct {
+			A uint8
+			B uint8 `if:"first(A) == 1"`
+		}{}, "not a slice"},
+		{struct {
+			A []Record `length:"1"`
+			B uint8    `if:"last(A).Size == 1"`
+		}{}, "No field by name Size"},
+		{struct {
+			A uint8
+			B uint32 `length:"A"`
+		}{}, "length tag"},
+		{struct {
+			A []uint8
+		}{}, "require a length"},
+		{struct {
+			A float32 `bits:"3"`
+		}{}, "bits tag"},
+		{struct {
+			A uint32 `size:"4"`
+		}{}, "size tag"},
+		{struct {
+			A interface{}
+		}{}, "typeof"},
+		{struct {
+			A string `length:"2" zigzag:"true"`
+		}{}, "zigzag"},
+		{struct {
+			A []byte `length:"2" transform:"check-missing"`
+		}{}, "No transform"},
+		{struct {
+			A uint8 `enum:"check-missing"`
+		}{}, "No enum"},
+		{struct {
+			A uint8 `if:"A >"`
+		}{}, "Tag if"},
+		{struct {
+			A uint8
+			B []struct {
+				C uint8 `if:"A > 1"`
+			} `length:"A"`
+		}{}, "field C: Tag if: No field by name A"},
+	}
+	for i, test := range tests {
+		err := CheckType(reflect.TypeOf(test.v))
+		if err == nil || !strings.Contains(err.Error(), test.exp) {
+			t.Errorf("%d: Expected an error containing %q, but got %v", i, test.exp, err)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"fmt"
+	"io"
+)
+
+type (
+	// ChunkFormat describes the header layout of the records in a
+	// type-length-value (TLV) stream, such as the chunks of PNG or
+	// RIFF files.
+	ChunkFormat struct {
+		// Size in bytes of the tag identifying the chunk type.
+		TagSize int
+		// Size in bytes of the length field, which is decoded using
+		// the BinaryReader's byte order. Must be 1, 2, 4 or 8.
+		LengthSize int
+		// Whether the length field comes before the tag.
+		LengthFirst bool
+		// If larger than 1, chunks are padded to start on a
+		// multiple of Align relative to the first chunk.
+		Align int
+		// Number of bytes following the payload which aren't included
+		// in the length, such as a checksum.
+		TrailerSize int
+
+		// If set, ReadTag and ReadLength are used instead of TagSize and
+		// LengthSize to read the respective header fields, allowing for
+		// variable length encodings.
+		ReadTag    func(r *BinaryReader) ([]byte, error)
+		ReadLength func(r *BinaryReader) (int64, error)
+	}
+
+	// A Chunk is a single record found by a ChunkIterator.
+	Chunk struct {
+		Tag []byte
+		// Absolute stream offset of the chunk's payload.
+		Offset int64
+		// Length of the chunk's payload.
+		Length int64
+		// A reader for the chunk's payload, which is bounded so that
+		// it can't read past the end of the payload.
+		Reader *BinaryReader
+	}
+
+	// ChunkIterator iterates over the chunks of a TLV stream without
+	// decoding their payloads.
+	//
+	//	it := NewChunkIterator(r, PNGChunks)
+	//	for it.Next() {
+	//		c := it.Chunk()
+	//		if string(c.Tag) == "IHDR" {
+	//			c.Reader.ReadInterface(&hdr)
+	//		}
+	//	}
+	//	if err := it.Err(); err != nil {
+	//		...
+	//	}
+	ChunkIterator struct {
+		r      *BinaryReader
+		format ChunkFormat
+		start  int64
+		next   int64
+		chunk  Chunk
+		err    error
+	}
+
+	// boundedReader restricts reading and seeking to the
+	// [start, end) range of the inner io.ReadSeeker, with
+	// offsets relative to start.
+	boundedReader struct {
+		inner      io.ReadSeeker
+		start, end int64
+		pos        int64
+	}
+)
+
+var (
+	PNGChunks  = ChunkFormat{TagSize: 4, LengthSize: 4, LengthFirst: true, TrailerSize: 4}
+	RIFFChunks = ChunkFormat{TagSize: 4, LengthSize: 4, Align: 2}
+)
+
+// Creates a new ChunkIterator reading chunks described by
+// the given format from the current position of r on.
+func NewChunkIterator(r *BinaryReader, format ChunkFormat) *ChunkIterator {
+	it := &ChunkIterator{r: r, format: format}
+	if it.start, it.err = r.Seek(0, 1); it.err == nil {
+		it.next = it.start
+	}
+	return it
+}
+
+func (it *ChunkIterator) readTag() ([]byte, error) {
+	if it.format.ReadTag != nil {
+		return it.format.ReadTag(it.r)
+	}
+	return it.r.Read(it.format.TagSize)
+}
+
+func (it *ChunkIterator) readLength() (int64, error) {
+	if it.format.ReadLength != nil {
+		return it.format.ReadLength(it.r)
+	}
+	switch it.format.LengthSize {
+	case 1:
+		v, err := it.r.Uint8()
+		return int64(v), err
+	case 2:
+		v, err := it.r.Uint16()
+		return int64(v), err
+	case 4:
+		v, err := it.r.Uint32()
+		return int64(v), err
+	case 8:
+		v, err := it.r.Uint64()
+		return int64(v), err
+	default:
+		return 0, fmt.Errorf("Unsupported chunk length size: %d", it.format.LengthSize)
+	}
+}
+
+// Advances to the next chunk, returning false once there are no
+// more chunks or an error occurred. Any part of the previous chunk's
+// payload that wasn't read is skipped.
+func (it *ChunkIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if _, it.err = it.r.Seek(it.next, 0); it.err != nil {
+		return false
+	}
+	var (
+		c   Chunk
+		err error
+	)
+	if it.format.LengthFirst {
+		if c.Length, err = it.readLength(); err == nil {
+			c.Tag, err = it.readTag()
+		}
+	} else {
+		if c.Tag, err = it.readTag(); err == nil {
+			c.Length, err = it.readLength()
+		}
+	}
+	if err == io.EOF && it.r.Tell() == it.next {
+		// Clean end of stream
+		return false
+	} else if err != nil {
+		it.err = err
+		return false
+	} else if c.Length < 0 {
+		it.err = fmt.Errorf("Invalid chunk length: %d", c.Length)
+		return false
+	}
+	c.Offset = it.r.Tell()
+	c.Reader = &BinaryReader{
+		Reader:    &boundedReader{inner: it.r.Reader, start: c.Offset, end: c.Offset + c.Length},
+		Endianess: it.r.Endianess,
+		Vars:      it.r.Vars,
+	}
+	it.next = c.Offset + c.Length + int64(it.format.TrailerSize)
+	if a := int64(it.format.Align); a > 1 {
+		if rem := (it.next - it.start) % a; rem != 0 {
+			it.next += a - rem
+		}
+	}
+	it.chunk = c
+	return true
+}
+
+// Returns the current chunk.
+func (it *ChunkIterator) Chunk() *Chunk {
+	return &it.chunk
+}
+
+// Returns the error, if any, that caused Next to return false.
+func (it *ChunkIterator) Err() error {
+	return it.err
+}
+
+func (b *boundedReader) Read(p []byte) (int, error) {
+	if b.pos >= b.end-b.start {
+		return 0, io.EOF
+	}
+	if rem := b.end - b.start - b.pos; int64(len(p)) > rem {
+		p = p[:rem]
+	}
+	if _, err := b.inner.Seek(b.start+b.pos, 0); err != nil {
+		return 0, err
+	}
+	n, err := b.inner.Read(p)
+	b.pos += int64(n)
+	return n, err
+}
+
+func (b *boundedReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case 0:
+	case 1:
+		offset += b.pos
+	case 2:
+		offset += b.end - b.start
+	default:
+		return 0, fmt.Errorf("Invalid whence: %d", whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("Can't seek to negative position: %d", offset)
+	}
+	b.pos = offset
+	return offset, nil
+}
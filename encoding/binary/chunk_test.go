@@ -0,0 +1,85 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestChunkIteratorPNG(t *testing.T) {
+	data := []byte{
+		0, 0, 0, 2, 'I', 'H', 'D', 'R', 1, 2, 0xc, 0xc, 0xc, 0xc,
+		0, 0, 0, 0, 'I', 'D', 'A', 'T', 0xc, 0xc, 0xc, 0xc,
+		0, 0, 0, 3, 'I', 'E', 'N', 'D', 3, 4, 5, 0xc, 0xc, 0xc, 0xc,
+	}
+	exp := []struct {
+		tag    string
+		offset int64
+		data   []byte
+	}{
+		{"IHDR", 8, []byte{1, 2}},
+		{"IDAT", 22, []byte{}},
+		{"IEND", 34, []byte{3, 4, 5}},
+	}
+	br := &BinaryReader{Reader: bytes.NewReader(data), Endianess: BigEndian}
+	it := NewChunkIterator(br, PNGChunks)
+	i := 0
+	for ; it.Next(); i++ {
+		if i >= len(exp) {
+			t.Fatalf("Too many chunks")
+		}
+		c := it.Chunk()
+		if string(c.Tag) != exp[i].tag || c.Offset != exp[i].offset || c.Length != int64(len(exp[i].data)) {
+			t.Errorf("%d: Unexpected chunk: %s %d %d", i, c.Tag, c.Offset, c.Length)
+		}
+		if d, err := c.Reader.Read(int(c.Length)); err != nil {
+			t.Errorf("%d: %s", i, err)
+		} else if !bytes.Equal(d, exp[i].data) {
+			t.Errorf("%d: Expected %v, but got %v", i, exp[i].data, d)
+		}
+		if _, err := c.Reader.Uint8(); err == nil {
+			t.Errorf("%d: Shouldn't be able to read past the end of the chunk", i)
+		}
+	}
+	if err := it.Err(); err != nil {
+		t.Error(err)
+	}
+	if i != len(exp) {
+		t.Errorf("Expected %d chunks, but got %d", len(exp), i)
+	}
+}
+
+func TestChunkIteratorRIFF(t *testing.T) {
+	data := []byte{
+		'f', 'm', 't', ' ', 3, 0, 0, 0, 1, 2, 3, 0,
+		'd', 'a', 't', 'a', 2, 0, 0, 0, 4, 5,
+	}
+	br := &BinaryReader{Reader: bytes.NewReader(data), Endianess: LittleEndian}
+	it := NewChunkIterator(br, RIFFChunks)
+	var tags []string
+	for it.Next() {
+		tags = append(tags, string(it.Chunk().Tag))
+		// Leave the payload unread; Next should skip it
+	}
+	if err := it.Err(); err != nil {
+		t.Error(err)
+	}
+	if len(tags) != 2 || tags[0] != "fmt " || tags[1] != "data" {
+		t.Errorf("Unexpected tags: %v", tags)
+	}
+}
+
+func TestChunkIteratorTruncated(t *testing.T) {
+	data := []byte{'f', 'm', 't', ' ', 3, 0}
+	br := &BinaryReader{Reader: bytes.NewReader(data), Endianess: LittleEndian}
+	it := NewChunkIterator(br, RIFFChunks)
+	if it.Next() {
+		t.Error("Didn't expect a chunk")
+	}
+	if it.Err() == nil {
+		t.Error("Expected an error, but didn't get one")
+	}
+}
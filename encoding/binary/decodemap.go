@@ -0,0 +1,187 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"context"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+)
+
+type (
+	// The position of a field in the stream.
+	fieldExtent struct {
+		offset, size int64
+	}
+
+	// A slog.Handler collecting the extents of the fields
+	// traced by a BinaryReader, by path.
+	extentHandler map[string]fieldExtent
+)
+
+var (
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+func (h extentHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h extentHandler) Handle(_ context.Context, rec slog.Record) error {
+	var (
+		path string
+		e    fieldExtent
+	)
+	rec.Attrs(func(a slog.Attr) bool {
+		switch a.Key {
+		case "path":
+			path = a.Value.String()
+		case "offset":
+			e.offset = a.Value.Int64()
+		case "size":
+			e.size = a.Value.Int64()
+		}
+		return true
+	})
+	h[path] = e
+	return nil
+}
+
+func (h extentHandler) WithAttrs([]slog.Attr) slog.Handler {
+	return h
+}
+
+func (h extentHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// Reads the struct v points to, like ReadInterface does, and returns
+// a map representation of it suitable for serializing to JSON or YAML,
+// such as for format inspectors or for diffing the decoded structures
+// of two files.
+//
+// Each field read is represented by a map holding its "offset" in the
+// stream, its "size" in bytes, its raw bytes as a "hex" string and
+// its decoded "value":
+//
+//	{
+//		"Header": {
+//			"offset": 0, "size": 5, "hex": "46494c4501",
+//			"value": {
+//				"Magic": {"offset": 0, "size": 4, "hex": "46494c45", "value": [70, 73, 76, 69]},
+//				"Kind":  {"offset": 4, "size": 1, "hex": "01", "value": 1}
+//			}
+//		}
+//	}
+//
+// The values of struct fields are maps of their fields in turn, as
+// are the elements of slices and arrays of structs. Fields that
+// weren't read, such as due to an `if` tag, are left out. Fields
+// with an `enum` tag also have the symbolic "enum" name of their
+// value, if it has one.
+func DecodeToMap(r *BinaryReader, v interface{}) (map[string]interface{}, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("Expected a pointer to a struct, not %T", v)
+	}
+	var (
+		extents = make(extentHandler)
+		trace   = r.Trace
+	)
+	r.Trace = slog.New(extents)
+	err := r.ReadInterface(v)
+	r.Trace = trace
+	if err != nil {
+		return nil, err
+	}
+	end, err := r.Seek(0, 1)
+	if err != nil {
+		return nil, err
+	}
+	ret, err := r.structMap("", rv.Elem(), extents)
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.Seek(end, 0)
+	return ret, err
+}
+
+// Returns the map representation of the fields of the struct v
+// found at the given path.
+func (r *BinaryReader) structMap(path string, v reflect.Value, extents extentHandler) (map[string]interface{}, error) {
+	var (
+		plan = getPlan(v.Type())
+		ret  = make(map[string]interface{}, len(plan.fields))
+	)
+	for i := range plan.fields {
+		fp := &plan.fields[i]
+		p := fp.name
+		if path != "" {
+			p = path + "." + fp.name
+		}
+		e, ok := extents[p]
+		if !ok {
+			continue
+		}
+		if _, err := r.Seek(e.offset, 0); err != nil {
+			return nil, err
+		}
+		raw, err := r.Read(int(e.size))
+		if err != nil {
+			return nil, err
+		}
+		val, err := r.valueMap(p, fp.field(v), extents)
+		if err != nil {
+			return nil, err
+		}
+		node := map[string]interface{}{
+			"offset": e.offset,
+			"size":   e.size,
+			"hex":    hex.EncodeToString(raw),
+			"value":  val,
+		}
+		if name := fp.enumName(fp.field(v)); name != "" {
+			node["enum"] = name
+		}
+		ret[fp.name] = node
+		r.release(raw)
+	}
+	return ret, nil
+}
+
+// Returns the representation of the value v found at the given path.
+func (r *BinaryReader) valueMap(path string, v reflect.Value, extents extentHandler) (interface{}, error) {
+	if t := reflect.PtrTo(v.Type()); t.Implements(textMarshalerType) || t.Implements(jsonMarshalerType) || readsItself(v.Type()) {
+		// Types that know how to represent or read themselves
+		return v.Interface(), nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return r.valueMap(path, v.Elem(), extents)
+	case reflect.Struct:
+		return r.structMap(path, v, extents)
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() != reflect.Struct {
+			break
+		}
+		ret := make([]interface{}, v.Len())
+		for i := range ret {
+			var err error
+			if ret[i], err = r.valueMap(fmt.Sprintf("%s[%d]", path, i), v.Index(i), extents); err != nil {
+				return nil, err
+			}
+		}
+		return ret, nil
+	}
+	return v.Interface(), nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeToMap(t *testing.T) {
+	type (
+		Entry struct {
+			X uint16
+		}
+		File struct {
+			Magic   [2]byte
+			Count   uint8
+			Entries []Entry `length:"Count"`
+			Extra   uint8   `if:"Count > 2"`
+			ID      UUID
+		}
+	)
+	var (
+		data = append([]byte{'F', 'I', 2, 1, 0, 2, 0}, make([]byte, 16)...)
+		br   = BinaryReader{Reader: bytes.NewReader(data), Endianess: LittleEndian}
+		v    File
+	)
+	m, err := DecodeToMap(&br, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if br.Tell() != int64(len(data)) {
+		t.Errorf("Expected to end up at %d, not %d", len(data), br.Tell())
+	}
+	if br.Trace != nil {
+		t.Error("The reader's Trace wasn't restored")
+	}
+	d, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, exp interface{}
+	json.Unmarshal(d, &got)
+	json.Unmarshal([]byte(`{
+		"Magic": {"offset": 0, "size": 2, "hex": "4649", "value": [70, 73]},
+		"Count": {"offset": 2, "size": 1, "hex": "02", "value": 2},
+		"Entries": {"offset": 3, "size": 4, "hex": "01000200", "value": [
+			{"X": {"offset": 3, "size": 2, "hex": "0100", "value": 1}},
+			{"X": {"offset": 5, "size": 2, "hex": "0200", "value": 2}}
+		]},
+		"ID": {"offset": 7, "size": 16, "hex": "00000000000000000000000000000000", "value": "00000000-0000-0000-0000-000000000000"}
+	}`), &exp)
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("Unexpected result:\n%s", d)
+	}
+
+	if _, err := DecodeToMap(&br, v); err == nil {
+		t.Error("Expected an error for a non-pointer")
+	}
+}
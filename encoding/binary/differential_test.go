@@ -0,0 +1,105 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	sb "encoding/binary"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+// The types in this list are all expressible with the standard
+// library's encoding/binary package, i.e. they only contain fixed
+// size data and don't need any tags. Each of them is cross-checked
+// against the standard library with randomly generated values.
+var differentialTypes = []interface{}{
+	struct {
+		A uint8
+		B uint16
+		C uint32
+		D uint64
+	}{},
+	struct {
+		A int8
+		B int16
+		C int32
+		D int64
+	}{},
+	struct {
+		A float32
+		B float64
+		C bool
+	}{},
+	struct {
+		A [3]uint16
+		B [2]struct {
+			X, Y int32
+		}
+		C [0]uint8
+	}{},
+	struct {
+		Header struct {
+			Magic   [4]byte
+			Version uint16
+		}
+		Points [4]struct {
+			X, Y, Z float32
+		}
+		Flags [3]bool
+	}{},
+	[8]int16{},
+}
+
+// Generates random values of type t, encodes them with the standard
+// library, and checks that decoding them with the BinaryReader gives
+// the same result as decoding them with the standard library.
+//
+// The values are compared by re-encoding them rather than directly,
+// as that also works for NaN floats.
+func crossCheck(t *testing.T, typ reflect.Type, order sb.ByteOrder, rnd *rand.Rand, iterations int) {
+	for i := 0; i < iterations; i++ {
+		in, ok := quick.Value(typ, rnd)
+		if !ok {
+			t.Fatalf("Unable to generate a value of type %s", typ)
+		}
+		var data bytes.Buffer
+		if err := sb.Write(&data, order, in.Interface()); err != nil {
+			t.Fatalf("%s: %s", typ, err)
+		}
+		var (
+			exp = reflect.New(typ)
+			got = reflect.New(typ)
+			br  = BinaryReader{Reader: bytes.NewReader(data.Bytes()), Endianess: order}
+		)
+		if err := sb.Read(bytes.NewReader(data.Bytes()), order, exp.Interface()); err != nil {
+			t.Fatalf("%s: %s", typ, err)
+		}
+		if err := br.ReadInterface(got.Interface()); err != nil {
+			t.Errorf("%s: %s", typ, err)
+			continue
+		}
+		if p := br.Tell(); p != int64(data.Len()) {
+			t.Errorf("%s: Read %d bytes, but the encoded size is %d", typ, p, data.Len())
+		}
+		var a, b bytes.Buffer
+		sb.Write(&a, order, exp.Elem().Interface())
+		sb.Write(&b, order, got.Elem().Interface())
+		if !bytes.Equal(a.Bytes(), b.Bytes()) {
+			t.Errorf("%s: Decoded values differ:\nstdlib: %+v\nbinary: %+v", typ, exp.Elem(), got.Elem())
+		}
+	}
+}
+
+func TestDifferentialStdlib(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1337))
+	for _, v := range differentialTypes {
+		for _, order := range []sb.ByteOrder{LittleEndian, BigEndian} {
+			crossCheck(t, reflect.TypeOf(v), order, rnd, 100)
+		}
+	}
+}
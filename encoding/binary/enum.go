@@ -0,0 +1,69 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Parses an `enum` tag into the name of the enumeration and
+// whether unknown values are errors.
+func parseEnum(tag string) (name string, strict bool) {
+	name, opt, _ := strings.Cut(tag, ",")
+	return strings.TrimSpace(name), strings.TrimSpace(opt) == "strict"
+}
+
+// Returns the integer value of f, or false if f isn't an integer.
+func enumValue(f reflect.Value) (int64, bool) {
+	switch f.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return f.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int64(f.Uint()), true
+	}
+	return 0, false
+}
+
+// Checks the value of a field with an `enum` tag, which must either
+// be an integer or a slice or array of integers. Unless the tag has
+// the strict option, only the existence of the enumeration is checked.
+func validateEnum(f reflect.Value, fp *fieldPlan) error {
+	names, err := lookupEnum(fp.enum)
+	if err != nil {
+		return fmt.Errorf("Field %s: %s", fp.name, err)
+	}
+	values := []reflect.Value{f}
+	if k := f.Kind(); k == reflect.Slice || k == reflect.Array {
+		values = values[:0]
+		for i := 0; i < f.Len(); i++ {
+			values = append(values, f.Index(i))
+		}
+	}
+	for _, v := range values {
+		i, ok := enumValue(v)
+		if !ok {
+			return fmt.Errorf("Field %s: enums must be integers, not %s", fp.name, v.Type())
+		} else if _, ok := names[i]; fp.strictEnum && !ok {
+			return fmt.Errorf("Field %s: %d isn't a known %s value", fp.name, i, fp.enum)
+		}
+	}
+	return nil
+}
+
+// Returns the symbolic name of the value of the integer field f,
+// or "" if it has none.
+func (fp *fieldPlan) enumName(f reflect.Value) string {
+	if fp.enum == "" {
+		return ""
+	}
+	i, ok := enumValue(f)
+	if !ok {
+		return ""
+	}
+	name, _ := EnumName(fp.enum, i)
+	return name
+}
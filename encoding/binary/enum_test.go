@@ -0,0 +1,63 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	sb "encoding/binary"
+	"testing"
+)
+
+func TestBinaryReaderEnum(t *testing.T) {
+	RegisterEnum("testColor", map[int64]string{0: "Gray", 2: "RGB", 3: "Palette"})
+	if name, ok := EnumName("testColor", 2); !ok || name != "RGB" {
+		t.Errorf("Expected RGB, got %q", name)
+	}
+	if _, ok := EnumName("testColor", 1); ok {
+		t.Error("Didn't expect 1 to have a name")
+	}
+
+	type (
+		Loose struct {
+			Color uint8 `enum:"testColor"`
+		}
+		Strict struct {
+			Color  uint8    `enum:"testColor,strict"`
+			Colors [2]int16 `enum:"testColor, strict"`
+		}
+		Unknown struct {
+			Color uint8 `enum:"testMissing"`
+		}
+	)
+	tests := []struct {
+		v    interface{}
+		data []byte
+		err  bool
+	}{
+		{&Loose{}, []byte{1}, false},
+		{&Strict{}, []byte{3, 0, 0, 2, 0}, false},
+		{&Strict{}, []byte{1, 0, 0, 2, 0}, true},
+		{&Strict{}, []byte{3, 0, 0, 4, 0}, true},
+		{&Unknown{}, []byte{1}, true},
+	}
+	for i, test := range tests {
+		br := BinaryReader{Reader: bytes.NewReader(test.data), Endianess: sb.LittleEndian}
+		if err := br.ReadInterface(test.v); (err != nil) != test.err {
+			t.Errorf("Test %d: Unexpected error: %v", i, err)
+		}
+	}
+
+	var (
+		s  Strict
+		br = BinaryReader{Reader: bytes.NewReader([]byte{2, 0, 0, 3, 0}), Endianess: sb.LittleEndian}
+	)
+	m, err := DecodeToMap(&br, &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := m["Color"].(map[string]interface{})["enum"]; name != "RGB" {
+		t.Errorf("Expected the enum name RGB, got %v", name)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package expression
+
+import (
+	"github.com/quarnster/parser"
+	"strings"
+)
+
+// The source form of the binary operators, by node name.
+var operators = map[string]string{
+	"Or":         "||",
+	"And":        "&&",
+	"ShiftRight": ">>",
+	"ShiftLeft":  "<<",
+	"Mask":       "&",
+	"Add":        "+",
+	"Sub":        "-",
+	"Mul":        "*",
+	"AndNot":     "&^",
+	"Eq":         "==",
+	"Ne":         "!=",
+	"Lt":         "<",
+	"Le":         "<=",
+	"Gt":         ">",
+	"Ge":         ">=",
+}
+
+// Parses the expression src, returning the root node of its tree.
+func Parse(src string) (*parser.Node, error) {
+	var p EXPRESSION
+	if !p.Parse(src) {
+		return nil, p.Error()
+	}
+	return p.RootNode(), nil
+}
+
+// Walks the expression tree rooted at node in depth first order,
+// calling visit for every node before its children. The children
+// of a node are skipped if visit returns false for it.
+//
+// Nodes are named after the rule that produced them: binary
+// operators are named Add, Sub, Mask, Eq, And, Or and so on, while
+// the leaves are DotIdentifier, First, Last, SizeOf and Constant.
+func Walk(node *parser.Node, visit func(n *parser.Node) bool) {
+	if !visit(node) {
+		return
+	}
+	for _, c := range node.Children {
+		Walk(c, visit)
+	}
+}
+
+// Returns the fields the expression node refers to, in the order
+// they first appear. Fields of nested structs are returned dotted,
+// as in Header.Size, and only the slice is returned for first(Field)
+// and last(Field). Identifiers naming constants or variables can't
+// be told apart from fields, and are returned as well.
+func Fields(node *parser.Node) []string {
+	var (
+		ret  []string
+		seen = make(map[string]bool)
+	)
+	add := func(n *parser.Node) {
+		if name := n.Data(); !seen[name] {
+			seen[name] = true
+			ret = append(ret, name)
+		}
+	}
+	Walk(node, func(n *parser.Node) bool {
+		switch n.Name {
+		case "DotIdentifier", "Identifier":
+			add(n)
+			return false
+		case "First", "Last", "SizeOf":
+			// Any further identifiers refer to the element
+			add(n.Children[0])
+			return false
+		}
+		return true
+	})
+	return ret
+}
+
+// Returns the source form of the expression node, normalized so
+// that binary operators are surrounded by single spaces and only
+// the parentheses the grammar requires are kept. Parsing the result
+// gives a tree equal to that of node.
+func Format(node *parser.Node) string {
+	var buf strings.Builder
+	format(&buf, node)
+	return buf.String()
+}
+
+func format(buf *strings.Builder, node *parser.Node) {
+	switch node.Name {
+	case "EXPRESSION":
+		if len(node.Children) > 0 {
+			format(buf, node.Children[0])
+		}
+	case "First", "Last", "SizeOf":
+		buf.WriteString(strings.ToLower(node.Name))
+		buf.WriteByte('(')
+		buf.WriteString(node.Children[0].Data())
+		buf.WriteByte(')')
+		if len(node.Children) > 1 {
+			buf.WriteByte('.')
+			buf.WriteString(node.Children[1].Data())
+		}
+	case "Or", "And":
+		// Plain operations never need parentheses here. Of the logical
+		// ones, only And is taken bare on the left hand side of Or and
+		// on the right hand side of And, while Or takes both bare on
+		// its right hand side.
+		l, r := node.Children[0], node.Children[1]
+		formatOperand(buf, l, l.Name != "Or" && (l.Name != "And" || node.Name == "Or"))
+		buf.WriteString(" " + operators[node.Name] + " ")
+		formatOperand(buf, r, node.Name == "Or" || r.Name != "Or")
+	default:
+		if op, ok := operators[node.Name]; ok {
+			formatOperand(buf, node.Children[0], false)
+			buf.WriteString(" " + op + " ")
+			formatOperand(buf, node.Children[1], false)
+		} else {
+			buf.WriteString(node.Data())
+		}
+	}
+}
+
+// Formats the operand of a binary operation, parenthesizing it if
+// it's an operation itself and bare isn't set.
+func formatOperand(buf *strings.Builder, node *parser.Node, bare bool) {
+	if _, ok := operators[node.Name]; !ok || bare {
+		format(buf, node)
+		return
+	}
+	buf.WriteByte('(')
+	format(buf, node)
+	buf.WriteByte(')')
+}
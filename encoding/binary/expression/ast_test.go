@@ -0,0 +1,83 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package expression
+
+import (
+	"github.com/quarnster/parser"
+	"reflect"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := [][2]string{
+		{"(MyMask & (Test >> 3)) << 0x2", "(MyMask & (Test >> 3)) << 0x2"},
+		{"Length-1", "Length - 1"},
+		{"A.B.C", "A.B.C"},
+		{"A && B || C", "A && B || C"},
+		{"A || (B || C)", "A || B || C"},
+		{"(A || B) || C", "(A || B) || C"},
+		{"(A && B) && C", "(A && B) && C"},
+		{"A && (B || C)", "A && (B || C)"},
+		{"(A==1)&&(B!=2)", "A == 1 && B != 2"},
+		{"last(Records).Offset+sizeof(Name)", "last(Records).Offset + sizeof(Name)"},
+		{"first(A.B) &^ 0xff", "first(A.B) &^ 0xff"},
+	}
+	for _, test := range tests {
+		n, err := Parse(test[0])
+		if err != nil {
+			t.Errorf("%s: %s", test[0], err)
+			continue
+		}
+		if s := Format(n); s != test[1] {
+			t.Errorf("%s: Expected %q, but got %q", test[0], test[1], s)
+		} else if n2, err := Parse(s); err != nil {
+			t.Errorf("%s: %s", s, err)
+		} else if Format(n2) != s {
+			t.Errorf("%s: Formatting isn't stable: %q", s, Format(n2))
+		}
+	}
+}
+
+func TestFields(t *testing.T) {
+	tests := []struct {
+		src string
+		exp []string
+	}{
+		{"A + (B.C * A)", []string{"A", "B.C"}},
+		{"last(Records).Offset - sizeof(Name)", []string{"Records", "Name"}},
+		{"0x10", nil},
+	}
+	for _, test := range tests {
+		n, err := Parse(test.src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f := Fields(n); !reflect.DeepEqual(f, test.exp) {
+			t.Errorf("%s: Expected %v, but got %v", test.src, test.exp, f)
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	n, err := Parse("(A & 3) == B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	Walk(n, func(n *parser.Node) bool {
+		names = append(names, n.Name)
+		return n.Name != "Mask"
+	})
+	exp := []string{"EXPRESSION", "Eq", "Mask", "DotIdentifier", "Identifier", "EndOfFile"}
+	if !reflect.DeepEqual(names, exp) {
+		t.Errorf("Expected %v, but got %v", exp, names)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	if _, err := Parse("A +"); err == nil {
+		t.Error("Expected an error")
+	}
+}
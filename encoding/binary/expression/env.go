@@ -0,0 +1,56 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package expression
+
+import (
+	"sync"
+)
+
+// The environment an expression is evaluated in, in addition to
+// the struct whose fields it refers to.
+type Env struct {
+	// Resolves sizeof(Field), if non-nil.
+	SizeOf SizeFunc
+	// Values of identifiers which aren't fields of the struct,
+	// such as settings provided by the application at run time.
+	// These take precedence over registered constants.
+	Vars map[string]int
+}
+
+var (
+	constsLock sync.RWMutex
+	consts     = make(map[string]int)
+)
+
+// Registers a named constant which expressions can refer to just
+// like a field, such as a block size that is fixed by a format's
+// specification but not stored in its files. Fields and variables
+// by the same name take precedence over constants.
+//
+// Registering a constant under an already registered name
+// replaces the previous one.
+func RegisterConst(name string, value int) {
+	constsLock.Lock()
+	defer constsLock.Unlock()
+	consts[name] = value
+}
+
+// Returns the value of the variable or constant by the given name.
+func (e *Env) lookup(name string) (int, bool) {
+	if e != nil {
+		if v, ok := e.Vars[name]; ok {
+			return v, true
+		}
+	}
+	return Const(name)
+}
+
+// Returns the value of the constant registered under the given name.
+func Const(name string) (int, bool) {
+	constsLock.RLock()
+	defer constsLock.RUnlock()
+	v, ok := consts[name]
+	return v, ok
+}
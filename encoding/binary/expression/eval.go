@@ -11,48 +11,150 @@ import (
 	"strconv"
 )
 
+// Looks up the field by the given name in the struct v, including
+// fields promoted from embedded structs. Unlike v.FieldByName, an
+// invalid Value is returned rather than panicking when the field is
+// promoted through a nil embedded pointer.
+func fieldByName(v reflect.Value, name string) reflect.Value {
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+	f2, ok := v.Type().FieldByName(name)
+	if !ok {
+		return reflect.Value{}
+	}
+	for i, x := range f2.Index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// Resolves the DotIdentifier or Identifier node to a field of the
+// struct v.
+func lookup(v reflect.Value, node *parser.Node) (reflect.Value, error) {
+	children := []*parser.Node{node}
+	if node.Name == "DotIdentifier" {
+		children = node.Children
+	}
+	for _, child := range children {
+		f := fieldByName(v, child.Data())
+		if !f.IsValid() {
+			return f, fmt.Errorf("No field by name %s in struct %s", node.Data(), v.Type())
+		}
+		v = f
+	}
+	return v, nil
+}
+
+// Returns the integer value of f.
+func intValue(f reflect.Value) (int, error) {
+	switch f.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(f.Uint()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(f.Int()), nil
+	case reflect.Bool:
+		if f.Bool() {
+			return 1, nil
+		} else {
+			return 0, nil
+		}
+	default:
+		return 0, fmt.Errorf("Unexpected identifier kind: %v %v", f, f.Kind())
+	}
+}
+
+// Returns the number of bytes the named field took up when it was
+// read, which is what sizeof(Field) evaluates to.
+type SizeFunc func(field string) (int, error)
+
+// Evaluates the expression node in the context of the struct v,
+// where identifiers refer to the struct's fields. Fields of embedded
+// structs can be referred to directly, as in Go.
+//
+// The first and last elements of an already read slice or array
+// field are available via first(Field) and last(Field), optionally
+// followed by the name of a field in the element, as in
+// last(Records).Offset.
+//
+// Identifiers which aren't fields of the struct may also refer to
+// constants registered with RegisterConst.
+//
+// Expressions using sizeof(Field) can only be evaluated with EvalSizes
+// or EvalEnv.
 func Eval(v *reflect.Value, node *parser.Node) (int, error) {
+	return EvalEnv(v, node, nil)
+}
+
+// Evaluates the expression node just like Eval, using sizeof to
+// resolve any sizeof(Field) in it.
+func EvalSizes(v *reflect.Value, node *parser.Node, sizeof SizeFunc) (int, error) {
+	return EvalEnv(v, node, &Env{SizeOf: sizeof})
+}
+
+// Evaluates the expression node just like Eval, with identifiers
+// which aren't fields of the struct also referring to the given
+// variables.
+func EvalVars(v *reflect.Value, node *parser.Node, vars map[string]int) (int, error) {
+	return EvalEnv(v, node, &Env{Vars: vars})
+}
+
+// Evaluates the expression node just like Eval, in the given
+// environment, which may be nil.
+func EvalEnv(v *reflect.Value, node *parser.Node, env *Env) (int, error) {
 	switch node.Name {
 	case "EXPRESSION":
 		if l := len(node.Children); l != 2 {
 			return 0, fmt.Errorf("Unexpected child length: %d, %s", l, node)
 		}
-		return Eval(v, node.Children[0])
-	case "DotIdentifier":
-		curr := v.Type().Name()
-		children := node.Children
-		if len(children) > 0 {
-			// The last one will be handled by the fallthrough instead
-			children = node.Children[:len(node.Children)-1]
-		}
-		for _, child := range children {
-			f := v.FieldByName(child.Data())
-			if !f.IsValid() {
-				return 0, fmt.Errorf("No field by name %s in struct %s", node.Data(), curr)
+		return EvalEnv(v, node.Children[0], env)
+	case "DotIdentifier", "Identifier":
+		f, err := lookup(*v, node)
+		if err == nil {
+			return intValue(f)
+		} else if node.Name == "Identifier" || len(node.Children) == 1 {
+			if c, ok := env.lookup(node.Data()); ok {
+				return c, nil
 			}
-			v = &f
 		}
-		node = node.Children[len(node.Children)-1]
-		fallthrough
-	case "Identifier":
-		if f := v.FieldByName(node.Data()); !f.IsValid() {
-			return 0, fmt.Errorf("No field by name %s in struct %s", node.Data(), v)
-		} else {
-			switch f.Kind() {
-			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-				return int(f.Uint()), nil
-			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-				return int(f.Int()), nil
-			case reflect.Bool:
-				if f.Bool() {
-					return 1, nil
-				} else {
-					return 0, nil
-				}
-			default:
-				return 0, fmt.Errorf("Unexpected identifier kind: %v %v", f, f.Kind())
+		return 0, err
+	case "First", "Last":
+		s, err := lookup(*v, node.Children[0])
+		if err != nil {
+			return 0, err
+		}
+		switch s.Kind() {
+		case reflect.Slice, reflect.Array:
+		default:
+			return 0, fmt.Errorf("%s is a %s, not a slice", node.Children[0].Data(), s.Kind())
+		}
+		if s.Len() == 0 {
+			return 0, fmt.Errorf("%s has no elements", node.Children[0].Data())
+		}
+		e := s.Index(0)
+		if node.Name == "Last" {
+			e = s.Index(s.Len() - 1)
+		}
+		for e.Kind() == reflect.Ptr && !e.IsNil() {
+			e = e.Elem()
+		}
+		if len(node.Children) > 1 {
+			if e, err = lookup(e, node.Children[1]); err != nil {
+				return 0, err
 			}
 		}
+		return intValue(e)
+	case "SizeOf":
+		if env == nil || env.SizeOf == nil {
+			return 0, fmt.Errorf("Field sizes aren't available in this context")
+		}
+		return env.SizeOf(node.Children[0].Data())
 	case "Constant":
 		i, err := strconv.ParseInt(node.Data(), 0, 32)
 		return int(i), err
@@ -62,9 +164,12 @@ func Eval(v *reflect.Value, node *parser.Node) (int, error) {
 		if l := len(node.Children); l != 2 {
 			return 0, fmt.Errorf("Unexpected child length: %d, %s", l, node)
 		}
-		if a, err := Eval(v, node.Children[0]); err != nil {
+		if node.Name == "And" || node.Name == "Or" {
+			return evalLogical(v, node, env)
+		}
+		if a, err := EvalEnv(v, node.Children[0], env); err != nil {
 			return 0, err
-		} else if b, err := Eval(v, node.Children[1]); err != nil {
+		} else if b, err := EvalEnv(v, node.Children[1], env); err != nil {
 			return 0, err
 		} else {
 			switch node.Name {
@@ -124,3 +229,26 @@ func Eval(v *reflect.Value, node *parser.Node) (int, error) {
 		}
 	}
 }
+
+// Evaluates the And or Or node, only evaluating its right hand side
+// if the left hand side doesn't decide the result, so that guards
+// such as Count > 0 && first(Items).Kind == 2 don't fail on empty
+// slices.
+func evalLogical(v *reflect.Value, node *parser.Node, env *Env) (int, error) {
+	a, err := EvalEnv(v, node.Children[0], env)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case node.Name == "And" && a == 0:
+		return 0, nil
+	case node.Name == "Or" && a != 0:
+		return 1, nil
+	}
+	if b, err := EvalEnv(v, node.Children[1], env); err != nil {
+		return 0, err
+	} else if b != 0 {
+		return 1, nil
+	}
+	return 0, nil
+}
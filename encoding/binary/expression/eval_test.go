@@ -5,6 +5,7 @@
 package expression
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
 )
@@ -36,6 +37,15 @@ func TestEval(t *testing.T) {
 		{"Length >= 3", 1},
 		{"Sub.Something", 10},
 		{"Sub.Something + Length", 13},
+		{"Length >= 2 && Length <= 4", 1},
+		{"Length >= 2 && Length <= 2", 0},
+		{"Length == 1 || Length == 3", 1},
+		{"Length == 1 || Length == 2", 0},
+		{"Length == 3 && Sub.Something == 1 || Length == 3", 1},
+		{"Length == 1 || Length == 3 && Sub.Something == 10", 1},
+		{"Length == 1 || Length == 3 && Sub.Something == 1", 0},
+		{"(Length == 1 || Length == 3) && Sub.Something == 10", 1},
+		{"Length && Sub.Something", 1},
 	}
 
 	for i, test := range tests {
@@ -52,3 +62,150 @@ func TestEval(t *testing.T) {
 		}
 	}
 }
+
+func TestEvalEmbedded(t *testing.T) {
+	type (
+		Header struct {
+			Size int
+		}
+		Other struct {
+			Flags int
+		}
+	)
+	var str = reflect.ValueOf(struct {
+		Header
+		*Other
+		Count int
+	}{Header{4}, nil, 2})
+	for _, test := range []struct {
+		in  string
+		out int
+		err bool
+	}{
+		{"Size", 4, false},
+		{"Size * Count", 8, false},
+		{"Header.Size", 4, false},
+		{"Flags", 0, true},
+	} {
+		var p EXPRESSION
+		if !p.Parse(test.in) {
+			t.Fatal(p.Error())
+		}
+		if r, err := Eval(&str, p.RootNode()); (err != nil) != test.err {
+			t.Errorf("%s: Error expectation mismatch, expected an error: %v, got %v", test.in, test.err, err)
+		} else if r != test.out {
+			t.Errorf("%s: Expected %d, but got %d", test.in, test.out, r)
+		}
+	}
+}
+
+func TestEvalFirstLast(t *testing.T) {
+	type record struct {
+		Offset int
+	}
+	var str = reflect.ValueOf(struct {
+		Records []record
+		Sizes   [3]uint8
+		Ptrs    []*record
+		Empty   []record
+		Count   int
+	}{[]record{{4}, {16}, {32}}, [3]uint8{1, 2, 3}, []*record{{8}}, nil, 2})
+	for _, test := range []struct {
+		in  string
+		out int
+		err bool
+	}{
+		{"first(Records).Offset", 4, false},
+		{"last(Records).Offset", 32, false},
+		{"last(Records).Offset - first(Records).Offset", 28, false},
+		{"last(Sizes)", 3, false},
+		{"first(Sizes) + Count", 3, false},
+		{"last(Ptrs).Offset", 8, false},
+		{"last(Empty).Offset", 0, true},
+		{"last(Count)", 0, true},
+		{"last(Records).Size", 0, true},
+		{"Count == 0 && last(Empty).Offset > 0", 0, false},
+		{"Count > 0 || last(Empty).Offset > 0", 1, false},
+		{"Count == 0 || last(Empty).Offset > 0", 0, true},
+		{"Count > 0 && last(Records).Offset > 16", 1, false},
+	} {
+		var p EXPRESSION
+		if !p.Parse(test.in) {
+			t.Fatalf("%s: %s", test.in, p.Error())
+		}
+		if r, err := Eval(&str, p.RootNode()); (err != nil) != test.err {
+			t.Errorf("%s: Error expectation mismatch, expected an error: %v, got %v", test.in, test.err, err)
+		} else if r != test.out {
+			t.Errorf("%s: Expected %d, but got %d", test.in, test.out, r)
+		}
+	}
+}
+
+func TestEvalSizes(t *testing.T) {
+	var (
+		str    = reflect.ValueOf(struct{ Size int }{10})
+		sizeof = func(name string) (int, error) {
+			if name != "Header" {
+				return 0, fmt.Errorf("No field %s", name)
+			}
+			return 4, nil
+		}
+	)
+	for _, test := range []struct {
+		in  string
+		out int
+		err bool
+	}{
+		{"Size - sizeof(Header)", 6, false},
+		{"sizeof(Header) * 2", 8, false},
+		{"sizeof(Missing)", 0, true},
+	} {
+		var p EXPRESSION
+		if !p.Parse(test.in) {
+			t.Fatalf("%s: %s", test.in, p.Error())
+		}
+		if r, err := EvalSizes(&str, p.RootNode(), sizeof); (err != nil) != test.err {
+			t.Errorf("%s: Error expectation mismatch, expected an error: %v, got %v", test.in, test.err, err)
+		} else if r != test.out {
+			t.Errorf("%s: Expected %d, but got %d", test.in, test.out, r)
+		}
+		if _, err := Eval(&str, p.RootNode()); err == nil {
+			t.Errorf("%s: Expected an error without sizes", test.in)
+		}
+	}
+}
+
+func TestEvalVars(t *testing.T) {
+	RegisterConst("TestBlock", 512)
+	RegisterConst("Size", 1)
+	var (
+		str  = reflect.ValueOf(struct{ Size int }{10})
+		vars = map[string]int{"Count": 3, "TestBlock": 256}
+	)
+	for _, test := range []struct {
+		in  string
+		out int
+		err bool
+	}{
+		// Fields take precedence over constants
+		{"Size * Count", 30, false},
+		// Variables take precedence over constants
+		{"TestBlock", 256, false},
+		{"Missing", 0, true},
+	} {
+		var p EXPRESSION
+		if !p.Parse(test.in) {
+			t.Fatalf("%s: %s", test.in, p.Error())
+		}
+		if r, err := EvalVars(&str, p.RootNode(), vars); (err != nil) != test.err {
+			t.Errorf("%s: Error expectation mismatch, expected an error: %v, got %v", test.in, test.err, err)
+		} else if r != test.out {
+			t.Errorf("%s: Expected %d, but got %d", test.in, test.out, r)
+		}
+	}
+	var p EXPRESSION
+	p.Parse("TestBlock")
+	if r, err := Eval(&str, p.RootNode()); err != nil || r != 512 {
+		t.Errorf("Expected the registered constant 512, got %d: %v", r, err)
+	}
+}
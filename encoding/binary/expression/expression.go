@@ -2,7 +2,7 @@
 // Use of this source code is governed by a 2-clause
 // BSD-style license that can be found in the LICENSE file.
 
-//go:generate "pegparser" "-peg=expression.peg" "-notest" "-ignore=Spacing,Primary,Op,Expression,Grouping,BooleanOp" "-testfile=\"\"" "-outpath=." "-generator=go" "-header=// Copyright 2013 Fredrik Ehnbom\n// Use of this source code is governed by a 2-clause\n// BSD-style license that can be found in the LICENSE file.\n\n" "-gogenerate"
+//go:generate "pegparser" "-peg=expression.peg" "-notest" "-ignore=Spacing,Primary,Op,Expression,Grouping,BooleanOp,LogicalOp,LogicalTerm" "-testfile=\"\"" "-outpath=." "-generator=go" "-header=// Copyright 2013 Fredrik Ehnbom\n// Use of this source code is governed by a 2-clause\n// BSD-style license that can be found in the LICENSE file.\n\n" "-gogenerate"
 
 package expression
 
@@ -61,7 +61,7 @@ func (p *EXPRESSION) realParse() bool {
 	return p.Expression()
 }
 func (p *EXPRESSION) Expression() bool {
-	// Expression      <-      (Op / Grouping) EndOfFile
+	// Expression      <-      (LogicalOp / Op / Grouping) EndOfFile
 	accept := false
 	accept = true
 	start := p.ParserData.Pos()
@@ -69,10 +69,13 @@ func (p *EXPRESSION) Expression() bool {
 		save := p.ParserData.Pos()
 		{
 			save := p.ParserData.Pos()
-			accept = p.Op()
+			accept = p.LogicalOp()
 			if !accept {
-				accept = p.Grouping()
+				accept = p.Op()
 				if !accept {
+					accept = p.Grouping()
+					if !accept {
+					}
 				}
 			}
 			if !accept {
@@ -100,6 +103,186 @@ func (p *EXPRESSION) Expression() bool {
 	return accept
 }
 
+func (p *EXPRESSION) LogicalOp() bool {
+	// LogicalOp       <-      Or / And
+	accept := false
+	accept = true
+	start := p.ParserData.Pos()
+	{
+		save := p.ParserData.Pos()
+		accept = p.Or()
+		if !accept {
+			accept = p.And()
+			if !accept {
+			}
+		}
+		if !accept {
+			p.ParserData.Seek(save)
+		}
+	}
+	if accept && start != p.ParserData.Pos() {
+		if start < p.IgnoreRange.A || p.IgnoreRange.A == 0 {
+			p.IgnoreRange.A = start
+		}
+		p.IgnoreRange.B = p.ParserData.Pos()
+	}
+	return accept
+}
+
+func (p *EXPRESSION) Or() bool {
+	// Or              <-      (And / LogicalTerm) "||" (Or / And / LogicalTerm)
+	accept := false
+	accept = true
+	start := p.ParserData.Pos()
+	{
+		save := p.ParserData.Pos()
+		{
+			save := p.ParserData.Pos()
+			accept = p.And()
+			if !accept {
+				accept = p.LogicalTerm()
+				if !accept {
+				}
+			}
+			if !accept {
+				p.ParserData.Seek(save)
+			}
+		}
+		if accept {
+			{
+				accept = true
+				s := p.ParserData.Pos()
+				if p.ParserData.Read() != '|' || p.ParserData.Read() != '|' {
+					p.ParserData.Seek(s)
+					accept = false
+				}
+			}
+			if accept {
+				{
+					save := p.ParserData.Pos()
+					accept = p.Or()
+					if !accept {
+						accept = p.And()
+						if !accept {
+							accept = p.LogicalTerm()
+							if !accept {
+							}
+						}
+					}
+					if !accept {
+						p.ParserData.Seek(save)
+					}
+				}
+				if accept {
+				}
+			}
+		}
+		if !accept {
+			if p.LastError < p.ParserData.Pos() {
+				p.LastError = p.ParserData.Pos()
+			}
+			p.ParserData.Seek(save)
+		}
+	}
+	end := p.ParserData.Pos()
+	if accept {
+		node := p.Root.Cleanup(start, end)
+		node.Name = "Or"
+		node.P = p
+		node.Range = node.Range.Clip(p.IgnoreRange)
+		p.Root.Append(node)
+	} else {
+		p.Root.Discard(start)
+	}
+	if p.IgnoreRange.A >= end || p.IgnoreRange.B <= start {
+		p.IgnoreRange = text.Region{}
+	}
+	return accept
+}
+
+func (p *EXPRESSION) And() bool {
+	// And             <-      LogicalTerm "&&" (And / LogicalTerm)
+	accept := false
+	accept = true
+	start := p.ParserData.Pos()
+	{
+		save := p.ParserData.Pos()
+		accept = p.LogicalTerm()
+		if accept {
+			{
+				accept = true
+				s := p.ParserData.Pos()
+				if p.ParserData.Read() != '&' || p.ParserData.Read() != '&' {
+					p.ParserData.Seek(s)
+					accept = false
+				}
+			}
+			if accept {
+				{
+					save := p.ParserData.Pos()
+					accept = p.And()
+					if !accept {
+						accept = p.LogicalTerm()
+						if !accept {
+						}
+					}
+					if !accept {
+						p.ParserData.Seek(save)
+					}
+				}
+				if accept {
+				}
+			}
+		}
+		if !accept {
+			if p.LastError < p.ParserData.Pos() {
+				p.LastError = p.ParserData.Pos()
+			}
+			p.ParserData.Seek(save)
+		}
+	}
+	end := p.ParserData.Pos()
+	if accept {
+		node := p.Root.Cleanup(start, end)
+		node.Name = "And"
+		node.P = p
+		node.Range = node.Range.Clip(p.IgnoreRange)
+		p.Root.Append(node)
+	} else {
+		p.Root.Discard(start)
+	}
+	if p.IgnoreRange.A >= end || p.IgnoreRange.B <= start {
+		p.IgnoreRange = text.Region{}
+	}
+	return accept
+}
+
+func (p *EXPRESSION) LogicalTerm() bool {
+	// LogicalTerm     <-      Op / Grouping
+	accept := false
+	accept = true
+	start := p.ParserData.Pos()
+	{
+		save := p.ParserData.Pos()
+		accept = p.Op()
+		if !accept {
+			accept = p.Grouping()
+			if !accept {
+			}
+		}
+		if !accept {
+			p.ParserData.Seek(save)
+		}
+	}
+	if accept && start != p.ParserData.Pos() {
+		if start < p.IgnoreRange.A || p.IgnoreRange.A == 0 {
+			p.IgnoreRange.A = start
+		}
+		p.IgnoreRange.B = p.ParserData.Pos()
+	}
+	return accept
+}
+
 func (p *EXPRESSION) Op() bool {
 	// Op              <-      ShiftRight / ShiftLeft / AndNot / Mask / Add / Sub / Mul / BooleanOp
 	accept := false
@@ -769,7 +952,7 @@ func (p *EXPRESSION) Ge() bool {
 }
 
 func (p *EXPRESSION) Grouping() bool {
-	// Grouping        <-      Spacing? ('(' Op ')' / Constant / DotIdentifier) Spacing?
+	// Grouping        <-      Spacing? ('(' (LogicalOp / Op) ')' / Constant / Last / First / SizeOf / DotIdentifier) Spacing?
 	accept := false
 	accept = true
 	start := p.ParserData.Pos()
@@ -789,7 +972,18 @@ func (p *EXPRESSION) Grouping() bool {
 						accept = true
 					}
 					if accept {
-						accept = p.Op()
+						{
+							save := p.ParserData.Pos()
+							accept = p.LogicalOp()
+							if !accept {
+								accept = p.Op()
+								if !accept {
+								}
+							}
+							if !accept {
+								p.ParserData.Seek(save)
+							}
+						}
 						if accept {
 							if p.ParserData.Read() != ')' {
 								p.ParserData.UnRead()
@@ -811,8 +1005,17 @@ func (p *EXPRESSION) Grouping() bool {
 				if !accept {
 					accept = p.Constant()
 					if !accept {
-						accept = p.DotIdentifier()
+						accept = p.Last()
 						if !accept {
+							accept = p.First()
+							if !accept {
+								accept = p.SizeOf()
+								if !accept {
+									accept = p.DotIdentifier()
+									if !accept {
+									}
+								}
+							}
 						}
 					}
 				}
@@ -843,6 +1046,205 @@ func (p *EXPRESSION) Grouping() bool {
 	return accept
 }
 
+func (p *EXPRESSION) Last() bool {
+	// Last            <-      "last(" DotIdentifier ')' ('.' DotIdentifier)?
+	accept := false
+	accept = true
+	start := p.ParserData.Pos()
+	{
+		save := p.ParserData.Pos()
+		{
+			accept = true
+			s := p.ParserData.Pos()
+			if p.ParserData.Read() != 'l' || p.ParserData.Read() != 'a' || p.ParserData.Read() != 's' || p.ParserData.Read() != 't' || p.ParserData.Read() != '(' {
+				p.ParserData.Seek(s)
+				accept = false
+			}
+		}
+		if accept {
+			accept = p.DotIdentifier()
+			if accept {
+				if p.ParserData.Read() != ')' {
+					p.ParserData.UnRead()
+					accept = false
+				} else {
+					accept = true
+				}
+				if accept {
+					{
+						save := p.ParserData.Pos()
+						if p.ParserData.Read() != '.' {
+							p.ParserData.UnRead()
+							accept = false
+						} else {
+							accept = true
+						}
+						if accept {
+							accept = p.DotIdentifier()
+							if accept {
+							}
+						}
+						if !accept {
+							if p.LastError < p.ParserData.Pos() {
+								p.LastError = p.ParserData.Pos()
+							}
+							p.ParserData.Seek(save)
+						}
+					}
+					accept = true
+					if accept {
+					}
+				}
+			}
+		}
+		if !accept {
+			if p.LastError < p.ParserData.Pos() {
+				p.LastError = p.ParserData.Pos()
+			}
+			p.ParserData.Seek(save)
+		}
+	}
+	end := p.ParserData.Pos()
+	if accept {
+		node := p.Root.Cleanup(start, end)
+		node.Name = "Last"
+		node.P = p
+		node.Range = node.Range.Clip(p.IgnoreRange)
+		p.Root.Append(node)
+	} else {
+		p.Root.Discard(start)
+	}
+	if p.IgnoreRange.A >= end || p.IgnoreRange.B <= start {
+		p.IgnoreRange = text.Region{}
+	}
+	return accept
+}
+
+func (p *EXPRESSION) First() bool {
+	// First           <-      "first(" DotIdentifier ')' ('.' DotIdentifier)?
+	accept := false
+	accept = true
+	start := p.ParserData.Pos()
+	{
+		save := p.ParserData.Pos()
+		{
+			accept = true
+			s := p.ParserData.Pos()
+			if p.ParserData.Read() != 'f' || p.ParserData.Read() != 'i' || p.ParserData.Read() != 'r' || p.ParserData.Read() != 's' || p.ParserData.Read() != 't' || p.ParserData.Read() != '(' {
+				p.ParserData.Seek(s)
+				accept = false
+			}
+		}
+		if accept {
+			accept = p.DotIdentifier()
+			if accept {
+				if p.ParserData.Read() != ')' {
+					p.ParserData.UnRead()
+					accept = false
+				} else {
+					accept = true
+				}
+				if accept {
+					{
+						save := p.ParserData.Pos()
+						if p.ParserData.Read() != '.' {
+							p.ParserData.UnRead()
+							accept = false
+						} else {
+							accept = true
+						}
+						if accept {
+							accept = p.DotIdentifier()
+							if accept {
+							}
+						}
+						if !accept {
+							if p.LastError < p.ParserData.Pos() {
+								p.LastError = p.ParserData.Pos()
+							}
+							p.ParserData.Seek(save)
+						}
+					}
+					accept = true
+					if accept {
+					}
+				}
+			}
+		}
+		if !accept {
+			if p.LastError < p.ParserData.Pos() {
+				p.LastError = p.ParserData.Pos()
+			}
+			p.ParserData.Seek(save)
+		}
+	}
+	end := p.ParserData.Pos()
+	if accept {
+		node := p.Root.Cleanup(start, end)
+		node.Name = "First"
+		node.P = p
+		node.Range = node.Range.Clip(p.IgnoreRange)
+		p.Root.Append(node)
+	} else {
+		p.Root.Discard(start)
+	}
+	if p.IgnoreRange.A >= end || p.IgnoreRange.B <= start {
+		p.IgnoreRange = text.Region{}
+	}
+	return accept
+}
+
+func (p *EXPRESSION) SizeOf() bool {
+	// SizeOf          <-      "sizeof(" Identifier ')'
+	accept := false
+	accept = true
+	start := p.ParserData.Pos()
+	{
+		save := p.ParserData.Pos()
+		{
+			accept = true
+			s := p.ParserData.Pos()
+			if p.ParserData.Read() != 's' || p.ParserData.Read() != 'i' || p.ParserData.Read() != 'z' || p.ParserData.Read() != 'e' || p.ParserData.Read() != 'o' || p.ParserData.Read() != 'f' || p.ParserData.Read() != '(' {
+				p.ParserData.Seek(s)
+				accept = false
+			}
+		}
+		if accept {
+			accept = p.Identifier()
+			if accept {
+				if p.ParserData.Read() != ')' {
+					p.ParserData.UnRead()
+					accept = false
+				} else {
+					accept = true
+				}
+				if accept {
+				}
+			}
+		}
+		if !accept {
+			if p.LastError < p.ParserData.Pos() {
+				p.LastError = p.ParserData.Pos()
+			}
+			p.ParserData.Seek(save)
+		}
+	}
+	end := p.ParserData.Pos()
+	if accept {
+		node := p.Root.Cleanup(start, end)
+		node.Name = "SizeOf"
+		node.P = p
+		node.Range = node.Range.Clip(p.IgnoreRange)
+		p.Root.Append(node)
+	} else {
+		p.Root.Discard(start)
+	}
+	if p.IgnoreRange.A >= end || p.IgnoreRange.B <= start {
+		p.IgnoreRange = text.Region{}
+	}
+	return accept
+}
+
 func (p *EXPRESSION) DotIdentifier() bool {
 	// DotIdentifier   <-      Identifier ('.' Identifier)*
 	accept := false
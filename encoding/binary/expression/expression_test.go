@@ -34,6 +34,36 @@ func TestParser(t *testing.T) {
 		2-3: "Identifier" - Data: "B"
 		4-5: "Identifier" - Data: "C"
 	5-5: "EndOfFile" - Data: ""
+`},
+		{"A && B || C", `0-11: "EXPRESSION"
+	0-11: "Or"
+		0-6: "And"
+			0-1: "DotIdentifier"
+				0-1: "Identifier" - Data: "A"
+			5-6: "DotIdentifier"
+				5-6: "Identifier" - Data: "B"
+		10-11: "DotIdentifier"
+			10-11: "Identifier" - Data: "C"
+	11-11: "EndOfFile" - Data: ""
+`},
+		{"Size-sizeof(Fixed)", `0-18: "EXPRESSION"
+	0-18: "Sub"
+		0-4: "DotIdentifier"
+			0-4: "Identifier" - Data: "Size"
+		5-18: "SizeOf"
+			12-17: "Identifier" - Data: "Fixed"
+	18-18: "EndOfFile" - Data: ""
+`},
+		{"last(Records).Offset-Base", `0-25: "EXPRESSION"
+	0-25: "Sub"
+		0-20: "Last"
+			5-12: "DotIdentifier"
+				5-12: "Identifier" - Data: "Records"
+			14-20: "DotIdentifier"
+				14-20: "Identifier" - Data: "Offset"
+		21-25: "DotIdentifier"
+			21-25: "Identifier" - Data: "Base"
+	25-25: "EndOfFile" - Data: ""
 `},
 	}
 	var p EXPRESSION
@@ -0,0 +1,74 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	sb "encoding/binary"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// A string whose encoding depends on the field's tags.
+type taggedString string
+
+func (s *taggedString) ReadField(r *BinaryReader, tag reflect.StructTag) error {
+	var n int
+	switch l := tag.Get("length"); l {
+	case "":
+		// NUL terminated
+		var str string
+		if err := r.ReadInterface(&str); err != nil {
+			return err
+		}
+		*s = taggedString(str)
+		return nil
+	case "uint8":
+		b, err := r.Uint8()
+		if err != nil {
+			return err
+		}
+		n = int(b)
+	default:
+		var err error
+		if n, err = strconv.Atoi(l); err != nil {
+			return err
+		}
+	}
+	data, err := r.Read(n)
+	if err != nil {
+		return err
+	}
+	if tag.Get("case") == "upper" {
+		data = bytes.ToUpper(data)
+	}
+	*s = taggedString(data)
+	return nil
+}
+
+func TestBinaryReaderFieldReader(t *testing.T) {
+	type Record struct {
+		A taggedString `length:"uint8"`
+		B taggedString `length:"3" case:"upper" align:"4"`
+		C taggedString
+		D []taggedString `length:"2"`
+	}
+	data := []byte("\x02abxyz\x00nul\x00p\x00q\x00")
+	var (
+		rec Record
+		br  = BinaryReader{Reader: bytes.NewReader(data), Endianess: sb.LittleEndian}
+	)
+	if err := br.ReadInterface(&rec); err != nil {
+		t.Fatal(err)
+	}
+	exp := Record{"ab", "XYZ", "nul", []taggedString{"p", "q"}}
+	if !reflect.DeepEqual(rec, exp) {
+		t.Errorf("Expected %+v, got %+v", exp, rec)
+	}
+	if br.Tell() != int64(len(data)) {
+		t.Errorf("Expected to read all %d bytes, but read %d", len(data), br.Tell())
+	}
+}
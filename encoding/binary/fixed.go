@@ -0,0 +1,104 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// A parsed `fixed` tag, parsed once when the layout plan of the
+// struct is compiled.
+type fixedPoint struct {
+	intBits, fracBits int
+	signed            bool
+	err               error
+}
+
+// Parses a `fixed` tag of the form "I.F" or "I.F,signed", where
+// I and F are the number of integer and fraction bits. Returns nil
+// for an empty tag. A malformed tag is reported by the err field of
+// the returned value.
+func parseFixed(tag string) *fixedPoint {
+	if tag == "" {
+		return nil
+	}
+	fx := &fixedPoint{}
+	if i := strings.Index(tag, ","); i != -1 {
+		if opt := tag[i+1:]; opt != "signed" {
+			fx.err = fmt.Errorf("Unknown fixed point option: %s", opt)
+			return fx
+		}
+		fx.signed = true
+		tag = tag[:i]
+	}
+	parts := strings.Split(tag, ".")
+	if len(parts) != 2 {
+		fx.err = fmt.Errorf("Malformed fixed point tag: %s", tag)
+		return fx
+	}
+	if fx.intBits, fx.err = strconv.Atoi(parts[0]); fx.err != nil {
+		return fx
+	}
+	if fx.fracBits, fx.err = strconv.Atoi(parts[1]); fx.err != nil {
+		return fx
+	}
+	switch fx.bits() {
+	case 8, 16, 32, 64:
+	default:
+		fx.err = fmt.Errorf("Fixed point values must be 8, 16, 32 or 64 bits wide, not %d", fx.bits())
+	}
+	return fx
+}
+
+// Returns the width of the fixed point values in bits.
+func (fx *fixedPoint) bits() int {
+	return fx.intBits + fx.fracBits
+}
+
+// Reads a fixed point value as described by fx into the float
+// field f, returning the number of bytes read.
+func (r *BinaryReader) readFixed(f reflect.Value, fx *fixedPoint) (int, error) {
+	if fx.err != nil {
+		return 0, fx.err
+	}
+	var (
+		bits = fx.bits()
+		raw  uint64
+		err  error
+	)
+	switch bits {
+	case 8:
+		var v uint8
+		v, err = r.Uint8()
+		raw = uint64(v)
+	case 16:
+		var v uint16
+		v, err = r.Uint16()
+		raw = uint64(v)
+	case 32:
+		var v uint32
+		v, err = r.Uint32()
+		raw = uint64(v)
+	case 64:
+		raw, err = r.Uint64()
+	}
+	if err != nil {
+		return 0, err
+	}
+	var value float64
+	if fx.signed {
+		// Sign extend by shifting the value up to the top of an int64
+		shift := uint(64 - bits)
+		value = float64(int64(raw<<shift) >> shift)
+	} else {
+		value = float64(raw)
+	}
+	f.SetFloat(value / math.Exp2(float64(fx.fracBits)))
+	return bits / 8, nil
+}
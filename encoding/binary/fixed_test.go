@@ -0,0 +1,64 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestBinaryReaderFixed(t *testing.T) {
+	type Test struct {
+		A float64 `fixed:"16.16"`
+		B float32 `fixed:"8.8,signed"`
+		C float32 `fixed:"2.14,signed"`
+		D float64 `fixed:"4.4"`
+	}
+	var (
+		t2   Test
+		data = []byte{
+			0x00, 0x80, 0x01, 0x00, // 1.5
+			0x80, 0xfe, // -1.5
+			0x00, 0xc0, // -1.0
+			0x18, // 1.5
+		}
+		exp = Test{1.5, -1.5, -1.0, 1.5}
+	)
+	br := BinaryReader{Reader: bytes.NewReader(data), Endianess: LittleEndian}
+	if err := br.ReadInterface(&t2); err != nil {
+		t.Fatal(err)
+	}
+	if t2 != exp {
+		t.Errorf("Expected %v, but got %v", exp, t2)
+	}
+}
+
+func TestBinaryReaderFixedInvalid(t *testing.T) {
+	tests := []interface{}{
+		&struct {
+			A float32 `fixed:"16.8"`
+		}{},
+		&struct {
+			A float32 `fixed:"16.16,unsigned"`
+		}{},
+		&struct {
+			A int32 `fixed:"16.16"`
+		}{},
+	}
+	for i, test := range tests {
+		br := BinaryReader{Reader: bytes.NewReader(make([]byte, 8)), Endianess: LittleEndian}
+		if err := br.ReadInterface(test); err == nil {
+			t.Errorf("%d: Expected an error, but didn't get one", i)
+		}
+		// Malformed tags are reported without reading anything
+		if err := Precompile(reflect.TypeOf(test)); err == nil {
+			t.Errorf("%d: Expected Precompile to fail, but it didn't", i)
+		}
+		if err := CheckType(reflect.TypeOf(test)); err == nil {
+			t.Errorf("%d: Expected CheckType to fail, but it didn't", i)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"fmt"
+	"github.com/quarnster/util/encoding/binary/expression"
+	"github.com/quarnster/util/mathutil"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Computes the positions and sizes of the fields of a value as the
+// BinaryReader would read them.
+type layouter struct {
+	// The current position, and the largest position reached
+	pos, end int64
+	path     []string
+	report   SizeReport
+	record   bool
+}
+
+// Returns the number of bytes the value v, or the value v points to,
+// takes up when encoded the way the BinaryReader reads it. Tags are
+// evaluated with the actual values in v, so that for example a field
+// whose `if` tag evaluates to zero doesn't count, and `length` and
+// `align` tags give the same sizes and padding as when reading.
+//
+// This is useful for allocating buffers of the right size before
+// serializing v, or for computing offset tables up front.
+//
+// Fields with an `offset` tag are placed where their offset says,
+// and the size returned covers them too. Single bit fields
+// and types implementing Reader other than Uint128 aren't supported,
+// as their sizes can't be known without reading them.
+func SizeOf(v interface{}) (int, error) {
+	var l layouter
+	if err := l.layout(v); err != nil {
+		return 0, err
+	}
+	return int(l.end), nil
+}
+
+// Returns the offsets and sizes of all the fields of the struct v,
+// or the struct v points to, when encoded. The report is laid out as
+// that of ReadSizeReport, and is computed the same way as SizeOf.
+func Layout(v interface{}) (SizeReport, error) {
+	l := layouter{record: true}
+	if err := l.layout(v); err != nil {
+		return nil, err
+	}
+	return l.report, nil
+}
+
+func (l *layouter) layout(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return fmt.Errorf("Can't compute the size of a nil %s", rv.Type())
+		}
+		rv = rv.Elem()
+	}
+	return l.value(rv)
+}
+
+func (l *layouter) advance(n int64) {
+	l.pos += n
+	if l.pos > l.end {
+		l.end = l.pos
+	}
+}
+
+func (l *layouter) pathString() string {
+	var b strings.Builder
+	for i, p := range l.path {
+		if i > 0 && !strings.HasPrefix(p, "[") {
+			b.WriteByte('.')
+		}
+		b.WriteString(p)
+	}
+	return b.String()
+}
+
+// Advances past the value v read without any tags.
+func (l *layouter) value(v reflect.Value) error {
+	t := v.Type()
+	if t == uint128Type {
+		l.advance(16)
+		return nil
+	} else if readsItself(t) {
+		return fmt.Errorf("Can't compute the size of %s, as it reads itself", t)
+	}
+	switch v.Kind() {
+	case reflect.Bool, reflect.Uint8, reflect.Int8:
+		l.advance(1)
+	case reflect.Uint16, reflect.Int16:
+		l.advance(2)
+	case reflect.Uint32, reflect.Int32, reflect.Float32:
+		l.advance(4)
+	case reflect.Uint, reflect.Int, reflect.Uint64, reflect.Int64, reflect.Float64:
+		l.advance(8)
+	case reflect.Array, reflect.Slice:
+		base := len(l.path)
+		for i := 0; i < v.Len(); i++ {
+			if l.record {
+				l.path = append(l.path[:base], "["+strconv.Itoa(i)+"]")
+			}
+			if err := l.value(v.Index(i)); err != nil {
+				return err
+			}
+		}
+		l.path = l.path[:base]
+	case reflect.String:
+		// Read up until and including the terminating NUL
+		l.advance(int64(v.Len() + 1))
+	case reflect.Struct:
+		return l.structValue(v)
+	default:
+		return fmt.Errorf("Don't know how to compute the size of type %s", v.Kind())
+	}
+	return nil
+}
+
+// Advances past the nullable value in f, returning its size in
+// the way readNullable does.
+func (l *layouter) nullable(f reflect.Value) (int, error) {
+	var val reflect.Value
+	switch {
+	case f.Kind() == reflect.Ptr && f.IsNil():
+		val = reflect.Zero(f.Type().Elem())
+	case f.Kind() == reflect.Ptr:
+		val = f.Elem()
+	case isNullStruct(f.Type()):
+		val = f.Field(0)
+	default:
+		return 0, fmt.Errorf("Can't apply null tag to a field of type %s", f.Type())
+	}
+	return int(val.Type().Size()), l.value(val)
+}
+
+// Advances past the fields of the struct v, mirroring the struct
+// case of BinaryReader.ReadInterface.
+func (l *layouter) structValue(v2 reflect.Value) error {
+	var (
+		plan        = getPlan(v2.Type())
+		structStart = l.pos
+		positions   []int64
+		sizes       []int
+		base        = len(l.path)
+	)
+	defer func() { l.path = l.path[:base] }()
+	if plan.hasOffset {
+		positions = newPositions(len(plan.fields))
+	}
+	if plan.hasSizeOf {
+		sizes = make([]int, len(plan.fields))
+		for i := range sizes {
+			sizes[i] = -1
+		}
+	}
+	var env *expression.Env
+	if sizes != nil {
+		env = &expression.Env{SizeOf: plan.sizeFunc(sizes)}
+	}
+	for i := range plan.fields {
+		var (
+			fp       = &plan.fields[i]
+			f        = fp.get(v2)
+			size     = -1
+			err      error
+			returnTo int64 = -1
+		)
+		if fp.cond != nil {
+			if ev, err := fp.cond.eval(&v2, env); err != nil {
+				return err
+			} else if ev == 0 {
+				if sizes != nil {
+					sizes[i] = 0
+				}
+				continue
+			}
+		}
+		if fp.skip != nil {
+			if ev, err := fp.skip.eval(&v2, env); err != nil {
+				return err
+			} else {
+				l.advance(int64(ev))
+			}
+		}
+		if fp.offset != nil {
+			if off, err := fieldOffset(&v2, plan, fp, structStart, positions, env); err != nil {
+				return err
+			} else {
+				returnTo, l.pos = l.pos, off
+			}
+		}
+		if fp.bits != nil && !fp.packed {
+			return fmt.Errorf("Field %s: can't compute the size of bit fields", fp.name)
+		}
+		start := l.pos
+		if positions != nil {
+			positions[i] = start
+		}
+		l.path = append(l.path[:base], fp.name)
+		var index int
+		if l.record {
+			// Struct fields are reported before the fields they contain
+			index = len(l.report)
+			l.report = append(l.report, FieldSize{Path: l.pathString(), Offset: start})
+		}
+
+		if fp.fieldReader {
+			return fmt.Errorf("Field %s: can't compute the size of a field that reads itself", fp.name)
+		}
+		var lengths []int
+		switch ln := fp.length; {
+		case strings.Contains(ln, ","):
+			if lengths, err = evalLengths(&v2, ln, env); err != nil {
+				return err
+			}
+			size = lengths[0]
+		case ln == "uint8" || ln == "uint16" || ln == "uint32" || ln == "uint64":
+			if fp.bounded {
+				return fmt.Errorf("Field %s: can't compute the size of a field that reads itself", fp.name)
+			}
+			bits, _ := strconv.Atoi(ln[4:])
+			l.advance(int64(bits / 8))
+			size = f.Len()
+		case ln != "":
+			if size, err = evalExpr(&v2, ln, env); err != nil {
+				return err
+			}
+		}
+
+		switch kind := fp.kind; {
+		case fp.transform != "":
+			if size < 0 {
+				return fmt.Errorf("Transformed fields require a known length")
+			}
+			l.advance(int64(size))
+		case fp.uuid != "":
+			size = 16
+			l.advance(16)
+		case fp.size != nil:
+			if size, err = fp.size.eval(&v2, env); err != nil {
+				return err
+			}
+			l.advance(int64(size))
+		case fp.bounded:
+			l.advance(int64(size))
+		case fp.packed:
+			bits, err := fp.bits.eval(&v2, env)
+			if err != nil {
+				return err
+			}
+			if fp.kind == reflect.Array {
+				size = f.Len()
+			} else if f.Len() != size {
+				return fmt.Errorf("Field %s: has %d elements, but its length is %d", fp.name, f.Len(), size)
+			}
+			size = packedSize(size, bits)
+			l.advance(int64(size))
+		case kind == reflect.String:
+			if size < 0 {
+				size = f.Len() + 1
+			}
+			l.advance(int64(size))
+		case kind == reflect.Slice:
+			if size == -1 {
+				return fmt.Errorf("Field %s: slices require a known length", fp.name)
+			} else if len(lengths) <= 1 && f.Len() != size {
+				return fmt.Errorf("Field %s: has %d elements, but its length is %d", fp.name, f.Len(), size)
+			}
+			if err := l.value(f); err != nil {
+				return err
+			}
+		case kind == reflect.Interface:
+			if f.IsNil() {
+				return fmt.Errorf("Field %s: can't compute the size of a nil interface", fp.name)
+			}
+			e := f.Elem()
+			if e.Kind() == reflect.Ptr {
+				e = e.Elem()
+			}
+			size = int(e.Type().Size())
+			if err := l.value(e); err != nil {
+				return err
+			}
+		case kind == reflect.Ptr:
+			if size, err = l.nullable(f); err != nil {
+				return err
+			}
+		case fp.null != "":
+			if size, err = l.nullable(f); err != nil {
+				return err
+			}
+		case fp.fixed != nil:
+			if fp.fixed.err != nil {
+				return fp.fixed.err
+			}
+			size = fp.fixed.bits() / 8
+			l.advance(int64(size))
+		default:
+			size = int(f.Type().Size())
+			if err := l.value(f); err != nil {
+				return err
+			}
+		}
+		if sizes != nil {
+			sizes[i] = int(l.pos - start)
+		}
+		if l.record {
+			l.report[index].Size = l.pos - start
+		}
+
+		if fp.align != nil {
+			var align int
+			if align, err = fp.align.eval(&v2, env); err != nil {
+				return err
+			}
+			if align < size {
+				l.advance(int64(mathutil.AlignUp(size, align) - size))
+			} else if align > size {
+				l.advance(int64(align - size))
+			}
+		}
+		if returnTo >= 0 {
+			l.pos = returnTo
+		}
+	}
+	return nil
+}
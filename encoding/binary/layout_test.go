@@ -0,0 +1,80 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	sb "encoding/binary"
+	"testing"
+)
+
+func TestSizeOf(t *testing.T) {
+	type (
+		Entry struct {
+			Kind  uint8
+			Value uint32 `if:"Kind != 0"`
+		}
+		File struct {
+			Magic   [4]byte
+			Count   uint16
+			Name    string  `align:"4"`
+			Entries []Entry `length:"Count"`
+			Comment string  `length:"uint8"`
+			Extra   []byte  `length:"Count - sizeof(Comment)"`
+			ID      UUID    `uuid:"rfc4122"`
+			Big     Uint128
+			Ratio   float32 `fixed:"8.8"`
+		}
+	)
+	var data bytes.Buffer
+	data.WriteString("FILE\x05\x00ab\x00\x00")
+	data.Write([]byte{0, 1, 1, 0, 0, 0, 0, 0, 2, 3, 4, 5, 6})
+	data.Write([]byte{3, 'a', 'b', 'c', 1})
+	data.Write(make([]byte, 16+16))
+	data.Write([]byte{0x80, 0x01})
+
+	var (
+		f  File
+		br = BinaryReader{Reader: bytes.NewReader(data.Bytes()), Endianess: sb.LittleEndian}
+	)
+	exp, err := ReadSizeReport(&br, &f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := SizeOf(&f); err != nil {
+		t.Fatal(err)
+	} else if n != data.Len() {
+		t.Errorf("Expected a size of %d, got %d", data.Len(), n)
+	}
+	if got, err := Layout(f); err != nil {
+		t.Fatal(err)
+	} else if got.String() != exp.String() {
+		t.Errorf("Expected:\n%s\nGot:\n%s", exp, got)
+	}
+
+	// Inconsistent values are reported
+	f.Entries = f.Entries[:2]
+	if _, err := SizeOf(&f); err == nil {
+		t.Error("Expected an error for a slice not matching its length")
+	}
+}
+
+func TestSizeOfOffset(t *testing.T) {
+	type Header struct {
+		Offset uint16
+		Data   uint32 `offset:"Offset" relative:"start"`
+		Tail   uint8
+	}
+	if n, err := SizeOf(Header{Offset: 8}); err != nil {
+		t.Fatal(err)
+	} else if n != 12 {
+		t.Errorf("Expected the size to cover the data at the offset, got %d", n)
+	}
+	if n, err := SizeOf(Header{Offset: 0}); err != nil {
+		t.Fatal(err)
+	} else if n != 4 {
+		t.Errorf("Expected a size of 4, got %d", n)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"fmt"
+	"github.com/quarnster/util/encoding/binary/expression"
+	"reflect"
+	"strings"
+)
+
+// Evaluates a comma separated list of length expressions, as used
+// for multi-dimensional slices such as:
+//
+//	Tiles [][]uint8 `length:"Height,Width"`
+//
+// The first length is that of the outermost slice.
+func evalLengths(v *reflect.Value, tag string, env *expression.Env) ([]int, error) {
+	var lengths []int
+	for _, l := range strings.Split(tag, ",") {
+		if ev, err := evalExpr(v, strings.TrimSpace(l), env); err != nil {
+			return nil, err
+		} else if ev < 0 {
+			return nil, fmt.Errorf("Negative length %d from expression %s", ev, l)
+		} else {
+			lengths = append(lengths, ev)
+		}
+	}
+	return lengths, nil
+}
+
+// Reads a nested slice with one length per dimension.
+func (r *BinaryReader) readSlices(f reflect.Value, lengths []int) error {
+	if f.Kind() != reflect.Slice {
+		return fmt.Errorf("Expected a slice for each of the %d lengths, not %s", len(lengths), f.Type())
+	}
+	v := reflect.MakeSlice(f.Type(), lengths[0], lengths[0])
+	for i := 0; i < lengths[0]; i++ {
+		var err error
+		if len(lengths) > 1 {
+			err = r.readSlices(v.Index(i), lengths[1:])
+		} else {
+			err = r.ReadInterface(v.Index(i).Addr().Interface())
+		}
+		if err != nil {
+			return err
+		}
+	}
+	f.Set(v)
+	return nil
+}
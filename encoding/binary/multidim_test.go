@@ -0,0 +1,50 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestBinaryReaderMultiDimensional(t *testing.T) {
+	type Test struct {
+		Height, Width uint8
+		Tiles         [][]uint16  `length:"Height, Width"`
+		Planes        [][][]uint8 `length:"2,Height,1"`
+	}
+	var (
+		t2   Test
+		data = []byte{
+			2, 3,
+			1, 0, 2, 0, 3, 0,
+			4, 0, 5, 0, 6, 0,
+			1, 2, 3, 4,
+		}
+		exp = Test{2, 3,
+			[][]uint16{{1, 2, 3}, {4, 5, 6}},
+			[][][]uint8{{{1}, {2}}, {{3}, {4}}},
+		}
+	)
+	br := BinaryReader{Reader: bytes.NewReader(data), Endianess: LittleEndian}
+	if err := br.ReadInterface(&t2); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(t2, exp) {
+		t.Errorf("Expected %v, but got %v", exp, t2)
+	}
+}
+
+func TestBinaryReaderMultiDimensionalMismatch(t *testing.T) {
+	type Test struct {
+		Data []uint8 `length:"2,2"`
+	}
+	var t2 Test
+	br := BinaryReader{Reader: bytes.NewReader(make([]byte, 4)), Endianess: LittleEndian}
+	if err := br.ReadInterface(&t2); err == nil {
+		t.Error("Expected an error, but didn't get one")
+	}
+}
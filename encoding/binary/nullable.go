@@ -0,0 +1,108 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Parses a `null` tag into the bit pattern of the sentinel value.
+// Negative values are stored in two's complement form.
+func parseSentinel(tag string) (uint64, error) {
+	if strings.HasPrefix(tag, "-") {
+		i, err := strconv.ParseInt(tag, 0, 64)
+		return uint64(i), err
+	}
+	return strconv.ParseUint(tag, 0, 64)
+}
+
+// Checks whether the value read equals the sentinel, comparing
+// only as many bits as the value's type is wide.
+func isSentinel(v reflect.Value, sentinel uint64) (bool, error) {
+	var (
+		bits uint64
+		mask = uint64(math.MaxUint64)
+	)
+	if s := v.Type().Size() * 8; s < 64 {
+		mask = (1 << s) - 1
+	}
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		bits = v.Uint()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		bits = uint64(v.Int())
+	case reflect.Float32:
+		bits = uint64(math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		bits = math.Float64bits(v.Float())
+	case reflect.Bool:
+		if v.Bool() {
+			bits = 1
+		}
+	default:
+		return false, fmt.Errorf("Can't compare a %s against a null sentinel", v.Kind())
+	}
+	return bits&mask == sentinel&mask, nil
+}
+
+// Checks whether t looks like one of the sql.Null* types, i.e. a
+// struct with a value field followed by a Valid bool field.
+func isNullStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t.NumField() == 2 &&
+		t.Field(1).Name == "Valid" && t.Field(1).Type.Kind() == reflect.Bool
+}
+
+// Reads a nullable field, which is either a pointer or a sql.Null*
+// style struct. If tag is non-empty and the value read equals the
+// sentinel it describes, pointers are set to nil and structs have
+// their Valid field set to false.
+//
+// Returns the number of bytes read, for the purposes of alignment.
+func (r *BinaryReader) readNullable(f reflect.Value, tag string) (int, error) {
+	var (
+		val      reflect.Value
+		null     bool
+		sentinel uint64
+		err      error
+	)
+	if tag != "" {
+		if sentinel, err = parseSentinel(tag); err != nil {
+			return 0, err
+		}
+	}
+	switch {
+	case f.Kind() == reflect.Ptr:
+		val = reflect.New(f.Type().Elem()).Elem()
+	case isNullStruct(f.Type()):
+		val = f.Field(0)
+	default:
+		return 0, fmt.Errorf("Can't apply null tag to a field of type %s", f.Type())
+	}
+	if err := r.ReadInterface(val.Addr().Interface()); err != nil {
+		return 0, err
+	}
+	if tag != "" {
+		if null, err = isSentinel(val, sentinel); err != nil {
+			return 0, err
+		}
+	}
+	if f.Kind() == reflect.Ptr {
+		if null {
+			f.Set(reflect.Zero(f.Type()))
+		} else {
+			f.Set(val.Addr())
+		}
+	} else {
+		if null {
+			val.Set(reflect.Zero(val.Type()))
+		}
+		f.Field(1).SetBool(!null)
+	}
+	return int(val.Type().Size()), nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	"database/sql"
+	"testing"
+)
+
+func TestBinaryReaderNullPointer(t *testing.T) {
+	type Test struct {
+		A *uint32 `null:"0xFFFFFFFF"`
+		B *int16  `null:"-1"`
+		C *uint8
+	}
+	var t2 Test
+	data := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	br := BinaryReader{Reader: bytes.NewReader(data), Endianess: LittleEndian}
+	if err := br.ReadInterface(&t2); err != nil {
+		t.Fatal(err)
+	}
+	if t2.A != nil || t2.B != nil {
+		t.Errorf("Expected nil values, but got %v, %v", t2.A, t2.B)
+	}
+	if t2.C == nil || *t2.C != 0xff {
+		t.Errorf("Expected 0xff, but got %v", t2.C)
+	}
+
+	data = []byte{1, 0, 0, 0, 2, 0, 3}
+	br = BinaryReader{Reader: bytes.NewReader(data), Endianess: LittleEndian}
+	if err := br.ReadInterface(&t2); err != nil {
+		t.Fatal(err)
+	}
+	if t2.A == nil || *t2.A != 1 || t2.B == nil || *t2.B != 2 || t2.C == nil || *t2.C != 3 {
+		t.Errorf("Unexpected values: %v, %v, %v", t2.A, t2.B, t2.C)
+	}
+}
+
+func TestBinaryReaderNullStruct(t *testing.T) {
+	type Test struct {
+		A sql.NullInt64 `null:"0"`
+		B sql.NullInt64 `null:"0"`
+	}
+	var t2 Test
+	data := []byte{0, 0, 0, 0, 0, 0, 0, 0, 5, 0, 0, 0, 0, 0, 0, 0}
+	br := BinaryReader{Reader: bytes.NewReader(data), Endianess: LittleEndian}
+	if err := br.ReadInterface(&t2); err != nil {
+		t.Fatal(err)
+	}
+	if t2.A.Valid {
+		t.Errorf("Expected an invalid value, but got %v", t2.A)
+	}
+	if !t2.B.Valid || t2.B.Int64 != 5 {
+		t.Errorf("Expected a valid 5, but got %v", t2.B)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"fmt"
+	"github.com/quarnster/util/encoding/binary/expression"
+	"reflect"
+)
+
+// Decodes a zigzag encoded integer, in which the sign is stored
+// in the lowest bit so that small negative numbers stay small.
+func unzigzag(u uint64) int64 {
+	return int64(u>>1) ^ -int64(u&1)
+}
+
+// Turns the raw values just read into the field f into their logical
+// values, as described by the field's number transform tags:
+//
+//	zigzag:"true"
+//		The signed integer field, or slice of signed integers, is
+//		zigzag encoded, as is common for varints.
+//	delta:"Base"
+//		The integer field holds the difference to the value of the
+//		expression, typically naming a previously read field. For
+//		slices of integers, every element holds the difference to
+//		the element before it, and the first element the difference
+//		to the value of the expression. Use delta:"0" for slices
+//		without a base value.
+//
+// Zigzag decoding happens before delta decoding, so that the two can
+// be combined for sequences that may decrease.
+func decodeNumbers(v *reflect.Value, f reflect.Value, fp *fieldPlan, env *expression.Env) error {
+	if f.Kind() == reflect.Slice || f.Kind() == reflect.Array {
+		if !isInteger(f.Type().Elem().Kind()) {
+			return fmt.Errorf("Field %s: number transforms apply to integers, not %s", fp.name, f.Type())
+		}
+	} else if !isInteger(f.Kind()) {
+		return fmt.Errorf("Field %s: number transforms apply to integers, not %s", fp.name, f.Type())
+	}
+	elems := []reflect.Value{f}
+	if f.Kind() == reflect.Slice || f.Kind() == reflect.Array {
+		elems = make([]reflect.Value, f.Len())
+		for i := range elems {
+			elems[i] = f.Index(i)
+		}
+	}
+	if fp.zigzag {
+		for _, e := range elems {
+			switch e.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				bits := uint(e.Type().Size() * 8)
+				e.SetInt(unzigzag(uint64(e.Int()) << (64 - bits) >> (64 - bits)))
+			default:
+				return fmt.Errorf("Field %s: zigzag decoding requires signed integers, not %s", fp.name, e.Type())
+			}
+		}
+	}
+	if fp.delta != nil {
+		prev, err := fp.delta.eval(v, env)
+		if err != nil {
+			return err
+		}
+		acc := int64(prev)
+		for _, e := range elems {
+			switch e.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				acc += e.Int()
+				e.SetInt(acc)
+			default:
+				acc += int64(e.Uint())
+				e.SetUint(uint64(acc))
+			}
+		}
+	}
+	return nil
+}
+
+func isInteger(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
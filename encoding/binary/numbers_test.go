@@ -0,0 +1,60 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	sb "encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func TestUnzigzag(t *testing.T) {
+	for in, exp := range map[uint64]int64{0: 0, 1: -1, 2: 1, 3: -2, 0xfffffffe: 0x7fffffff, 0xffffffff: -0x80000000} {
+		if got := unzigzag(in); got != exp {
+			t.Errorf("unzigzag(%d): Expected %d, got %d", in, exp, got)
+		}
+	}
+}
+
+func TestBinaryReaderNumberTransforms(t *testing.T) {
+	type Series struct {
+		Base    uint32
+		Next    uint32 `delta:"Base"`
+		Small   int8   `zigzag:"true"`
+		Count   uint8
+		Times   []uint16 `length:"Count" delta:"Next"`
+		Changes []int16  `length:"Count" zigzag:"true" delta:"0"`
+		Bits    int8     `bits:"4" zigzag:"true"`
+	}
+	data := []byte{
+		100, 0, 0, 0,
+		5, 0, 0, 0,
+		0xff,
+		3,
+		1, 0, 2, 0, 3, 0,
+		4, 0, 3, 0, 1, 0,
+		0x30,
+	}
+	var (
+		s  Series
+		br = BinaryReader{Reader: bytes.NewReader(data), Endianess: sb.LittleEndian}
+	)
+	if err := br.ReadInterface(&s); err != nil {
+		t.Fatal(err)
+	}
+	exp := Series{100, 105, -128, 3, []uint16{106, 108, 111}, []int16{2, 0, -1}, -2}
+	if !reflect.DeepEqual(s, exp) {
+		t.Errorf("Expected %+v, got %+v", exp, s)
+	}
+
+	type Bad struct {
+		F float32 `zigzag:"true"`
+	}
+	br = BinaryReader{Reader: bytes.NewReader(data), Endianess: sb.LittleEndian}
+	if err := br.ReadInterface(&Bad{}); err == nil {
+		t.Error("Expected an error for a zigzag float")
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"fmt"
+	"github.com/quarnster/util/encoding/binary/expression"
+	"reflect"
+)
+
+// Returns the positions of the fields of a struct with n fields, as
+// used by fieldOffset, with all of the fields marked as not yet read.
+func newPositions(n int) []int64 {
+	positions := make([]int64, n)
+	for i := range positions {
+		positions[i] = -1
+	}
+	return positions
+}
+
+// Evaluates the `offset` tag expression of the field fp,
+// returning the absolute stream position the field is to be read from.
+//
+// By default the offset is absolute, but the `relative` tag can be
+// used to make it relative to either the start of the struct being
+// read, with relative:"start", or to the position of a previously
+// read field in the same struct, by giving the name of that field.
+// It's an error for that field to have been skipped by its `if` tag.
+//
+// Note that when reading a Chunk's payload via its Reader, positions
+// are already relative to the start of the chunk.
+func fieldOffset(v *reflect.Value, plan *structPlan, fp *fieldPlan, structStart int64, positions []int64, env *expression.Env) (int64, error) {
+	var off int64
+	if ev, err := fp.offset.eval(v, env); err != nil {
+		return 0, err
+	} else {
+		off = int64(ev)
+	}
+	switch rel := fp.relative; rel {
+	case "":
+	case "start":
+		off += structStart
+	default:
+		if rf := plan.lookup(rel); rf == nil {
+			return 0, fmt.Errorf("Field %s: no field by name %s to be relative to", fp.name, rel)
+		} else if rf.index >= fp.index {
+			return 0, fmt.Errorf("Field %s: can only be relative to fields before it, not %s", fp.name, rel)
+		} else if positions[rf.index] < 0 {
+			return 0, fmt.Errorf("Field %s: relative field %s was not read", fp.name, rel)
+		} else {
+			off += positions[rf.index]
+		}
+	}
+	return off, nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBinaryReaderOffset(t *testing.T) {
+	type Test struct {
+		Off  uint8
+		Data uint16 `offset:"Off"`
+		Next uint8
+	}
+	var (
+		t2   Test
+		data = []byte{4, 9, 0, 0, 0x37, 0x13}
+	)
+	br := BinaryReader{Reader: bytes.NewReader(data), Endianess: LittleEndian}
+	if err := br.ReadInterface(&t2); err != nil {
+		t.Fatal(err)
+	}
+	if exp := (Test{4, 0x1337, 9}); t2 != exp {
+		t.Errorf("Expected %v, but got %v", exp, t2)
+	}
+}
+
+func TestBinaryReaderOffsetRelative(t *testing.T) {
+	type Inner struct {
+		Off   uint8
+		Table uint8
+		A     uint8 `offset:"Off" relative:"start"`
+		B     uint8 `offset:"Off" relative:"Table"`
+	}
+	type Test struct {
+		Pad   [2]uint8
+		Inner Inner
+	}
+	var (
+		t2   Test
+		data = []byte{0, 0, 3, 0xff, 0, 1, 2, 3, 4}
+	)
+	br := BinaryReader{Reader: bytes.NewReader(data), Endianess: LittleEndian}
+	if err := br.ReadInterface(&t2); err != nil {
+		t.Fatal(err)
+	}
+	// A is at struct start (2) + 3, and B at Table's position (3) + 3
+	if t2.Inner.A != 1 || t2.Inner.B != 2 {
+		t.Errorf("Unexpected values: %+v", t2.Inner)
+	}
+	if p := br.Tell(); p != 4 {
+		t.Errorf("Expected to be at 4, but was at %d", p)
+	}
+}
+
+func TestBinaryReaderOffsetRelativeInvalid(t *testing.T) {
+	tests := []interface{}{
+		&struct {
+			Off uint8
+			A   uint8 `offset:"Off" relative:"Missing"`
+		}{},
+		&struct {
+			Off uint8
+			A   uint8 `offset:"Off" relative:"B"`
+			B   uint8
+		}{},
+		&struct {
+			Off   uint8
+			Table uint8 `if:"Off > 5"`
+			A     uint8 `offset:"Off" relative:"Table"`
+		}{},
+	}
+	for i, test := range tests {
+		br := BinaryReader{Reader: bytes.NewReader(make([]byte, 8)), Endianess: LittleEndian}
+		if err := br.ReadInterface(test); err == nil {
+			t.Errorf("%d: Expected an error, but didn't get one", i)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"io"
+	"reflect"
+)
+
+// Returns whether the reader is positioned at the end of the stream,
+// without consuming any data.
+//
+// This is what the `optional` tag uses to find out whether an
+// optional trailing field, typically one only present in newer
+// versions of a format, is there at all:
+//
+//	type Header struct {
+//		Version uint16
+//		Flags   uint16
+//		// Added in version 2
+//		Extra   uint32 `optional:"true"`
+//	}
+//
+// If the stream ends right where an optional field starts, that field
+// and all the fields after it are set to their zero values and the
+// struct is considered successfully read. Ending anywhere else, such
+// as in the middle of the optional field, is still an error.
+func (r *BinaryReader) atEOF() (bool, error) {
+	n, err := r.Reader.Read(r.scratch[:1])
+	if n == 0 {
+		if err == io.EOF {
+			return true, nil
+		}
+		return false, err
+	}
+	_, err = r.Seek(-1, 1)
+	return false, err
+}
+
+// Sets the fields of the struct v from the field fp onwards to their
+// zero values.
+func (p *structPlan) zeroFrom(v reflect.Value, fp *fieldPlan) {
+	for i := fp.index; i < len(p.fields); i++ {
+		f := p.fields[i].field(v)
+		f.Set(reflect.Zero(f.Type()))
+	}
+}
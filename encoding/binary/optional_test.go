@@ -0,0 +1,42 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	sb "encoding/binary"
+	"testing"
+)
+
+func TestBinaryReaderOptional(t *testing.T) {
+	type Header struct {
+		Version uint16
+		Extra   uint32 `optional:"true"`
+		Flags   uint8
+	}
+	tests := []struct {
+		data []byte
+		exp  Header
+		err  bool
+	}{
+		{[]byte{1, 0}, Header{Version: 1}, false},
+		{[]byte{2, 0, 3, 0, 0, 0, 4}, Header{2, 3, 4}, false},
+		// Ending within the optional field isn't clean
+		{[]byte{2, 0, 3, 0}, Header{}, true},
+		// Nor is ending after it
+		{[]byte{2, 0, 3, 0, 0, 0}, Header{}, true},
+		{[]byte{2}, Header{}, true},
+	}
+	for i, test := range tests {
+		// The optional fields are zeroed, even if they held data
+		h := Header{Extra: 10, Flags: 11}
+		br := BinaryReader{Reader: bytes.NewReader(test.data), Endianess: sb.LittleEndian}
+		if err := br.ReadInterface(&h); (err != nil) != test.err {
+			t.Errorf("Test %d: Unexpected error: %v", i, err)
+		} else if !test.err && h != test.exp {
+			t.Errorf("Test %d: Expected %+v, got %+v", i, test.exp, h)
+		}
+	}
+}
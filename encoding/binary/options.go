@@ -0,0 +1,83 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	sb "encoding/binary"
+	"fmt"
+	"reflect"
+)
+
+// Returns whether the struct field f2 declares the options of its
+// struct rather than being a field to read. Options are declared by
+// the tags of a blank field of zero size, and hold for the struct as
+// a whole, so that a format definition doesn't depend on how the
+// BinaryReader reading it was set up:
+//
+//	type Header struct {
+//		_     struct{} `endian:"big" align:"4"`
+//		Magic uint32
+//		Name  string
+//	}
+//
+//	endian:"big"
+//		The struct, and the structs read as part of it which don't
+//		declare an endian option of their own, are read with the
+//		given byte order, "big" or "little", rather than with the
+//		BinaryReader's Endianess.
+//	align:"4"
+//		Every field of the struct which doesn't have an align tag
+//		of its own is aligned as if it had this one.
+//	version:"Header.Version"
+//		The expression the minver and maxver tags of the fields
+//		are compared against, rather than Version. See Versions.
+func isOptions(f2 reflect.StructField) bool {
+	return f2.Name == "_" && f2.Type.Size() == 0 && f2.Tag != ""
+}
+
+// Returns the byte order by the given name, or nil for an empty name.
+func parseEndian(name string) (sb.ByteOrder, error) {
+	switch name {
+	case "":
+		return nil, nil
+	case "big":
+		return BigEndian, nil
+	case "little":
+		return LittleEndian, nil
+	}
+	return nil, fmt.Errorf("Unknown byte order %q, expected big or little", name)
+}
+
+// Returns the tag of the options field of the struct type t, if any.
+func optionsTag(t reflect.Type) reflect.StructTag {
+	for i := 0; i < t.NumField(); i++ {
+		if f2 := t.Field(i); isOptions(f2) {
+			return f2.Tag
+		}
+	}
+	return ""
+}
+
+// Sets the options of the plan from the tag of an options field.
+func (p *structPlan) setOptions(tag reflect.StructTag) {
+	p.endian, p.err = parseEndian(tag.Get("endian"))
+	p.align = parseExpr(tag.Get("align"))
+	p.version = tag.Get("version")
+}
+
+// Applies the default alignment of the plan's options to the fields
+// without one of their own.
+func (p *structPlan) applyOptions() {
+	if p.align == nil {
+		return
+	}
+	for i := range p.fields {
+		if fp := &p.fields[i]; fp.align == nil {
+			fp.align = p.align
+			// The fast path doesn't align
+			fp.fast = nil
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStructOptions(t *testing.T) {
+	type (
+		Little struct {
+			_ struct{} `endian:"little"`
+			A uint16
+		}
+		Inherited struct {
+			B uint16
+		}
+		Header struct {
+			_         struct{} `endian:"big" align:"4"`
+			Magic     uint16
+			Count     uint8
+			Little    Little
+			Inherited Inherited `align:"2"`
+			Last      uint32
+		}
+	)
+	data := []byte{
+		0x12, 0x34, 0, 0,
+		7, 0, 0, 0,
+		0x34, 0x12, 0, 0,
+		0x12, 0x34,
+		0, 0, 0, 1,
+	}
+	var (
+		h  Header
+		br = BinaryReader{Reader: bytes.NewReader(data), Endianess: LittleEndian}
+	)
+	if err := br.ReadInterface(&h); err != nil {
+		t.Fatal(err)
+	}
+	if h.Magic != 0x1234 || h.Count != 7 || h.Little.A != 0x1234 || h.Inherited.B != 0x1234 || h.Last != 1 {
+		t.Errorf("Unexpected header %+v", h)
+	}
+	if br.Tell() != int64(len(data)) {
+		t.Errorf("Expected to read %d bytes, but read %d", len(data), br.Tell())
+	}
+	if br.Endianess != LittleEndian {
+		t.Error("Expected the reader's byte order to be restored")
+	}
+	if n, err := SizeOf(&h); err != nil || n != len(data) {
+		t.Errorf("Expected a size of %d, but got %d, %v", len(data), n, err)
+	}
+	var buf bytes.Buffer
+	if err := WriteLayoutReport(&buf, reflect.TypeOf(h), LittleEndian, LayoutText); err != nil {
+		t.Fatal(err)
+	} else if s := buf.String(); strings.Contains(s, "_") || !strings.Contains(s, "be") || !strings.Contains(s, "Little.A") {
+		t.Errorf("Unexpected report:\n%s", s)
+	}
+
+	type Bad struct {
+		_ struct{} `endian:"middle"`
+		A uint8
+	}
+	br = BinaryReader{Reader: bytes.NewReader([]byte{1}), Endianess: LittleEndian}
+	if err := br.ReadInterface(&Bad{}); err == nil || !strings.Contains(err.Error(), "middle") {
+		t.Errorf("Expected an error about the byte order, but got %v", err)
+	}
+	if err := Precompile(reflect.TypeOf(Bad{})); err == nil {
+		t.Error("Expected Precompile to fail")
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Returns the number of bytes holding count packed elements of
+// the given bit width.
+func packedSize(count, bits int) int {
+	return (count*bits + 7) / 8
+}
+
+// Reads count integers of the given bit width, packed contiguously
+// without any padding between them, into the slice or array f. This
+// is what a `bits` tag on a slice or array field does:
+//
+//	type Samples struct {
+//		Count uint16
+//		Data  []uint16 `length:"Count" bits:"12"`
+//	}
+//
+// By default the bits are packed most significant bit first, the
+// same order as fields with a `bits` tag are read in, so that the
+// 12-bit values 0xabc and 0xdef are stored as ab cd ef. The
+// bitorder:"lsb" tag instead packs them least significant bit first,
+// in which case the same values are stored as bc fa de. Signed
+// elements are sign extended from the given width.
+//
+// The packed data starts on a byte boundary, and any bits left over
+// in the last byte are skipped.
+func (r *BinaryReader) readPacked(f reflect.Value, fp *fieldPlan, bits, count int) (int, error) {
+	et := f.Type().Elem()
+	if !isInteger(et.Kind()) {
+		return 0, fmt.Errorf("Field %s: bit-packed elements must be integers, not %s", fp.name, et)
+	} else if bits < 1 || bits > 64 || bits > int(et.Size()*8) {
+		return 0, fmt.Errorf("Field %s: invalid element width of %d bits for %s", fp.name, bits, et)
+	}
+	lsb := false
+	switch fp.bitorder {
+	case "", "msb":
+	case "lsb":
+		lsb = true
+	default:
+		return 0, fmt.Errorf("Field %s: unknown bit order %s", fp.name, fp.bitorder)
+	}
+	if f.Kind() == reflect.Array {
+		count = f.Len()
+	} else if count < 0 {
+		return 0, fmt.Errorf("Field %s: bit-packed slices require a known length", fp.name)
+	}
+	size := packedSize(count, bits)
+	data, err := r.Read(size)
+	if err != nil {
+		return 0, err
+	}
+	defer r.release(data)
+
+	v := f
+	if f.Kind() == reflect.Slice {
+		if r.Reuse && f.Cap() >= count {
+			v = f.Slice(0, count)
+		} else {
+			v = reflect.MakeSlice(f.Type(), count, count)
+		}
+	}
+	signed := !isUnsigned(et.Kind())
+	for i, pos := 0, 0; i < count; i++ {
+		var u uint64
+		for b := 0; b < bits; b, pos = b+1, pos+1 {
+			if lsb {
+				u |= uint64(data[pos/8]>>(pos%8)&1) << b
+			} else {
+				u = u<<1 | uint64(data[pos/8]>>(7-pos%8)&1)
+			}
+		}
+		if signed {
+			shift := uint(64 - bits)
+			v.Index(i).SetInt(int64(u<<shift) >> shift)
+		} else {
+			v.Index(i).SetUint(u)
+		}
+	}
+	if f.Kind() == reflect.Slice {
+		f.Set(v)
+	}
+	return size, nil
+}
+
+func isUnsigned(k reflect.Kind) bool {
+	switch k {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
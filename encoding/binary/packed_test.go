@@ -0,0 +1,53 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	sb "encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func TestBinaryReaderPacked(t *testing.T) {
+	type Samples struct {
+		Count  uint8
+		MSB    []uint16 `length:"Count" bits:"12"`
+		LSB    []uint16 `length:"Count" bits:"12" bitorder:"lsb"`
+		Signed [3]int8  `bits:"4"`
+		Tail   uint8
+	}
+	data := []byte{
+		2,
+		0xab, 0xcd, 0xef,
+		0xbc, 0xfa, 0xde,
+		0x7f, 0x80,
+		0x42,
+	}
+	var (
+		s  Samples
+		br = BinaryReader{Reader: bytes.NewReader(data), Endianess: sb.LittleEndian}
+	)
+	if err := br.ReadInterface(&s); err != nil {
+		t.Fatal(err)
+	}
+	exp := Samples{2, []uint16{0xabc, 0xdef}, []uint16{0xabc, 0xdef}, [3]int8{7, -1, -8}, 0x42}
+	if !reflect.DeepEqual(s, exp) {
+		t.Errorf("Expected %+v, got %+v", exp, s)
+	}
+	if n, err := SizeOf(&s); err != nil {
+		t.Error(err)
+	} else if n != len(data) {
+		t.Errorf("Expected a size of %d, got %d", len(data), n)
+	}
+
+	type Bad struct {
+		Data []uint8 `length:"1" bits:"12"`
+	}
+	br = BinaryReader{Reader: bytes.NewReader(data), Endianess: sb.LittleEndian}
+	if err := br.ReadInterface(&Bad{}); err == nil {
+		t.Error("Expected an error for elements too narrow for the width")
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	"reflect"
+	"sync"
+)
+
+// The least number of bytes worth handing to a goroutine of its own
+// when decoding a slice in parallel.
+const parallelChunkSize = 16 << 10
+
+// Returns the encoded size of values of type t if it's the same for
+// all values, which is the case for numbers, booleans, and arrays and
+// untagged structs of such. Types which read or validate themselves
+// are never of fixed size, as they might read any number of bytes
+// or depend on being validated in order.
+func fixedSize(t reflect.Type) (int, bool) {
+	if t == uint128Type {
+		return 16, true
+	} else if !isPlain(t) {
+		return 0, false
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.Uint8, reflect.Int8:
+		return 1, true
+	case reflect.Uint16, reflect.Int16:
+		return 2, true
+	case reflect.Uint32, reflect.Int32, reflect.Float32:
+		return 4, true
+	case reflect.Uint64, reflect.Int64, reflect.Float64:
+		return 8, true
+	case reflect.Array:
+		if es, ok := fixedSize(t.Elem()); ok {
+			return es * t.Len(), true
+		}
+	case reflect.Struct:
+		size := 0
+		for i := 0; i < t.NumField(); i++ {
+			f2 := t.Field(i)
+			if f2.Tag != "" {
+				return 0, false
+			}
+			fs, ok := fixedSize(f2.Type)
+			if !ok {
+				return 0, false
+			}
+			size += fs
+		}
+		return size, true
+	}
+	return 0, false
+}
+
+// Returns the number of goroutines to decode count elements of the
+// given size with, which is 1 unless parallel decoding is enabled
+// and there's enough data to make it worthwhile.
+func (r *BinaryReader) parallelism(count, size int) int {
+	if r.Workers < 2 || r.Trace != nil {
+		return 1
+	}
+	return max(1, min(r.Workers, count*size/parallelChunkSize))
+}
+
+// Decodes the elements of the slice v, each of which takes up size
+// bytes, split across the given number of goroutines. The data of
+// all the elements is read up front, and each goroutine decodes its
+// share from a BinaryReader of its own.
+func (r *BinaryReader) readParallel(v reflect.Value, size, workers int) error {
+	data, err := r.Read(v.Len() * size)
+	if err != nil {
+		return err
+	}
+	defer r.release(data)
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, workers)
+		per  = (v.Len() + workers - 1) / workers
+	)
+	for w := 0; w < workers; w++ {
+		from, to := w*per, min((w+1)*per, v.Len())
+		if from >= to {
+			break
+		}
+		wg.Add(1)
+		go func(w, from, to int) {
+			defer wg.Done()
+			sub := BinaryReader{
+				Reader:    bytes.NewReader(data[from*size : to*size]),
+				Endianess: r.Endianess,
+				Vars:      r.Vars,
+			}
+			for i := from; i < to; i++ {
+				if err := sub.ReadInterface(v.Index(i).Addr().Interface()); err != nil {
+					errs[w] = err
+					return
+				}
+			}
+		}(w, from, to)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+type parallelRecord struct {
+	A uint32
+	B int16
+	C [2]uint8
+}
+
+type parallelChecked struct {
+	V uint32
+}
+
+func (p *parallelChecked) Validate() error {
+	if p.V == 0xffffffff {
+		return fmt.Errorf("Invalid value")
+	}
+	return nil
+}
+
+// Reads itself from a single byte, although its kind is that of
+// a uint32.
+type parallelSmall uint32
+
+func (p *parallelSmall) Read(r *BinaryReader) error {
+	v, err := r.Uint8()
+	*p = parallelSmall(v)
+	return err
+}
+
+func TestFixedSize(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		size int
+		ok   bool
+	}{
+		{uint16(0), 2, true},
+		{parallelRecord{}, 8, true},
+		{[3]parallelRecord{}, 24, true},
+		{Uint128{}, 16, true},
+		{"", 0, false},
+		{struct {
+			A uint8 `if:"1"`
+		}{}, 0, false},
+		{struct{ A []uint8 }{}, 0, false},
+		{parallelSmall(0), 0, false},
+		{[4]parallelSmall{}, 0, false},
+		{struct{ A parallelSmall }{}, 0, false},
+		{parallelChecked{}, 0, false},
+	}
+	for _, test := range tests {
+		if size, ok := fixedSize(reflect.TypeOf(test.v)); size != test.size || ok != test.ok {
+			t.Errorf("%T: Expected %d, %v, but got %d, %v", test.v, test.size, test.ok, size, ok)
+		}
+	}
+}
+
+func TestBinaryReaderWorkers(t *testing.T) {
+	type Table struct {
+		Count   uint32
+		Records []parallelRecord `length:"Count"`
+		End     uint8
+	}
+	var (
+		count = 20000
+		data  = make([]byte, 4+count*8+1)
+	)
+	rand.New(rand.NewSource(1)).Read(data)
+	LittleEndian.PutUint32(data, uint32(count))
+	var exp, got Table
+	br := BinaryReader{Reader: bytes.NewReader(data), Endianess: LittleEndian}
+	if err := br.ReadInterface(&exp); err != nil {
+		t.Fatal(err)
+	}
+	br = BinaryReader{Reader: bytes.NewReader(data), Endianess: LittleEndian, Workers: 4}
+	if err := br.ReadInterface(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(exp, got) {
+		t.Error("Decoding in parallel gave a different result")
+	}
+	if br.Tell() != int64(len(data)) {
+		t.Errorf("Expected to read %d bytes, but read %d", len(data), br.Tell())
+	}
+
+	type Small struct {
+		Values []parallelSmall `length:"40000"`
+	}
+	data = make([]byte, 40000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	var small Small
+	br = BinaryReader{Reader: bytes.NewReader(data), Endianess: LittleEndian, Workers: 4}
+	if err := br.ReadInterface(&small); err != nil {
+		t.Error(err)
+	} else if len(small.Values) != 40000 || small.Values[39999] != parallelSmall(39999%256) {
+		t.Error("Unexpected values of the elements reading themselves")
+	}
+
+	type Checked struct {
+		Values []parallelChecked `length:"10000"`
+	}
+	data = make([]byte, 4*10000)
+	copy(data[4*9000:], []byte{0xff, 0xff, 0xff, 0xff})
+	br = BinaryReader{Reader: bytes.NewReader(data), Endianess: LittleEndian, Workers: 2}
+	if err := br.ReadInterface(&Checked{}); err == nil {
+		t.Error("Expected the validation error of an element to be returned")
+	}
+}
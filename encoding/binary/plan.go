@@ -0,0 +1,492 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	sb "encoding/binary"
+	"fmt"
+	"github.com/quarnster/parser"
+	"github.com/quarnster/util/encoding/binary/expression"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+type (
+	// A tag expression, parsed once and then evaluated for every
+	// struct read.
+	expr struct {
+		src  string
+		node *parser.Node
+		err  error
+	}
+
+	// Reads a field that has no tags and a primitive kind, without
+	// going through ReadInterface.
+	fastReader func(r *BinaryReader, f reflect.Value) error
+
+	// The layout plan of a single struct field. Tags holding
+	// expressions are nil when the tag isn't set.
+	fieldPlan struct {
+		// The index of the field in the plan, and the index path
+		// of the field in the struct
+		index     int
+		path      []int
+		name      string
+		typ       reflect.Type
+		kind      reflect.Kind
+		tag       reflect.StructTag
+		cond      *expr
+		skip      *expr
+		offset    *expr
+		relative  string
+		bits      *expr
+		length    string
+		max       *expr
+		align     *expr
+		typeof    *expr
+		null      string
+		fixed     *fixedPoint
+		transform string
+		match     string
+		resync    string
+		uuid      string
+		in        []string
+		assert    *expr
+		size      *expr
+		noterm    bool
+		optional  bool
+		zigzag    bool
+		delta     *expr
+		minver    string
+		maxver    string
+		enum      string
+		bitorder  string
+		// Whether values not in the enum are errors
+		strictEnum bool
+		// Whether the field reads itself from a sub-stream bounded
+		// by its length or size tag
+		bounded bool
+		// Whether the field is a slice or array of bit-packed
+		// elements, rather than a single bit field
+		packed bool
+		// Whether the field reads itself via FieldReader
+		fieldReader bool
+		// Whether the tags of any field in the struct refer to
+		// this field
+		referenced bool
+		fast       fastReader
+		elemFast   fastReader
+	}
+
+	// The layout plan of a struct type, compiled the first time the
+	// type is read and then reused for every subsequent read.
+	structPlan struct {
+		fields    []fieldPlan
+		hasOffset bool
+		// Whether any tag expression uses sizeof(Field)
+		hasSizeOf bool
+		// The byte order and default alignment declared by the
+		// struct's options, if any
+		endian sb.ByteOrder
+		align  *expr
+		// The expression the minver and maxver tags of the fields
+		// are compared against
+		version string
+		// The error of the struct's options, if any
+		err error
+	}
+)
+
+var (
+	structPlans sync.Map // reflect.Type -> *structPlan
+	parsedExprs sync.Map // string -> *expr
+)
+
+var fastReaders = map[reflect.Kind]fastReader{
+	reflect.Bool: func(r *BinaryReader, f reflect.Value) error {
+		d, err := r.Uint8()
+		f.SetBool(d != 0)
+		return err
+	},
+	reflect.Uint8: func(r *BinaryReader, f reflect.Value) error {
+		d, err := r.Uint8()
+		f.SetUint(uint64(d))
+		return err
+	},
+	reflect.Uint16: func(r *BinaryReader, f reflect.Value) error {
+		d, err := r.Uint16()
+		f.SetUint(uint64(d))
+		return err
+	},
+	reflect.Uint32: func(r *BinaryReader, f reflect.Value) error {
+		d, err := r.Uint32()
+		f.SetUint(uint64(d))
+		return err
+	},
+	reflect.Uint64: func(r *BinaryReader, f reflect.Value) error {
+		d, err := r.Uint64()
+		f.SetUint(d)
+		return err
+	},
+	reflect.Int8: func(r *BinaryReader, f reflect.Value) error {
+		d, err := r.Int8()
+		f.SetInt(int64(d))
+		return err
+	},
+	reflect.Int16: func(r *BinaryReader, f reflect.Value) error {
+		d, err := r.Int16()
+		f.SetInt(int64(d))
+		return err
+	},
+	reflect.Int32: func(r *BinaryReader, f reflect.Value) error {
+		d, err := r.Int32()
+		f.SetInt(int64(d))
+		return err
+	},
+	reflect.Int64: func(r *BinaryReader, f reflect.Value) error {
+		d, err := r.Int64()
+		f.SetInt(d)
+		return err
+	},
+	reflect.Float32: func(r *BinaryReader, f reflect.Value) error {
+		d, err := r.Float32()
+		f.SetFloat(float64(d))
+		return err
+	},
+	reflect.Float64: func(r *BinaryReader, f reflect.Value) error {
+		d, err := r.Float64()
+		f.SetFloat(d)
+		return err
+	},
+}
+
+var (
+	readerType       = reflect.TypeOf((*Reader)(nil)).Elem()
+	fieldReaderType  = reflect.TypeOf((*FieldReader)(nil)).Elem()
+	validateableType = reflect.TypeOf((*Validateable)(nil)).Elem()
+)
+
+// Returns the parsed expression src, parsing it only the first
+// time it's seen. Returns nil for an empty src.
+func parseExpr(src string) *expr {
+	if src == "" {
+		return nil
+	}
+	if e, ok := parsedExprs.Load(src); ok {
+		return e.(*expr)
+	}
+	e := &expr{src: src}
+	e.node, e.err = expression.Parse(src)
+	e2, _ := parsedExprs.LoadOrStore(src, e)
+	return e2.(*expr)
+}
+
+// Evaluates the expression in the context of the struct v and the
+// environment env, which may be nil.
+func (e *expr) eval(v *reflect.Value, env *expression.Env) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	return expression.EvalEnv(v, e.node, env)
+}
+
+// Evaluates the expression src in the context of the struct v.
+func evalExpr(v *reflect.Value, src string, env *expression.Env) (int, error) {
+	return parseExpr(src).eval(v, env)
+}
+
+// Splits a comma separated tag value, trimming whitespace.
+func splitTag(tag string) []string {
+	if tag == "" {
+		return nil
+	}
+	ret := strings.Split(tag, ",")
+	for i := range ret {
+		ret[i] = strings.TrimSpace(ret[i])
+	}
+	return ret
+}
+
+// Returns whether values of type t read themselves, either via
+// the Reader or the FieldReader interface.
+func readsItself(t reflect.Type) bool {
+	pt := reflect.PtrTo(t)
+	return pt.Implements(readerType) || pt.Implements(fieldReaderType)
+}
+
+// Returns whether values of type t are decoded by the BinaryReader
+// alone, neither reading nor validating themselves.
+func isPlain(t reflect.Type) bool {
+	return !readsItself(t) && !reflect.PtrTo(t).Implements(validateableType)
+}
+
+// Returns whether the struct field f2 is an embedded struct whose
+// fields are to be read as if they were fields of the embedding
+// struct. This is the case unless the embedded struct has tags of
+// its own or reads or validates itself.
+func isInlined(f2 reflect.StructField) bool {
+	t := f2.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if !f2.Anonymous || f2.Tag != "" || t.Kind() != reflect.Struct {
+		return false
+	} else if t != f2.Type && !f2.IsExported() {
+		// Pointers to unexported structs can't be allocated
+		return false
+	}
+	return !readsItself(t) && !reflect.PtrTo(t).Implements(validateableType)
+}
+
+// Returns the layout plan of the struct type t, compiling it
+// if this is the first time t is seen.
+func getPlan(t reflect.Type) *structPlan {
+	if p, ok := structPlans.Load(t); ok {
+		return p.(*structPlan)
+	}
+	p := &structPlan{}
+	p.addFields(t, nil)
+	p.applyOptions()
+	p.applyVersions()
+	p.markReferenced()
+	p2, _ := structPlans.LoadOrStore(t, p)
+	return p2.(*structPlan)
+}
+
+// Adds the fields of the struct type t, found at the index path
+// prefix, to the plan. The fields of embedded structs are inlined,
+// so that they're read in the context of the embedding struct.
+func (p *structPlan) addFields(t reflect.Type, prefix []int) {
+	for i := 0; i < t.NumField(); i++ {
+		var (
+			f2   = t.Field(i)
+			tag  = f2.Tag
+			path = append(prefix[:len(prefix):len(prefix)], i)
+		)
+		if isOptions(f2) {
+			p.setOptions(tag)
+			continue
+		} else if isInlined(f2) {
+			et := f2.Type
+			if et.Kind() == reflect.Ptr {
+				et = et.Elem()
+			}
+			p.addFields(et, path)
+			continue
+		}
+		p.fields = append(p.fields, fieldPlan{
+			index:     len(p.fields),
+			path:      path,
+			name:      f2.Name,
+			typ:       f2.Type,
+			kind:      f2.Type.Kind(),
+			tag:       tag,
+			cond:      parseExpr(tag.Get("if")),
+			skip:      parseExpr(tag.Get("skip")),
+			offset:    parseExpr(tag.Get("offset")),
+			relative:  tag.Get("relative"),
+			bits:      parseExpr(tag.Get("bits")),
+			length:    tag.Get("length"),
+			max:       parseExpr(tag.Get("max")),
+			align:     parseExpr(tag.Get("align")),
+			typeof:    parseExpr(tag.Get("typeof")),
+			null:      tag.Get("null"),
+			fixed:     parseFixed(tag.Get("fixed")),
+			transform: tag.Get("transform"),
+			match:     tag.Get("match"),
+			resync:    tag.Get("resync"),
+			uuid:      tag.Get("uuid"),
+			in:        splitTag(tag.Get("in")),
+			assert:    parseExpr(tag.Get("assert")),
+			size:      parseExpr(tag.Get("size")),
+			noterm:    tag.Get("noterm") == "true",
+			optional:  tag.Get("optional") == "true",
+			zigzag:    tag.Get("zigzag") == "true",
+			delta:     parseExpr(tag.Get("delta")),
+			minver:    tag.Get("minver"),
+			maxver:    tag.Get("maxver"),
+			bounded:   isBounded(f2.Type, tag),
+		})
+		fp := &p.fields[len(p.fields)-1]
+		if fp.offset != nil {
+			p.hasOffset = true
+		}
+		if tag == "" && isPlain(f2.Type) {
+			fp.fast = fastReaders[fp.kind]
+		}
+		if fp.kind == reflect.Slice && isPlain(f2.Type.Elem()) {
+			fp.elemFast = fastReaders[f2.Type.Elem().Kind()]
+		}
+		if k := fp.kind; fp.fixed != nil && fp.fixed.err == nil && k != reflect.Float32 && k != reflect.Float64 {
+			fp.fixed = &fixedPoint{err: fmt.Errorf("Fixed point values must be read into floats, not %s", k)}
+		}
+		fp.enum, fp.strictEnum = parseEnum(tag.Get("enum"))
+		fp.packed = fp.bits != nil && (fp.kind == reflect.Slice || fp.kind == reflect.Array)
+		fp.bitorder = tag.Get("bitorder")
+		fp.fieldReader = reflect.PtrTo(f2.Type).Implements(fieldReaderType)
+	}
+}
+
+// Marks the fields referred to by the tag expressions of the plan.
+func (p *structPlan) markReferenced() {
+	var mark func(n *parser.Node)
+	mark = func(n *parser.Node) {
+		switch n.Name {
+		case "DotIdentifier":
+			n = n.Children[0]
+			fallthrough
+		case "Identifier":
+			if fp := p.lookup(n.Data()); fp != nil {
+				fp.referenced = true
+			}
+			return
+		case "First", "Last":
+			// Any further identifiers refer to the element
+			mark(n.Children[0])
+			return
+		case "SizeOf":
+			p.hasSizeOf = true
+		}
+		for _, c := range n.Children {
+			mark(c)
+		}
+	}
+	for i := range p.fields {
+		fp := &p.fields[i]
+		exprs := fp.exprs()
+		for _, l := range strings.Split(fp.length, ",") {
+			switch l = strings.TrimSpace(l); l {
+			case "", "uint8", "uint16", "uint32", "uint64":
+			default:
+				exprs = append(exprs, parseExpr(l))
+			}
+		}
+		for _, e := range exprs {
+			if e != nil && e.node != nil {
+				mark(e.node)
+			}
+		}
+	}
+}
+
+// Returns the plan of the field by the given name, or nil if
+// there is no such field.
+func (p *structPlan) lookup(name string) *fieldPlan {
+	for i := range p.fields {
+		if p.fields[i].name == name {
+			return &p.fields[i]
+		}
+	}
+	return nil
+}
+
+// Returns the field fp of the struct v, allocating any nil
+// embedded struct pointers on the way.
+func (fp *fieldPlan) field(v reflect.Value) reflect.Value {
+	for i, x := range fp.path {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// Returns the field fp of the struct v like field does, but without
+// allocating anything. Fields promoted through nil embedded struct
+// pointers are returned as zero values.
+func (fp *fieldPlan) get(v reflect.Value) reflect.Value {
+	for i, x := range fp.path {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Zero(fp.typ)
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// Returns the tag expressions of the field, some of which
+// might be nil.
+func (fp *fieldPlan) exprs() []*expr {
+	return []*expr{fp.cond, fp.skip, fp.offset, fp.bits, fp.max, fp.align, fp.typeof, fp.assert, fp.size, fp.delta}
+}
+
+// Returns the first error of the tag expressions of the field, or
+// else that of its fixed tag.
+func (fp *fieldPlan) err() error {
+	for _, e := range fp.exprs() {
+		if e != nil && e.err != nil {
+			return e.err
+		}
+	}
+	if fp.fixed != nil {
+		return fp.fixed.err
+	}
+	return nil
+}
+
+// Compiles the layout plans of the given type and of all the struct
+// types reachable from it, so that the first ReadInterface of the
+// type doesn't have to. Plans are otherwise compiled on first use.
+//
+// Also returns an error if any of the tag expressions in the
+// reached types fail to parse, which makes Precompile a convenient
+// way of checking type definitions at start up:
+//
+//	func init() {
+//		if err := binary.Precompile(reflect.TypeOf(Header{})); err != nil {
+//			panic(err)
+//		}
+//	}
+//
+// CheckType goes further, checking the tags for consistency with the
+// fields they're on and the fields they refer to.
+func Precompile(t reflect.Type) error {
+	return precompile(t, make(map[reflect.Type]bool))
+}
+
+func precompile(t reflect.Type, seen map[reflect.Type]bool) error {
+	if seen[t] {
+		return nil
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Array, reflect.Slice:
+		return precompile(t.Elem(), seen)
+	case reflect.Struct:
+		p := getPlan(t)
+		if p.err != nil {
+			return fmt.Errorf("%s: %s", t, p.err)
+		} else if p.align != nil && p.align.err != nil {
+			return fmt.Errorf("%s: %s", t, p.align.err)
+		}
+		for i := range p.fields {
+			fp := &p.fields[i]
+			if err := fp.err(); err != nil {
+				return fmt.Errorf("%s field %s: %s", t, fp.name, err)
+			}
+			for _, l := range strings.Split(fp.length, ",") {
+				switch l = strings.TrimSpace(l); l {
+				case "", "uint8", "uint16", "uint32", "uint64":
+				default:
+					if e := parseExpr(l); e.err != nil {
+						return fmt.Errorf("%s field %s: %s", t, fp.name, e.err)
+					}
+				}
+			}
+			if err := precompile(fp.typ, seen); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
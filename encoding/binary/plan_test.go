@@ -0,0 +1,76 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPrecompile(t *testing.T) {
+	type (
+		Inner struct {
+			Count uint8
+			Data  []uint16 `length:"Count"`
+		}
+		Outer struct {
+			Version uint8
+			Extra   uint8    `if:"Version > 1"`
+			Inner   [2]Inner `align:"4"`
+			Next    *Inner   `null:"0"`
+		}
+		Broken struct {
+			A uint8
+			B uint8 `if:"A >"`
+		}
+		BrokenLength struct {
+			A    uint8
+			Data []uint8 `length:"A,)"`
+		}
+		Nested struct {
+			Ok     Inner
+			Broken []Broken
+		}
+	)
+	if err := Precompile(reflect.TypeOf(Outer{})); err != nil {
+		t.Error(err)
+	}
+	for _, typ := range []reflect.Type{reflect.TypeOf(Inner{}), reflect.TypeOf(Outer{})} {
+		if _, ok := structPlans.Load(typ); !ok {
+			t.Errorf("Expected a plan for %s", typ)
+		}
+	}
+	for _, v := range []interface{}{Broken{}, BrokenLength{}, &Nested{}} {
+		if err := Precompile(reflect.TypeOf(v)); err == nil {
+			t.Errorf("Expected an error for %T", v)
+		} else if !strings.Contains(err.Error(), "field") {
+			t.Errorf("Expected the error to name the field: %s", err)
+		}
+	}
+}
+
+func TestPlanFastPath(t *testing.T) {
+	type (
+		Tagged struct {
+			V uint16 `bits:"16"`
+		}
+		Test struct {
+			A uint16
+			B Tagged
+			C int32
+			D []uint16 `length:"2"`
+		}
+	)
+	p := getPlan(reflect.TypeOf(Test{}))
+	for i, exp := range []bool{true, false, true, false} {
+		if got := p.fields[i].fast != nil; got != exp {
+			t.Errorf("Field %s: expected fast path %v, but got %v", p.fields[i].name, exp, got)
+		}
+	}
+	if p.fields[3].elemFast == nil {
+		t.Error("Expected a fast path for the slice elements")
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Like ReadInterface, but only decodes the named fields of the struct
+// v points to, which is useful when only a small part of a large
+// structure is of interest, such as a file's header:
+//
+//	var f File
+//	err := br.ReadFields(&f, "Header", "Index")
+//
+// The fields that weren't asked for are skipped over without being
+// decoded whenever their size is known up front, which is the case
+// for strings, slices and transformed fields with a `length` tag and
+// for types of a fixed size. Other fields, as well as the fields
+// referred to by the tags of other fields, are read as usual.
+//
+// Fields of embedded structs are named directly, just like they are
+// in tag expressions. Skipped fields are left untouched and aren't
+// validated. Once done, the reader is positioned after the struct
+// just as if it had been read in full.
+func (r *BinaryReader) ReadFields(v interface{}, names ...string) error {
+	t := reflect.TypeOf(v)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Expected a pointer to a struct, not %s", t)
+	} else if readsItself(t.Elem()) {
+		return fmt.Errorf("%s reads itself and can't be read selectively", t.Elem())
+	}
+	plan := getPlan(t.Elem())
+	want := make(map[string]bool, len(names))
+	for _, n := range names {
+		if plan.lookup(n) == nil {
+			return fmt.Errorf("No field by name %s in struct %s", n, t.Elem())
+		}
+		want[n] = true
+	}
+	r.want = want
+	defer func() { r.want = nil }()
+	return r.ReadInterface(v)
+}
+
+// Returns the number of bytes the field fp takes up in the stream,
+// given the size from its `length` tag, or -1 if it can't be known
+// without decoding the field.
+func skipSize(fp *fieldPlan, size int, lengths []int) int {
+	switch {
+	case fp.referenced, fp.fieldReader:
+		return -1
+	case fp.transform != "", fp.bounded && fp.size == nil:
+		return size
+	case fp.uuid != "":
+		return len(UUID{})
+	case fp.null != "", fp.fixed != nil, fp.bits != nil, fp.size != nil, len(lengths) > 1:
+		return -1
+	case fp.kind == reflect.String:
+		return size
+	case fp.kind == reflect.Slice:
+		if es := typeSize(fp.typ.Elem(), ""); size >= 0 && es.static() {
+			return size * es.n
+		}
+		return -1
+	}
+	if ts := typeSize(fp.typ, ""); ts.static() {
+		return ts.n
+	}
+	return -1
+}
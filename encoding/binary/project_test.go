@@ -0,0 +1,82 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	sb "encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func TestBinaryReaderReadFields(t *testing.T) {
+	type (
+		Header struct {
+			Magic [4]byte
+			Count uint8
+		}
+		File struct {
+			Header Header
+			Names  []string `length:"Header.Count"`
+			Blob   []uint16 `length:"Header.Count"`
+			Pad    [3]byte
+			Title  string `length:"4"`
+			Tail   uint8  `match:"7"`
+		}
+	)
+	data := []byte{
+		'F', 'I', 'L', 'E', 2,
+		'a', 0, 'b', 'c', 0,
+		1, 0, 2, 0,
+		9, 9, 9,
+		'a', 'b', 'c', 'd',
+		7,
+	}
+	for _, test := range []struct {
+		names []string
+		exp   File
+	}{
+		{[]string{"Header"}, File{Header: Header{[4]byte{'F', 'I', 'L', 'E'}, 2}, Names: []string{"a", "bc"}}},
+		{[]string{"Names", "Title"}, File{Header: Header{[4]byte{'F', 'I', 'L', 'E'}, 2}, Names: []string{"a", "bc"}, Title: "abcd"}},
+		{[]string{"Blob", "Pad", "Tail"}, File{Header: Header{[4]byte{'F', 'I', 'L', 'E'}, 2}, Names: []string{"a", "bc"}, Blob: []uint16{1, 2}, Pad: [3]byte{9, 9, 9}, Tail: 7}},
+	} {
+		var (
+			br = BinaryReader{Reader: bytes.NewReader(data), Endianess: sb.LittleEndian}
+			v  File
+		)
+		if err := br.ReadFields(&v, test.names...); err != nil {
+			t.Errorf("%v: %s", test.names, err)
+		} else if !reflect.DeepEqual(v, test.exp) {
+			t.Errorf("%v: Expected %+v, but got %+v", test.names, test.exp, v)
+		} else if br.Tell() != int64(len(data)) {
+			t.Errorf("%v: Expected to end up at %d, not %d", test.names, len(data), br.Tell())
+		}
+	}
+
+	type Record struct {
+		Id    uint16
+		Flags uint8
+		Size  uint32
+	}
+	var (
+		r  = Record{Id: 1, Flags: 2, Size: 3}
+		br = BinaryReader{Reader: bytes.NewReader([]byte{9, 0, 8, 7, 0, 0, 0}), Endianess: sb.LittleEndian}
+	)
+	if err := br.ReadFields(&r, "Flags"); err != nil {
+		t.Error(err)
+	} else if exp := (Record{Id: 1, Flags: 8, Size: 3}); r != exp {
+		t.Errorf("Expected the fields not asked for to be left untouched, %+v, but got %+v", exp, r)
+	} else if br.Tell() != 7 {
+		t.Errorf("Expected to end up at 7, not %d", br.Tell())
+	}
+
+	br = BinaryReader{Reader: bytes.NewReader(data), Endianess: sb.LittleEndian}
+	if err := br.ReadFields(&File{}, "Missing"); err == nil {
+		t.Error("Expected an error for a field that doesn't exist")
+	}
+	if err := br.ReadFields(File{}, "Header"); err == nil {
+		t.Error("Expected an error for a non-pointer")
+	}
+}
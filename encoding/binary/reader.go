@@ -12,11 +12,15 @@ package binary
 import (
 	sb "encoding/binary"
 	"fmt"
+	"github.com/quarnster/util"
 	"github.com/quarnster/util/encoding/binary/expression"
+	"github.com/quarnster/util/mathutil"
 	"io"
+	"log/slog"
 	"math"
 	"reflect"
-	"unsafe"
+	"strconv"
+	"strings"
 )
 
 type (
@@ -35,6 +39,33 @@ type (
 		Read(*BinaryReader) error
 	}
 
+	// Like Reader, but also given the tag of the struct field being
+	// read, which allows a single type to adapt to per-field tags
+	// instead of needing a new type for every variation:
+	//
+	//	type String string
+	//
+	//	func (s *String) ReadField(r *BinaryReader, tag reflect.StructTag) error {
+	//		switch tag.Get("encoding") {
+	//		...
+	//		}
+	//	}
+	//
+	//	type Record struct {
+	//		Name  String `encoding:"utf16"`
+	//		Label String `encoding:"latin1"`
+	//	}
+	//
+	// The BinaryReader doesn't interpret any of the tags of such
+	// fields other than `if`, `skip`, `offset`, `align` and the
+	// validation tags, leaving the rest to ReadField. Values read
+	// other than as struct fields, such as slice elements, are given
+	// an empty tag. Types implementing both interfaces are read
+	// with ReadField.
+	FieldReader interface {
+		ReadField(r *BinaryReader, tag reflect.StructTag) error
+	}
+
 	// The BinaryReader uses information provided in struct tags to deal with
 	// operations common when reading data from a binary file into a Go struct,
 	// such as data alignment, array lengths, "if" checks and skipping of
@@ -48,7 +79,39 @@ type (
 	BinaryReader struct {
 		Reader    io.ReadSeeker
 		Endianess sb.ByteOrder
-		br        BitReader
+		// When Reuse is true, slices that already have enough capacity
+		// to hold the data being read are resliced and overwritten
+		// rather than reallocated, which makes a big difference when
+		// repeatedly decoding records into the same destination.
+		Reuse bool
+		// If non-nil, every struct field read is logged at debug level
+		// to Trace, including its path, offset, raw bytes, decoded value
+		// and tags. Tracing can be turned on and off at any time by
+		// setting or clearing this field, or by changing the level of
+		// the logger's handler.
+		Trace *slog.Logger
+		// If non-nil, the slices returned by Read and ReadPartial are
+		// taken from Buffers rather than freshly allocated. Callers
+		// that are done with such a slice may hand it back with
+		// Buffers.Put, and the BinaryReader does so itself for the
+		// temporary data it reads internally.
+		Buffers *util.BytePool
+		// Values of identifiers in tag expressions which aren't fields
+		// of the struct being read, such as a block size provided by the
+		// application, as in `length:"BlockSize"`. See also
+		// expression.RegisterConst.
+		Vars map[string]int
+		// If greater than 1, large slices of elements whose encoded size
+		// is fixed, such as numbers and untagged structs of numbers, are
+		// decoded by up to this many goroutines in parallel. Elements
+		// which read or validate themselves are always decoded in order.
+		Workers int
+		// The fields to read of the next struct, as set by ReadFields
+		want    map[string]bool
+		br      BitReader
+		scratch [8]byte
+		strbuf  []byte
+		path    []string
 	}
 )
 
@@ -57,8 +120,17 @@ var (
 	BigEndian    = sb.BigEndian
 )
 
+// Returns whether order stores the least significant byte first,
+// which is also the case for sb.NativeEndian on little endian hosts
+// and for any other implementation of a little endian ByteOrder.
+func isLittleEndian(order sb.ByteOrder) bool {
+	return order.Uint16([]byte{1, 0}) == 1
+}
+
 func (r *BinaryReader) ReadInterface(v interface{}) error {
-	if ri, ok := v.(Reader); ok {
+	if fr, ok := v.(FieldReader); ok {
+		return fr.ReadField(r, "")
+	} else if ri, ok := v.(Reader); ok {
 		return ri.Read(r)
 	}
 	t := reflect.ValueOf(v)
@@ -172,42 +244,119 @@ func (r *BinaryReader) ReadInterface(v interface{}) error {
 		}
 		v2.SetString(string(data))
 	case reflect.Struct:
-		for i := 0; i < v2.NumField(); i++ {
+		// The struct is passed by pointer to the expression evaluator,
+		// which would make it escape to the heap for every call if it
+		// wasn't for this copy.
+		v2 := v2
+		base := len(r.path)
+		if r.Trace != nil {
+			defer func() { r.path = r.path[:base] }()
+		}
+		var (
+			plan        = getPlan(v2.Type())
+			structStart int64
+			positions   []int64
+			sizes       []int
+			// Only applies to this struct, not to any nested ones
+			want = r.want
+		)
+		r.want = nil
+		if plan.err != nil {
+			return fmt.Errorf("%s: %s", v2.Type(), plan.err)
+		} else if e := plan.endian; e != nil && e != r.Endianess {
+			defer func(e sb.ByteOrder) { r.Endianess = e }(r.Endianess)
+			r.Endianess = e
+		}
+		if plan.hasOffset {
+			structStart = r.Tell()
+			positions = newPositions(len(plan.fields))
+		}
+		if plan.hasSizeOf {
+			sizes = make([]int, len(plan.fields))
+			for i := range sizes {
+				sizes[i] = -1
+			}
+		}
+		var env *expression.Env
+		if sizes != nil || r.Vars != nil {
+			env = &expression.Env{SizeOf: plan.sizeFunc(sizes), Vars: r.Vars}
+		}
+		for i := range plan.fields {
 			var (
-				f    = v2.Field(i)
-				f2   = v2.Type().Field(i)
-				size = -1
-				err  error
+				fp       = &plan.fields[i]
+				f        = fp.field(v2)
+				size     = -1
+				err      error
+				start    int64
+				returnTo int64 = -1
 			)
-			if fi := f2.Tag.Get("if"); fi != "" {
-				var e expression.EXPRESSION
-				if !e.Parse(fi) {
-					return e.Error()
-				} else if ev, err := expression.Eval(&v2, e.RootNode()); err != nil {
+			if fp.fast != nil && r.Trace == nil && want == nil && positions == nil && sizes == nil {
+				if err := fp.fast(r, f); err != nil {
+					return err
+				}
+				continue
+			}
+			if fp.cond != nil {
+				if ev, err := fp.cond.eval(&v2, env); err != nil {
 					return err
 				} else if ev == 0 {
+					if sizes != nil {
+						sizes[i] = 0
+					}
 					continue
 				}
 			}
-			if l := f2.Tag.Get("skip"); l != "" {
-				var e expression.EXPRESSION
-				if !e.Parse(l) {
-					return e.Error()
-				} else if ev, err := expression.Eval(&v2, e.RootNode()); err != nil {
+			if fp.skip != nil {
+				if ev, err := fp.skip.eval(&v2, env); err != nil {
 					return err
 				} else if _, err := r.Seek(int64(ev), 1); err != nil {
 					return err
 				}
 			}
+			if fp.offset != nil {
+				// Read the field from the given offset, and then
+				// return to where we were.
+				if off, err := fieldOffset(&v2, plan, fp, structStart, positions, env); err != nil {
+					return err
+				} else if returnTo, err = r.Seek(0, 1); err != nil {
+					return err
+				} else if _, err := r.Seek(off, 0); err != nil {
+					return err
+				}
+			}
+			if fp.resync != "" {
+				if err := r.resync(fp); err != nil {
+					return err
+				}
+			}
+			if fp.optional {
+				if eof, err := r.atEOF(); err != nil {
+					return err
+				} else if eof {
+					plan.zeroFrom(v2, fp)
+					if returnTo >= 0 {
+						if _, err := r.Seek(returnTo, 0); err != nil {
+							return err
+						}
+					}
+					break
+				}
+			}
+			if r.Trace != nil || positions != nil || sizes != nil {
+				start = r.Tell()
+				if positions != nil {
+					positions[i] = start
+				}
+			}
+			if r.Trace != nil {
+				r.path = append(r.path[:base], fp.name)
+			}
 
-			if l := f2.Tag.Get("bits"); l != "" {
-				var e expression.EXPRESSION
+			if fp.bits != nil && !fp.packed {
 				if r.br.Inner == nil {
 					r.br.Inner = r.Reader
 				}
-				if !e.Parse(l) {
-					return e.Error()
-				} else if ev, err := expression.Eval(&v2, e.RootNode()); err != nil {
+				if ev, err := fp.bits.eval(&v2, env); err != nil {
 					return err
 				} else if bits, err := r.br.ReadBits(ev); err != nil {
 					return err
@@ -223,10 +372,35 @@ func (r *BinaryReader) ReadInterface(v interface{}) error {
 						return fmt.Errorf("Don't know how to set bits of type: %s", f.Kind())
 					}
 				}
+				if sizes != nil {
+					sizes[i] = int(r.Tell() - start)
+				}
+				if fp.zigzag || fp.delta != nil {
+					if err := decodeNumbers(&v2, f, fp, env); err != nil {
+						return err
+					}
+				}
+				if err := validateField(&v2, f, fp, env); err != nil {
+					return err
+				}
+				r.traceField(start, f, fp)
+				if returnTo >= 0 {
+					if _, err := r.Seek(returnTo, 0); err != nil {
+						return err
+					}
+				}
 				continue
 			}
 
-			if l := f2.Tag.Get("length"); l != "" {
+			var lengths []int
+			if l := fp.length; fp.fieldReader {
+				// Left for ReadField to interpret
+			} else if strings.Contains(l, ",") {
+				if lengths, err = evalLengths(&v2, l, env); err != nil {
+					return err
+				}
+				size = lengths[0]
+			} else if l != "" {
 				switch l {
 				case "uint8":
 					if s, err := r.Uint8(); err != nil {
@@ -253,10 +427,7 @@ func (r *BinaryReader) ReadInterface(v interface{}) error {
 						size = int(s)
 					}
 				default:
-					var e expression.EXPRESSION
-					if !e.Parse(l) {
-						return e.Error()
-					} else if ev, err := expression.Eval(&v2, e.RootNode()); err != nil {
+					if ev, err := evalExpr(&v2, l, env); err != nil {
 						return err
 					} else {
 						size = ev
@@ -264,26 +435,79 @@ func (r *BinaryReader) ReadInterface(v interface{}) error {
 				}
 			}
 
-			switch f.Type().Kind() {
-			case reflect.String:
+			skipped := false
+			if want != nil && !want[fp.name] {
+				if n := skipSize(fp, size, lengths); n >= 0 {
+					if _, err := r.Seek(int64(n), 1); err != nil {
+						return err
+					}
+					size, skipped = n, true
+				}
+			}
+
+			switch kind := fp.kind; {
+			case skipped:
+			case fp.fieldReader:
+				at := r.Tell()
+				if err := f.Addr().Interface().(FieldReader).ReadField(r, fp.tag); err != nil {
+					return err
+				}
+				size = int(r.Tell() - at)
+			case fp.transform != "":
+				if size, err = r.readTransformed(f, fp.transform, size); err != nil {
+					return err
+				}
+			case fp.uuid != "":
+				if size, err = r.readUUID(f, fp.uuid); err != nil {
+					return err
+				}
+			case fp.packed:
+				if bits, err := fp.bits.eval(&v2, env); err != nil {
+					return err
+				} else if size, err = r.readPacked(f, fp, bits, size); err != nil {
+					return err
+				}
+			case fp.bounded:
+				if fp.size != nil {
+					if size, err = fp.size.eval(&v2, env); err != nil {
+						return err
+					}
+				}
+				if err = r.readBounded(f, size); err != nil {
+					return err
+				}
+			case fp.size != nil:
+				if size, err = fp.size.eval(&v2, env); err != nil {
+					return err
+				} else if err = r.readBigInt(f, size); err != nil {
+					return err
+				}
+			case kind == reflect.String:
 				var data []byte
 				if size >= 0 {
-					if data, err = r.Read(size); err != nil {
+					// The data is copied when converted into a string,
+					// so a shared buffer can be used for reading it.
+					if cap(r.strbuf) < size {
+						r.strbuf = make([]byte, size)
+					}
+					data = r.strbuf[:size]
+					if err = r.readFull(data); err != nil {
 						return err
 					}
-					for i, v := range data {
-						if v == '\u0000' {
-							data = data[:i]
-							break
+					// Unless the `noterm` tag is set, the string is
+					// truncated at the first NUL character.
+					if !fp.noterm {
+						for i, v := range data {
+							if v == '\u0000' {
+								data = data[:i]
+								break
+							}
 						}
 					}
 				} else {
 					var max = math.MaxInt32
-					if m := f2.Tag.Get("max"); m != "" {
-						var e expression.EXPRESSION
-						if !e.Parse(m) {
-							return e.Error()
-						} else if ev, err := expression.Eval(&v2, e.RootNode()); err != nil {
+					if fp.max != nil {
+						if ev, err := fp.max.eval(&v2, env); err != nil {
 							return err
 						} else {
 							max = ev
@@ -302,48 +526,130 @@ func (r *BinaryReader) ReadInterface(v interface{}) error {
 					}
 				}
 				f.SetString(string(data))
-			case reflect.Slice:
+			case kind == reflect.Slice:
 				if size == -1 {
 					return fmt.Errorf("SliceHeader require a known length, %+v", v)
 				}
-				if f.Type().Elem().Kind() == reflect.Int8 {
+				if len(lengths) > 1 {
+					if err := r.readSlices(f, lengths); err != nil {
+						return err
+					}
+				} else if f.Type().Elem().Kind() == reflect.Int8 {
 					if b, err := r.Read(size); err != nil {
 						return err
 					} else {
 						f.Set(reflect.ValueOf(b))
 					}
 				} else {
-					var v3 = reflect.MakeSlice(f.Type(), size, size)
+					var v3 reflect.Value
+					if r.Reuse && f.Cap() >= size {
+						v3 = f.Slice(0, size)
+						zero := reflect.Zero(f.Type().Elem())
+						for i := 0; i < size; i++ {
+							v3.Index(i).Set(zero)
+						}
+					} else {
+						v3 = reflect.MakeSlice(f.Type(), size, size)
+					}
+					if es, ok := fixedSize(f.Type().Elem()); ok {
+						if n := r.parallelism(size, es); n > 1 {
+							if err := r.readParallel(v3, es, n); err != nil {
+								return err
+							}
+							f.Set(v3)
+							break
+						}
+					}
 					for i := 0; i < size; i++ {
+						if fp.elemFast != nil && r.Trace == nil {
+							if err = fp.elemFast(r, v3.Index(i)); err != nil {
+								return err
+							}
+							continue
+						}
+						if r.Trace != nil {
+							r.path = append(r.path[:base+1], "["+strconv.Itoa(i)+"]")
+						}
 						if err = r.ReadInterface(v3.Index(i).Addr().Interface()); err != nil {
 							return err
 						}
 					}
+					if r.Trace != nil {
+						r.path = r.path[:base+1]
+					}
+					f.Set(v3)
+				}
+			case kind == reflect.Interface:
+				if fp.typeof == nil {
+					return fmt.Errorf("Interface field %s requires a typeof tag", fp.name)
+				} else if ev, err := fp.typeof.eval(&v2, env); err != nil {
+					return err
+				} else if t, err := lookupType(ev, f.Type()); err != nil {
+					return err
+				} else {
+					var v3 reflect.Value
+					if t.Kind() == reflect.Ptr {
+						v3 = reflect.New(t.Elem())
+						size = int(t.Elem().Size())
+					} else {
+						v3 = reflect.New(t)
+						size = int(t.Size())
+					}
+					if err := r.ReadInterface(v3.Interface()); err != nil {
+						return err
+					}
+					if t.Kind() != reflect.Ptr {
+						v3 = v3.Elem()
+					}
 					f.Set(v3)
 				}
+			case kind == reflect.Ptr:
+				if size, err = r.readNullable(f, fp.null); err != nil {
+					return err
+				}
 			default:
-				if err := r.ReadInterface(f.Addr().Interface()); err != nil {
+				if n := fp.null; n != "" {
+					if size, err = r.readNullable(f, n); err != nil {
+						return err
+					}
+				} else if fx := fp.fixed; fx != nil {
+					if size, err = r.readFixed(f, fx); err != nil {
+						return err
+					}
+				} else if err := r.ReadInterface(f.Addr().Interface()); err != nil {
 					return err
 				} else {
 					size = int(f.Type().Size())
 				}
 			}
 
-			if al := f2.Tag.Get("align"); al != "" {
+			if sizes != nil {
+				sizes[i] = int(r.Tell() - start)
+			}
+			if !skipped {
+				if fp.zigzag || fp.delta != nil {
+					if err := decodeNumbers(&v2, f, fp, env); err != nil {
+						return err
+					}
+				}
+				if err := validateField(&v2, f, fp, env); err != nil {
+					return err
+				}
+				r.traceField(start, f, fp)
+			}
+
+			if fp.align != nil {
 				var (
-					e     expression.EXPRESSION
 					align int
 					seek  int
 				)
-				if !e.Parse(al) {
-					return e.Error()
-				} else if ev, err := expression.Eval(&v2, e.RootNode()); err != nil {
+				if ev, err := fp.align.eval(&v2, env); err != nil {
 					return err
 				} else {
 					align = ev
 				}
 				if align < size {
-					seek = ((size + (align - 1)) &^ (align - 1)) - size
+					seek = mathutil.AlignUp(size, align) - size
 				} else if align > size {
 					seek = align - size
 				}
@@ -353,6 +659,11 @@ func (r *BinaryReader) ReadInterface(v interface{}) error {
 					}
 				}
 			}
+			if returnTo >= 0 {
+				if _, err := r.Seek(returnTo, 0); err != nil {
+					return err
+				}
+			}
 		}
 	default:
 		return fmt.Errorf("Don't know how to read type %s", v2.Kind())
@@ -367,21 +678,103 @@ func (r *BinaryReader) Seek(offset int64, whence int) (int64, error) {
 	return r.Reader.Seek(offset, whence)
 }
 
+// Returns the current absolute position in the stream, or
+// -1 if it couldn't be determined.
+func (r *BinaryReader) Tell() int64 {
+	if pos, err := r.Seek(0, 1); err != nil {
+		return -1
+	} else {
+		return pos
+	}
+}
+
+// Skips forward to the next position in the stream that is
+// a multiple of n. Nothing is skipped if the current position
+// is already aligned.
+func (r *BinaryReader) Align(n int) error {
+	if n <= 0 {
+		return fmt.Errorf("Invalid alignment: %d", n)
+	}
+	pos, err := r.Seek(0, 1)
+	if err != nil {
+		return err
+	}
+	if rem := pos % int64(n); rem != 0 {
+		_, err = r.Seek(int64(n)-rem, 1)
+	}
+	return err
+}
+
+// Skips forward to the given absolute position in the stream.
+// It is an error to try to skip to a position before the current one.
+func (r *BinaryReader) SkipTo(absoluteOffset int64) error {
+	pos, err := r.Seek(0, 1)
+	if err != nil {
+		return err
+	}
+	if absoluteOffset < pos {
+		return fmt.Errorf("Can't skip backwards from %d to %d", pos, absoluteOffset)
+	}
+	_, err = r.Seek(absoluteOffset, 0)
+	return err
+}
+
+// Reads exactly size bytes from the stream, calling the
+// underlying reader's Read as many times as needed.
+//
+// The error is io.EOF only if no bytes were read, and
+// io.ErrUnexpectedEOF if the stream ended part way through.
 func (r *BinaryReader) Read(size int) ([]byte, error) {
-	data := make([]byte, size)
-	if size == 0 {
-		return data, nil
+	data := r.alloc(size)
+	if err := r.readFull(data); err != nil {
+		r.release(data)
+		return nil, err
 	}
-	if n, err := r.Reader.Read(data); err != nil {
+	return data, nil
+}
+
+// Like Read, but when the read fails the data read before the
+// error occurred is returned together with the error rather than
+// being discarded.
+func (r *BinaryReader) ReadPartial(size int) ([]byte, error) {
+	data := r.alloc(size)
+	n, err := io.ReadFull(r.Reader, data)
+	return data[:n], err
+}
+
+func (r *BinaryReader) alloc(size int) []byte {
+	if r.Buffers != nil {
+		return r.Buffers.Get(size)
+	}
+	return make([]byte, size)
+}
+
+// Returns data to Buffers, if set.
+func (r *BinaryReader) release(data []byte) {
+	if r.Buffers != nil {
+		r.Buffers.Put(data)
+	}
+}
+
+// Reads exactly len(data) bytes into data.
+func (r *BinaryReader) readFull(data []byte) error {
+	_, err := io.ReadFull(r.Reader, data)
+	return err
+}
+
+// Reads size bytes into the reader's internal scratch buffer, which
+// is only valid until the next read. Used for primitives to avoid
+// allocating a new buffer for each of them.
+func (r *BinaryReader) readScratch(size int) ([]byte, error) {
+	data := r.scratch[:size]
+	if err := r.readFull(data); err != nil {
 		return nil, err
-	} else if n != len(data) {
-		return nil, fmt.Errorf("Didn't read the expected number of bytes")
 	}
 	return data, nil
 }
 
 func (r *BinaryReader) Uint64() (uint64, error) {
-	if data, err := r.Read(8); err != nil {
+	if data, err := r.readScratch(8); err != nil {
 		return 0, err
 	} else {
 		return r.Endianess.Uint64(data), nil
@@ -389,7 +782,7 @@ func (r *BinaryReader) Uint64() (uint64, error) {
 }
 
 func (r *BinaryReader) Uint32() (uint32, error) {
-	if data, err := r.Read(4); err != nil {
+	if data, err := r.readScratch(4); err != nil {
 		return 0, err
 	} else {
 		return r.Endianess.Uint32(data), nil
@@ -397,7 +790,7 @@ func (r *BinaryReader) Uint32() (uint32, error) {
 }
 
 func (r *BinaryReader) Uint16() (uint16, error) {
-	if data, err := r.Read(2); err != nil {
+	if data, err := r.readScratch(2); err != nil {
 		return 0, err
 	} else {
 		return r.Endianess.Uint16(data), nil
@@ -405,7 +798,7 @@ func (r *BinaryReader) Uint16() (uint16, error) {
 }
 
 func (r *BinaryReader) Uint8() (uint8, error) {
-	if data, err := r.Read(1); err != nil {
+	if data, err := r.readScratch(1); err != nil {
 		return 0, err
 	} else {
 		return uint8(data[0]), nil
@@ -437,7 +830,7 @@ func (r *BinaryReader) Int16() (int16, error) {
 }
 
 func (r *BinaryReader) Int8() (int8, error) {
-	if data, err := r.Read(1); err != nil {
+	if data, err := r.readScratch(1); err != nil {
 		return 0, err
 	} else {
 		return int8(data[0]), nil
@@ -445,19 +838,17 @@ func (r *BinaryReader) Int8() (int8, error) {
 }
 
 func (r *BinaryReader) Float32() (float32, error) {
-	if i32, err := r.Int32(); err != nil {
+	if u32, err := r.Uint32(); err != nil {
 		return 0, err
 	} else {
-		f32 := *(*float32)(unsafe.Pointer(&i32))
-		return f32, nil
+		return math.Float32frombits(u32), nil
 	}
 }
 
 func (r *BinaryReader) Float64() (float64, error) {
-	if i64, err := r.Int64(); err != nil {
+	if u64, err := r.Uint64(); err != nil {
 		return 0, err
 	} else {
-		f64 := *(*float64)(unsafe.Pointer(&i64))
-		return f64, nil
+		return math.Float64frombits(u64), nil
 	}
 }
@@ -9,6 +9,9 @@ import (
 	sb "encoding/binary"
 	"errors"
 	"fmt"
+	"github.com/quarnster/util"
+	"io"
+	"reflect"
 	"testing"
 )
 
@@ -362,3 +365,265 @@ func TestBinaryReaderValidateablePass(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestBinaryReaderSeekHelpers(t *testing.T) {
+	br := BinaryReader{Reader: bytes.NewReader(make([]byte, 32)), Endianess: sb.LittleEndian}
+	if p := br.Tell(); p != 0 {
+		t.Errorf("Expected 0, but got %d", p)
+	}
+	if err := br.Align(4); err != nil {
+		t.Error(err)
+	} else if p := br.Tell(); p != 0 {
+		t.Errorf("Expected 0, but got %d", p)
+	}
+	if _, err := br.Uint8(); err != nil {
+		t.Fatal(err)
+	}
+	if err := br.Align(4); err != nil {
+		t.Error(err)
+	} else if p := br.Tell(); p != 4 {
+		t.Errorf("Expected 4, but got %d", p)
+	}
+	if err := br.Align(0); err == nil {
+		t.Error("Expected an error, but didn't get one")
+	}
+	if err := br.SkipTo(10); err != nil {
+		t.Error(err)
+	} else if p := br.Tell(); p != 10 {
+		t.Errorf("Expected 10, but got %d", p)
+	}
+	if err := br.Align(8); err != nil {
+		t.Error(err)
+	} else if p := br.Tell(); p != 16 {
+		t.Errorf("Expected 16, but got %d", p)
+	}
+	if err := br.SkipTo(8); err == nil {
+		t.Error("Expected an error, but didn't get one")
+	}
+}
+
+func TestBinaryReaderReuse(t *testing.T) {
+	type Elem struct {
+		Flag uint8
+		Opt  uint8 `if:"Flag == 1"`
+	}
+	type Test struct {
+		Count uint8
+		Elems []Elem `length:"Count"`
+	}
+	data := []byte{3, 1, 10, 0, 1, 20}
+	for _, reuse := range []bool{false, true} {
+		t2 := Test{Elems: []Elem{{9, 9}, {9, 9}, {9, 9}, {9, 9}}}
+		orig := &t2.Elems[0]
+		br := BinaryReader{Reader: bytes.NewReader(data), Endianess: sb.LittleEndian, Reuse: reuse}
+		if err := br.ReadInterface(&t2); err != nil {
+			t.Fatal(err)
+		}
+		exp := []Elem{{1, 10}, {0, 0}, {1, 20}}
+		if !reflect.DeepEqual(t2.Elems, exp) {
+			t.Errorf("%v: Expected %v, but got %v", reuse, exp, t2.Elems)
+		}
+		if reused := &t2.Elems[0] == orig; reused != reuse {
+			t.Errorf("Expected reuse to be %v, but it was %v", reuse, reused)
+		}
+	}
+}
+
+func TestBinaryReaderPrimitiveAllocs(t *testing.T) {
+	var (
+		data = make([]byte, 8)
+		rd   = bytes.NewReader(data)
+		br   = BinaryReader{Reader: rd, Endianess: sb.LittleEndian}
+	)
+	allocs := testing.AllocsPerRun(100, func() {
+		rd.Seek(0, 0)
+		br.Uint64()
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocations, but got %v", allocs)
+	}
+}
+
+// Returns at most one byte per Read call, like a slow pipe might.
+type trickleReader struct {
+	*bytes.Reader
+}
+
+func (t trickleReader) Read(p []byte) (int, error) {
+	if len(p) > 1 {
+		p = p[:1]
+	}
+	return t.Reader.Read(p)
+}
+
+func TestBinaryReaderShortReads(t *testing.T) {
+	br := BinaryReader{Reader: trickleReader{bytes.NewReader([]byte{1, 2, 3, 4, 5, 6})}, Endianess: sb.LittleEndian}
+	if v, err := br.Uint32(); err != nil {
+		t.Error(err)
+	} else if v != 0x04030201 {
+		t.Errorf("Expected 0x04030201, but got %x", v)
+	}
+	if _, err := br.Uint32(); err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected %v, but got %v", io.ErrUnexpectedEOF, err)
+	}
+	if _, err := br.Uint32(); err != io.EOF {
+		t.Errorf("Expected %v, but got %v", io.EOF, err)
+	}
+}
+
+func TestBinaryReaderReadPartial(t *testing.T) {
+	br := BinaryReader{Reader: bytes.NewReader([]byte{1, 2, 3}), Endianess: sb.LittleEndian}
+	if d, err := br.ReadPartial(5); err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected %v, but got %v", io.ErrUnexpectedEOF, err)
+	} else if !bytes.Equal(d, []byte{1, 2, 3}) {
+		t.Errorf("Unexpected data: %v", d)
+	}
+}
+
+func TestBinaryReaderStringNoTerm(t *testing.T) {
+	type Test struct {
+		A string `length:"uint16"`
+		B string `length:"uint16" noterm:"true"`
+	}
+	var (
+		t2   Test
+		str  = "Hello\u0000World"
+		data = []byte{byte(len(str)), 0}
+	)
+	data = append(data, str...)
+	data = append(data, byte(len(str)), 0)
+	data = append(data, str...)
+	br := BinaryReader{Reader: bytes.NewReader(data), Endianess: sb.LittleEndian}
+	if err := br.ReadInterface(&t2); err != nil {
+		t.Fatal(err)
+	}
+	if t2.A != "Hello" {
+		t.Errorf("Expected %q, but got %q", "Hello", t2.A)
+	}
+	if t2.B != str {
+		t.Errorf("Expected %q, but got %q", str, t2.B)
+	}
+}
+
+func TestBinaryReaderBuffers(t *testing.T) {
+	var (
+		pool util.BytePool
+		rd   = bytes.NewReader(make([]byte, 64))
+		br   = BinaryReader{Reader: rd, Endianess: sb.LittleEndian, Buffers: &pool}
+	)
+	for i := 0; i < 4; i++ {
+		rd.Seek(0, 0)
+		if b, err := br.Read(48); err != nil {
+			t.Fatal(err)
+		} else if len(b) != 48 {
+			t.Errorf("Expected 48 bytes, but got %d", len(b))
+		} else {
+			pool.Put(b)
+		}
+	}
+	if s := pool.Stats(); s.Live != 0 || s.Misses+s.Hits != 4 {
+		t.Errorf("Unexpected pool stats %+v", s)
+	}
+	if _, err := br.Read(100); err == nil {
+		t.Error("Expected an error")
+	} else if s := pool.Stats(); s.Live != 0 {
+		t.Errorf("Expected the buffer of a failed read to be released, but %d are live", s.Live)
+	}
+}
+
+type (
+	commonHeader struct {
+		Version    uint8
+		HeaderSize uint8
+	}
+	ExtendedHeader struct {
+		// Refers to a field of the embedding struct
+		Extra uint16 `if:"Kind == 2"`
+	}
+)
+
+func TestBinaryReaderEmbedded(t *testing.T) {
+	type Test struct {
+		commonHeader
+		Kind uint8
+		*ExtendedHeader
+		Data []byte `length:"HeaderSize"`
+		Tail uint8  `if:"Version > 1 && Extra != 0"`
+	}
+	var (
+		data = []byte{2, 3, 2, 0x34, 0x12, 'a', 'b', 'c', 9}
+		br   = BinaryReader{Reader: bytes.NewReader(data), Endianess: sb.LittleEndian}
+		v    Test
+	)
+	if err := br.ReadInterface(&v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Version != 2 || v.HeaderSize != 3 || v.Kind != 2 || v.Extra != 0x1234 || string(v.Data) != "abc" || v.Tail != 9 {
+		t.Errorf("Unexpected result: %+v %+v", v, v.ExtendedHeader)
+	}
+}
+
+func TestBinaryReaderLastElement(t *testing.T) {
+	type Test struct {
+		Count   uint8
+		Records []struct {
+			Offset uint8
+		} `length:"Count"`
+		Data []byte `length:"last(Records).Offset - first(Records).Offset"`
+	}
+	var (
+		data = []byte{3, 2, 4, 7, 'a', 'b', 'c', 'd', 'e'}
+		br   = BinaryReader{Reader: bytes.NewReader(data), Endianess: sb.LittleEndian}
+		v    Test
+	)
+	if err := br.ReadInterface(&v); err != nil {
+		t.Fatal(err)
+	}
+	if len(v.Records) != 3 || string(v.Data) != "abcde" {
+		t.Errorf("Unexpected result: %+v", v)
+	}
+}
+
+func TestBinaryReaderVars(t *testing.T) {
+	type Block struct {
+		Kind uint8
+		Data []byte `length:"BlockSize - 1"`
+	}
+	var (
+		b  Block
+		br = BinaryReader{
+			Reader:    bytes.NewReader([]byte{1, 2, 3, 4, 5}),
+			Endianess: sb.LittleEndian,
+			Vars:      map[string]int{"BlockSize": 4},
+		}
+	)
+	if err := br.ReadInterface(&b); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(b.Data, []byte{2, 3, 4}) {
+		t.Errorf("Expected 3 bytes of data, got %v", b.Data)
+	}
+	br.Vars = nil
+	br.Seek(0, 0)
+	if err := br.ReadInterface(&b); err == nil {
+		t.Error("Expected an error without the BlockSize variable")
+	}
+}
+
+func TestBinaryReaderFloats(t *testing.T) {
+	tests := []struct {
+		order sb.ByteOrder
+		data  []byte
+	}{
+		{LittleEndian, []byte{0x00, 0x00, 0xc0, 0x3f, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x04, 0xc0}},
+		{BigEndian, []byte{0x3f, 0xc0, 0x00, 0x00, 0xc0, 0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+	}
+	for _, test := range tests {
+		br := BinaryReader{Reader: bytes.NewReader(test.data), Endianess: test.order}
+		if f, err := br.Float32(); err != nil || f != 1.5 {
+			t.Errorf("%s: Expected 1.5, but got %v, %v", test.order, f, err)
+		}
+		if f, err := br.Float64(); err != nil || f != -2.5 {
+			t.Errorf("%s: Expected -2.5, but got %v, %v", test.order, f, err)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A range of bytes [Start, End) of a stream.
+type ByteRange struct {
+	Start, End int64
+}
+
+func (b ByteRange) overlaps(fs FieldSize) bool {
+	return b.Start < fs.Offset+fs.Size && fs.Offset < b.End
+}
+
+// Returns the value at the given path, such as "Entries[3].Header",
+// of the struct v, together with the plan of the field the path
+// ends with and the struct that field is in.
+func valueAt(v reflect.Value, path string) (f reflect.Value, fp *fieldPlan, parent reflect.Value, err error) {
+	for _, part := range strings.Split(path, ".") {
+		name, index, _ := strings.Cut(part, "[")
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return f, nil, parent, fmt.Errorf("Nil value on the way to %s", path)
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return f, nil, parent, fmt.Errorf("%s isn't a struct on the way to %s", v.Type(), path)
+		}
+		if fp = getPlan(v.Type()).lookup(name); fp == nil {
+			return f, nil, parent, fmt.Errorf("No field by name %s in struct %s", name, v.Type())
+		}
+		parent, v = v, fp.field(v)
+		if index == "" {
+			continue
+		}
+		for _, ix := range strings.Split(strings.TrimSuffix(index, "]"), "][") {
+			i, err := strconv.Atoi(ix)
+			if err != nil {
+				return f, nil, parent, err
+			}
+			for v.Kind() == reflect.Ptr && !v.IsNil() {
+				v = v.Elem()
+			}
+			if (v.Kind() != reflect.Slice && v.Kind() != reflect.Array) || i < 0 || i >= v.Len() {
+				return f, nil, parent, fmt.Errorf("Invalid index %d on the way to %s", i, path)
+			}
+			v = v.Index(i)
+		}
+	}
+	return v, fp, parent, nil
+}
+
+// Returns whether the struct field at the given path can be read
+// again on its own, which is the case when it's a plain struct with
+// nothing outside of it depending on its values.
+func redecodable(root reflect.Value, path string) bool {
+	f, fp, parent, err := valueAt(root, path)
+	switch {
+	case err != nil, !f.CanAddr(), fp.referenced:
+		return false
+	case fp.kind != reflect.Struct, fp.bounded, fp.transform != "", fp.null != "", fp.size != nil:
+		return false
+	case reflect.PtrTo(parent.Type()).Implements(validateableType):
+		// The parent would have to be validated again
+		return false
+	}
+	return true
+}
+
+// Returns whether path is a path within the field at the given path.
+func within(path, field string) bool {
+	return strings.HasPrefix(path, field) && len(path) > len(field) && (path[len(field)] == '.' || path[len(field)] == '[')
+}
+
+// Returns the path of the struct or slice the field at path is in,
+// or "" for fields of the root struct.
+func parentPath(path string) string {
+	if i := strings.LastIndexAny(path, ".["); i != -1 {
+		return path[:i]
+	}
+	return ""
+}
+
+// Decodes the struct v again after the bytes in the changed ranges
+// of the stream have been modified, such as when a file being
+// inspected is edited, and returns the updated SizeReport.
+//
+// The report must be the one ReadSizeReport returned when v was
+// read from the same stream, or that a previous Redecode returned.
+// Rather than reading all of v again, only the innermost structs
+// containing the changed bytes are, provided that their sizes stay
+// the same and nothing outside of them depends on their values
+// through the tags of other fields. Otherwise the struct containing
+// them is read in their place, all the way up to v itself, in which
+// case r must be positioned where v starts, as when it was first
+// read. The position of r afterwards is unspecified.
+//
+// Changes to bytes that weren't part of any field read, such as
+// padding or skipped data, are ignored.
+func Redecode(r *BinaryReader, v interface{}, report SizeReport, changed []ByteRange) (SizeReport, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("Expected a pointer to a struct, not %T", v)
+	}
+	start, err := r.Seek(0, 1)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		root    = rv.Elem()
+		extents = make(map[string]FieldSize, len(report))
+		dirty   = make(map[string]bool)
+	)
+	for _, fs := range report {
+		extents[fs.Path] = fs
+		for _, c := range changed {
+			if c.overlaps(fs) {
+				dirty[fs.Path] = true
+				break
+			}
+		}
+	}
+	// The innermost redecodable structs containing the changes,
+	// excluding those within any other one
+	var roots []string
+	for path := range dirty {
+		for path != "" {
+			if _, ok := extents[path]; ok && redecodable(root, path) {
+				break
+			}
+			path = parentPath(path)
+		}
+		roots = append(roots, path)
+	}
+	sort.Strings(roots)
+	for i := 0; i < len(roots); i++ {
+		if i > 0 && (roots[i] == roots[i-1] || within(roots[i], roots[i-1])) {
+			roots = append(roots[:i], roots[i+1:]...)
+			i--
+		}
+	}
+
+	for len(roots) > 0 && roots[0] != "" {
+		path := roots[0]
+		roots = roots[1:]
+		sub, err := r.redecodeField(root, path, extents[path])
+		if err != nil {
+			return nil, err
+		} else if sub == nil {
+			// Read the struct containing it instead
+			for path = parentPath(path); path != ""; path = parentPath(path) {
+				if _, ok := extents[path]; ok && redecodable(root, path) {
+					break
+				}
+			}
+			roots = append([]string{path}, roots...)
+			continue
+		}
+		ret := make(SizeReport, 0, len(report))
+		for _, fs := range report {
+			if !within(fs.Path, path) {
+				ret = append(ret, fs)
+			}
+		}
+		report = append(ret, sub...)
+		report.sort()
+		for _, fs := range sub {
+			extents[fs.Path] = fs
+		}
+	}
+	if len(roots) > 0 {
+		if _, err := r.Seek(start, 0); err != nil {
+			return nil, err
+		}
+		return ReadSizeReport(r, v)
+	}
+	return report, nil
+}
+
+// Reads the struct field at the given path of root again, returning
+// the sizes of the fields within it. Returns a nil report if the size
+// of the struct changed, in which case the data following it has
+// moved and the struct containing it needs to be read again.
+func (r *BinaryReader) redecodeField(root reflect.Value, path string, extent FieldSize) (SizeReport, error) {
+	f, _, _, err := valueAt(root, path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.Seek(extent.Offset, 0); err != nil {
+		return nil, err
+	}
+	var (
+		sub   = SizeReport{}
+		trace = r.Trace
+	)
+	r.Trace = slog.New(sizeHandler{&sub})
+	r.path = strings.Split(path, ".")
+	err = r.ReadInterface(f.Addr().Interface())
+	r.Trace, r.path = trace, nil
+	if err != nil {
+		return nil, err
+	} else if r.Tell()-extent.Offset != extent.Size {
+		return nil, nil
+	}
+	return sub, nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	sb "encoding/binary"
+	"testing"
+)
+
+func TestRedecode(t *testing.T) {
+	type (
+		Header struct {
+			Version uint8
+			Flags   uint8
+		}
+		Entry struct {
+			Name  string
+			Value uint16
+		}
+		File struct {
+			Header  Header
+			Count   uint8
+			Entries []Entry `length:"Count"`
+			Tail    uint8
+		}
+	)
+	data := []byte{1, 2, 2, 'a', 0, 5, 0, 'b', 0, 6, 0, 0xff}
+	var (
+		f  File
+		br = BinaryReader{Reader: bytes.NewReader(data), Endianess: sb.LittleEndian}
+	)
+	report, err := ReadSizeReport(&br, &f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	redecode := func(changed ...ByteRange) {
+		t.Helper()
+		br.Seek(0, 0)
+		if report, err = Redecode(&br, &f, report, changed); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Only the header is read again
+	f.Tail = 0
+	data[1] = 3
+	redecode(ByteRange{1, 2})
+	if f.Header.Flags != 3 || f.Tail != 0 {
+		t.Errorf("Expected only the header to be read again, got %+v", f)
+	}
+
+	// Changes outside of any field are ignored
+	redecode(ByteRange{100, 101})
+	if f.Tail != 0 {
+		t.Errorf("Didn't expect anything to be read again, got %+v", f)
+	}
+
+	// The entries are only in a slice, so everything is read again
+	data[5] = 7
+	redecode(ByteRange{5, 6})
+	if f.Entries[0].Value != 7 || f.Tail != 0xff {
+		t.Errorf("Expected everything to be read again, got %+v", f)
+	}
+
+	var exp File
+	br.Seek(0, 0)
+	expReport, err := ReadSizeReport(&br, &exp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.String() != expReport.String() {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expReport, report)
+	}
+}
+
+func TestRedecodeResized(t *testing.T) {
+	type (
+		Name struct {
+			Text string
+		}
+		File struct {
+			Name Name
+			Tail uint8
+		}
+	)
+	data := []byte{'a', 'b', 0, 0, 7}
+	var (
+		f  File
+		br = BinaryReader{Reader: bytes.NewReader(data), Endianess: sb.LittleEndian}
+	)
+	report, err := ReadSizeReport(&br, &f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Tail = 99
+	data[1] = 'c'
+	br.Seek(0, 0)
+	if report, err = Redecode(&br, &f, report, []ByteRange{{1, 2}}); err != nil {
+		t.Fatal(err)
+	} else if f.Name.Text != "ac" || f.Tail != 99 {
+		t.Errorf("Expected only the name to be read again, got %+v", f)
+	}
+
+	// The name grows, which moves the tail
+	data[2] = 'd'
+	br.Seek(0, 0)
+	if report, err = Redecode(&br, &f, report, []ByteRange{{2, 3}}); err != nil {
+		t.Fatal(err)
+	} else if f.Name.Text != "acd" || f.Tail != 7 {
+		t.Errorf("Expected everything to be read again, got %+v", f)
+	}
+	if fs, ok := report.Lookup("Tail"); !ok || fs.Offset != 4 {
+		t.Errorf("Unexpected extent of Tail: %+v", fs)
+	}
+}
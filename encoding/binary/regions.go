@@ -0,0 +1,58 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"github.com/limetext/text"
+	"sort"
+)
+
+// Returns the bytes of the field as a text.Region, so that views
+// built on the text package, such as a hex editor, can highlight it.
+func (fs FieldSize) Region() text.Region {
+	return text.Region{A: int(fs.Offset), B: int(fs.Offset + fs.Size)}
+}
+
+// Returns the fields whose bytes include the given offset, outermost
+// first, which is what to select when a hex editor's cursor is moved
+// to the offset. Fields that took up no bytes are never included.
+func (s SizeReport) At(offset int64) SizeReport {
+	var ret SizeReport
+	for _, fs := range s {
+		if fs.Offset > offset {
+			// The report is ordered by offset
+			break
+		} else if offset < fs.Offset+fs.Size {
+			ret = append(ret, fs)
+		}
+	}
+	return ret
+}
+
+// Returns the regions of the fields at the given paths, ordered and
+// with any overlapping or adjacent regions merged, as a text.RegionSet
+// would have them. Paths not in the report are ignored.
+func (s SizeReport) Regions(paths ...string) []text.Region {
+	var ret []text.Region
+	for _, p := range paths {
+		if fs, ok := s.Lookup(p); ok && fs.Size > 0 {
+			ret = append(ret, fs.Region())
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].A < ret[j].A
+	})
+	merged := ret[:0]
+	for _, r := range ret {
+		if l := len(merged) - 1; l >= 0 && r.A <= merged[l].B {
+			if r.B > merged[l].B {
+				merged[l].B = r.B
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
@@ -0,0 +1,55 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"github.com/limetext/text"
+	"reflect"
+	"testing"
+)
+
+func TestSizeReportRegions(t *testing.T) {
+	report := SizeReport{
+		{"Header", 0, 6},
+		{"Header.Magic", 0, 4},
+		{"Header.Count", 4, 2},
+		{"Name", 6, 3},
+		{"Entries", 10, 8},
+	}
+	at := []struct {
+		offset int64
+		exp    []string
+	}{
+		{0, []string{"Header", "Header.Magic"}},
+		{5, []string{"Header", "Header.Count"}},
+		{9, nil},
+		{17, []string{"Entries"}},
+		{18, nil},
+	}
+	for _, test := range at {
+		var paths []string
+		for _, fs := range report.At(test.offset) {
+			paths = append(paths, fs.Path)
+		}
+		if !reflect.DeepEqual(paths, test.exp) {
+			t.Errorf("At(%d): Expected %v, but got %v", test.offset, test.exp, paths)
+		}
+	}
+
+	regions := []struct {
+		paths []string
+		exp   []text.Region
+	}{
+		{[]string{"Name"}, []text.Region{{A: 6, B: 9}}},
+		{[]string{"Entries", "Header.Magic"}, []text.Region{{A: 0, B: 4}, {A: 10, B: 18}}},
+		{[]string{"Name", "Header.Count", "Header"}, []text.Region{{A: 0, B: 9}}},
+		{[]string{"Missing"}, nil},
+	}
+	for _, test := range regions {
+		if r := report.Regions(test.paths...); !reflect.DeepEqual(r, test.exp) && len(r)+len(test.exp) > 0 {
+			t.Errorf("Regions(%v): Expected %v, but got %v", test.paths, test.exp, r)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+var (
+	typeRegistryLock sync.RWMutex
+	typeRegistry     = make(map[int][]reflect.Type)
+)
+
+// Registers a concrete type to be used for interface typed struct
+// fields having a `typeof` tag which evaluates to the given discriminator.
+//
+// For example given the following definitions:
+//
+//	type Chunk interface{}
+//	type Header struct {
+//		Type uint8
+//		Data Chunk `typeof:"Type"`
+//	}
+//	func init() {
+//		RegisterType(1, reflect.TypeOf(TextChunk{}))
+//		RegisterType(2, reflect.TypeOf(ImageChunk{}))
+//	}
+//
+// the Data field is populated with a TextChunk when Type is 1 and
+// with an ImageChunk when Type is 2.
+//
+// The same discriminator may be registered multiple times with
+// different types as long as they are used for different field
+// interface types, in which case the first registered type
+// implementing the field's interface type is used.
+func RegisterType(discriminator int, t reflect.Type) {
+	typeRegistryLock.Lock()
+	defer typeRegistryLock.Unlock()
+	typeRegistry[discriminator] = append(typeRegistry[discriminator], t)
+}
+
+func lookupType(discriminator int, iface reflect.Type) (reflect.Type, error) {
+	typeRegistryLock.RLock()
+	defer typeRegistryLock.RUnlock()
+	for _, t := range typeRegistry[discriminator] {
+		if t.Implements(iface) {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("No type implementing %s registered for discriminator %d", iface, discriminator)
+}
+
+// A Transform converts a block of raw data, such as by decrypting
+// or decompressing it, before it is decoded.
+type Transform func(data []byte) ([]byte, error)
+
+var (
+	transformRegistryLock sync.RWMutex
+	transformRegistry     = make(map[string]Transform)
+)
+
+// Registers a Transform to be used for fields with a `transform`
+// tag naming it. Such fields must also have a `length` tag, which
+// gives the size in bytes of the raw data block to pass to the
+// Transform. The field is then decoded from the transformed data:
+//
+//	func init() {
+//		RegisterTransform("xor", func(data []byte) ([]byte, error) {
+//			for i := range data {
+//				data[i] ^= 0x5a
+//			}
+//			return data, nil
+//		})
+//	}
+//	type File struct {
+//		Size    uint32
+//		Payload Payload `length:"Size" transform:"xor"`
+//	}
+//
+// Registering a Transform under an already registered name
+// replaces the previous one.
+func RegisterTransform(name string, t Transform) {
+	transformRegistryLock.Lock()
+	defer transformRegistryLock.Unlock()
+	transformRegistry[name] = t
+}
+
+func lookupTransform(name string) (Transform, error) {
+	transformRegistryLock.RLock()
+	defer transformRegistryLock.RUnlock()
+	if t, ok := transformRegistry[name]; ok {
+		return t, nil
+	}
+	return nil, fmt.Errorf("No transform registered by name %s", name)
+}
+
+var (
+	formatRegistryLock sync.RWMutex
+	formatRegistry     = make(map[string]reflect.Type)
+)
+
+// Registers the root type of a file format under the given name,
+// making it available to tools such as cmd/bindump:
+//
+//	func init() {
+//		RegisterFormat("png", reflect.TypeOf(PNGFile{}))
+//	}
+//
+// Registering a format under an already registered name
+// replaces the previous one.
+func RegisterFormat(name string, t reflect.Type) {
+	formatRegistryLock.Lock()
+	defer formatRegistryLock.Unlock()
+	formatRegistry[name] = t
+}
+
+// Returns the root type of the format registered by the given name.
+func LookupFormat(name string) (reflect.Type, error) {
+	formatRegistryLock.RLock()
+	defer formatRegistryLock.RUnlock()
+	if t, ok := formatRegistry[name]; ok {
+		return t, nil
+	}
+	return nil, fmt.Errorf("No format registered by name %s", name)
+}
+
+// Returns the sorted names of all registered formats.
+func Formats() []string {
+	formatRegistryLock.RLock()
+	defer formatRegistryLock.RUnlock()
+	ret := make([]string, 0, len(formatRegistry))
+	for name := range formatRegistry {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+var (
+	enumRegistryLock sync.RWMutex
+	enumRegistry     = make(map[string]map[int64]string)
+)
+
+// Registers the symbolic names of the values of an enumeration, to
+// be used for integer fields with an `enum` tag naming it:
+//
+//	func init() {
+//		RegisterEnum("ColorType", map[int64]string{
+//			0: "Grayscale",
+//			2: "RGB",
+//			3: "Palette",
+//		})
+//	}
+//	type Header struct {
+//		Color uint8 `enum:"ColorType,strict"`
+//	}
+//
+// Such fields are shown by name in traces and in the output of
+// DecodeToMap. With the strict option, reading a value that isn't
+// one of the registered ones is an error.
+//
+// Registering an enumeration under an already registered name
+// replaces the previous one.
+func RegisterEnum(name string, names map[int64]string) {
+	m := make(map[int64]string, len(names))
+	for k, v := range names {
+		m[k] = v
+	}
+	enumRegistryLock.Lock()
+	defer enumRegistryLock.Unlock()
+	enumRegistry[name] = m
+}
+
+// Returns the name of the given value of the enumeration registered
+// by the given name, or false if either of them isn't registered.
+func EnumName(enum string, value int64) (string, bool) {
+	enumRegistryLock.RLock()
+	defer enumRegistryLock.RUnlock()
+	name, ok := enumRegistry[enum][value]
+	return name, ok
+}
+
+func lookupEnum(name string) (map[int64]string, error) {
+	enumRegistryLock.RLock()
+	defer enumRegistryLock.RUnlock()
+	if m, ok := enumRegistry[name]; ok {
+		return m, nil
+	}
+	return nil, fmt.Errorf("No enum registered by name %s", name)
+}
@@ -0,0 +1,89 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+type (
+	registryChunk interface {
+		Kind() string
+	}
+	registryText struct {
+		Length uint8
+		Text   string `length:"Length"`
+	}
+	registryPoint struct {
+		X, Y uint16
+	}
+)
+
+func (registryText) Kind() string   { return "text" }
+func (*registryPoint) Kind() string { return "point" }
+
+func init() {
+	RegisterType(1, reflect.TypeOf(registryText{}))
+	RegisterType(2, reflect.TypeOf(&registryPoint{}))
+}
+
+func TestBinaryReaderTypeof(t *testing.T) {
+	type Test struct {
+		Type  uint8
+		Chunk registryChunk `typeof:"Type"`
+		End   uint8
+	}
+	tests := []struct {
+		data []byte
+		exp  registryChunk
+	}{
+		{[]byte{1, 5, 'h', 'e', 'l', 'l', 'o', 0xff}, registryText{5, "hello"}},
+		{[]byte{2, 1, 0, 2, 0, 0xff}, &registryPoint{1, 2}},
+	}
+	for i, test := range tests {
+		var t2 Test
+		br := BinaryReader{Reader: bytes.NewReader(test.data), Endianess: LittleEndian}
+		if err := br.ReadInterface(&t2); err != nil {
+			t.Errorf("%d: %s", i, err)
+		} else if !reflect.DeepEqual(t2.Chunk, test.exp) {
+			t.Errorf("%d: Expected %#v, but got %#v", i, test.exp, t2.Chunk)
+		} else if t2.End != 0xff {
+			t.Errorf("%d: Expected 0xff, but got %x", i, t2.End)
+		}
+	}
+}
+
+func TestBinaryReaderTypeofUnregistered(t *testing.T) {
+	type Test struct {
+		Type  uint8
+		Chunk registryChunk `typeof:"Type"`
+	}
+	var t2 Test
+	br := BinaryReader{Reader: bytes.NewReader([]byte{3, 0, 0}), Endianess: LittleEndian}
+	if err := br.ReadInterface(&t2); err == nil {
+		t.Error("Expected an error, but didn't get one")
+	}
+}
+
+func TestRegisterFormat(t *testing.T) {
+	RegisterFormat("registry-point", reflect.TypeOf(registryPoint{}))
+	if typ, err := LookupFormat("registry-point"); err != nil {
+		t.Error(err)
+	} else if typ != reflect.TypeOf(registryPoint{}) {
+		t.Errorf("Unexpected type: %s", typ)
+	}
+	if _, err := LookupFormat("registry-missing"); err == nil {
+		t.Error("Expected an error for an unregistered format")
+	}
+	found := false
+	for _, name := range Formats() {
+		found = found || name == "registry-point"
+	}
+	if !found {
+		t.Errorf("registry-point missing from %v", Formats())
+	}
+}
@@ -0,0 +1,297 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	sb "encoding/binary"
+	"fmt"
+	"github.com/quarnster/util/mathutil"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// The output formats of WriteLayoutReport.
+type LayoutFormat int
+
+const (
+	// Plain text with aligned columns
+	LayoutText LayoutFormat = iota
+	// A Markdown table
+	LayoutMarkdown
+)
+
+type (
+	// A size or offset, made up of a constant number of bytes plus
+	// any number of terms which are only known when reading.
+	layoutExpr struct {
+		n     int
+		terms []string
+	}
+
+	layoutRow struct {
+		path, typ, offset, size, endian, tags string
+	}
+)
+
+func (e layoutExpr) static() bool {
+	return len(e.terms) == 0
+}
+
+func (e layoutExpr) String() string {
+	var parts []string
+	if e.n != 0 || len(e.terms) == 0 {
+		parts = append(parts, strconv.Itoa(e.n))
+	}
+	return strings.Join(append(parts, e.terms...), " + ")
+}
+
+func (e *layoutExpr) add(o layoutExpr) {
+	e.n += o.n
+	e.terms = append(e.terms, o.terms...)
+}
+
+// Wraps expr in parentheses unless it's a single identifier
+// or constant.
+func paren(expr string) string {
+	if strings.ContainsAny(expr, " +-*/%&|^<>=!()") {
+		return "(" + expr + ")"
+	}
+	return expr
+}
+
+func dynamic(term string) layoutExpr {
+	return layoutExpr{terms: []string{term}}
+}
+
+// Returns the size of values of type t when they have no tags.
+func typeSize(t reflect.Type, path string) layoutExpr {
+	switch t.Kind() {
+	case reflect.Bool, reflect.Uint8, reflect.Int8:
+		return layoutExpr{n: 1}
+	case reflect.Uint16, reflect.Int16:
+		return layoutExpr{n: 2}
+	case reflect.Uint32, reflect.Int32, reflect.Float32:
+		return layoutExpr{n: 4}
+	case reflect.Uint, reflect.Int, reflect.Uint64, reflect.Int64, reflect.Float64:
+		return layoutExpr{n: 8}
+	case reflect.Array:
+		if es := typeSize(t.Elem(), path); es.static() {
+			return layoutExpr{n: es.n * t.Len()}
+		}
+	case reflect.Struct:
+		if t == uint128Type {
+			return layoutExpr{n: 16}
+		} else if readsItself(t) {
+			break
+		}
+		var size layoutExpr
+		for i := 0; i < t.NumField(); i++ {
+			f2 := t.Field(i)
+			if isOptions(f2) && f2.Tag.Get("align") == "" {
+				// Only the byte order is changed
+				continue
+			} else if f2.Tag != "" {
+				return dynamic("sizeof(" + path + ")")
+			}
+			fs := typeSize(f2.Type, path+"."+f2.Name)
+			if !fs.static() {
+				return dynamic("sizeof(" + path + ")")
+			}
+			size.add(fs)
+		}
+		return size
+	case reflect.String:
+		return dynamic("strlen(" + path + ") + 1")
+	}
+	return dynamic("sizeof(" + path + ")")
+}
+
+// Returns the size of the struct field f2 given its tags.
+func fieldSize(f2 reflect.StructField, path string) layoutExpr {
+	var (
+		t = f2.Type
+		l = f2.Tag.Get("length")
+		// The size of each of the elements the length counts
+		elem = layoutExpr{n: 1}
+	)
+	if t.Kind() == reflect.Slice {
+		elem = typeSize(t.Elem(), path+"[i]")
+	}
+	times := func(count string) layoutExpr {
+		switch {
+		case !elem.static():
+			return dynamic("sizeof(" + path + ")")
+		case elem.n == 1:
+			return dynamic(count)
+		}
+		return dynamic(count + " * " + strconv.Itoa(elem.n))
+	}
+	switch {
+	case f2.Tag.Get("transform") != "":
+		return dynamic(paren(l))
+	case f2.Tag.Get("fixed") != "":
+		if fx := parseFixed(f2.Tag.Get("fixed")); fx.err == nil {
+			return layoutExpr{n: fx.bits() / 8}
+		}
+	case t.Kind() == reflect.Interface:
+		return dynamic("sizeof(typeof(" + f2.Tag.Get("typeof") + "))")
+	case t.Kind() == reflect.Ptr:
+		return typeSize(t.Elem(), path)
+	case isNullStruct(t):
+		return typeSize(t.Field(0).Type, path)
+	case strings.Contains(l, ","):
+		return dynamic("sizeof(" + path + ")")
+	case l == "uint8" || l == "uint16" || l == "uint32" || l == "uint64":
+		bits, _ := strconv.Atoi(l[4:])
+		e := times("len(" + path + ")")
+		e.n += bits / 8
+		return e
+	case l != "":
+		if n, err := strconv.Atoi(l); err == nil && elem.static() {
+			return layoutExpr{n: n * elem.n}
+		}
+		return times(paren(l))
+	}
+	return typeSize(t, path)
+}
+
+// Returns "le" or "be" for multi-byte numeric types, and "" otherwise.
+func endianOf(t reflect.Type, order sb.ByteOrder) string {
+	for t.Kind() == reflect.Array || t.Kind() == reflect.Slice || t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.Uint8, reflect.Int8, reflect.String, reflect.Struct, reflect.Interface:
+		return ""
+	}
+	if isLittleEndian(order) {
+		return "le"
+	}
+	return "be"
+}
+
+// Appends the rows of the fields of the struct type t, which starts
+// at offset, returning the offset after the struct.
+func layoutRows(rows []layoutRow, t reflect.Type, prefix string, offset layoutExpr, order sb.ByteOrder) ([]layoutRow, layoutExpr) {
+	opts := optionsTag(t)
+	if e, err := parseEndian(opts.Get("endian")); err == nil && e != nil {
+		order = e
+	}
+	for i := 0; i < t.NumField(); i++ {
+		var (
+			f2   = t.Field(i)
+			path = prefix + f2.Name
+			at   = offset
+			size layoutExpr
+		)
+		if isOptions(f2) {
+			continue
+		}
+		if s := f2.Tag.Get("skip"); s != "" {
+			if n, err := strconv.Atoi(s); err == nil {
+				at.n += n
+			} else {
+				at.terms = append(at.terms, paren(s))
+			}
+		}
+		if o := f2.Tag.Get("offset"); o != "" {
+			at = dynamic("@(" + o + ")")
+			if rel := f2.Tag.Get("relative"); rel != "" {
+				at.terms = append(at.terms, "relative to "+rel)
+			}
+		}
+		if b := f2.Tag.Get("bits"); b != "" {
+			size = dynamic(b + " bits")
+		} else {
+			size = fieldSize(f2, path)
+		}
+		rows = append(rows, layoutRow{
+			path:   path,
+			typ:    f2.Type.String(),
+			offset: at.String(),
+			size:   size.String(),
+			endian: endianOf(f2.Type, order),
+			tags:   string(f2.Tag),
+		})
+		if f2.Type.Kind() == reflect.Struct && f2.Tag == "" && !readsItself(f2.Type) {
+			rows, _ = layoutRows(rows, f2.Type, path+".", at, order)
+		}
+		if f2.Tag.Get("offset") != "" {
+			// The reader returns to where it was after the field
+			continue
+		}
+		if f2.Tag.Get("if") != "" || f2.Tag.Get("minver") != "" || f2.Tag.Get("maxver") != "" {
+			size = dynamic("sizeof(" + path + ")")
+		}
+		offset = at
+		offset.add(size)
+		al := f2.Tag.Get("align")
+		if al == "" {
+			al = opts.Get("align")
+		}
+		if al != "" {
+			n, err := strconv.Atoi(al)
+			if err != nil || !size.static() {
+				offset.terms = append(offset.terms, "align("+path+", "+al+")")
+			} else if n < size.n {
+				offset.n += mathutil.AlignUp(size.n, n) - size.n
+			} else {
+				offset.n += n - size.n
+			}
+		}
+	}
+	return rows, offset
+}
+
+// Writes a human readable report of how the struct type t is laid
+// out when read with the given byte order. Every field, including
+// those of nested untagged structs, is listed with its offset and
+// size, which are given as expressions when they depend on data
+// only known when reading, together with its byte order and tags.
+//
+// This is mostly useful for documenting formats directly from
+// their Go definitions.
+func WriteLayoutReport(w io.Writer, t reflect.Type, order sb.ByteOrder, format LayoutFormat) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("Can only report the layout of structs, not %s", t)
+	}
+	rows, size := layoutRows(nil, t, "", layoutExpr{}, order)
+	header := layoutRow{"Field", "Type", "Offset", "Size", "Order", "Tags"}
+	switch format {
+	case LayoutText:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintf(tw, "%s\n", t)
+		for _, r := range append([]layoutRow{header}, rows...) {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", r.path, r.typ, r.offset, r.size, r.endian, r.tags)
+		}
+		fmt.Fprintf(tw, "Total size: %s\n", size)
+		return tw.Flush()
+	case LayoutMarkdown:
+		var b strings.Builder
+		escape := strings.NewReplacer("|", `\|`)
+		cell := func(s string) string {
+			if s == "" {
+				return ""
+			}
+			return "`" + escape.Replace(s) + "`"
+		}
+		fmt.Fprintf(&b, "## %s\n\n", t)
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n", header.path, header.typ, header.offset, header.size, header.endian, header.tags)
+		b.WriteString("|---|---|---|---|---|---|\n")
+		for _, r := range rows {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n", r.path, cell(r.typ), cell(r.offset), cell(r.size), r.endian, cell(r.tags))
+		}
+		fmt.Fprintf(&b, "\nTotal size: `%s`\n", size)
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	return fmt.Errorf("Unknown layout format: %d", format)
+}
@@ -0,0 +1,76 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteLayoutReport(t *testing.T) {
+	type (
+		Point struct {
+			X, Y int16
+		}
+		Test struct {
+			Magic   [4]byte `match:"RIFF"`
+			Version uint16
+			Origin  Point
+			Count   uint8
+			Points  []Point `length:"Count"`
+			Name    string  `length:"uint8"`
+			Extra   uint32  `if:"Version > 1"`
+			Flags   uint8   `align:"4"`
+			Scale   float32 `fixed:"16.16"`
+			Data    []byte  `length:"Count + 1" skip:"2"`
+		}
+	)
+	var (
+		typ     = reflect.TypeOf(Test{})
+		rows, _ = layoutRows(nil, typ, "", layoutExpr{}, LittleEndian)
+		exp     = []layoutRow{
+			{"Magic", "[4]uint8", "0", "4", "", `match:"RIFF"`},
+			{"Version", "uint16", "4", "2", "le", ""},
+			{"Origin", "binary.Point", "6", "4", "", ""},
+			{"Origin.X", "int16", "6", "2", "le", ""},
+			{"Origin.Y", "int16", "8", "2", "le", ""},
+			{"Count", "uint8", "10", "1", "", ""},
+			{"Points", "[]binary.Point", "11", "Count * 4", "", `length:"Count"`},
+			{"Name", "string", "11 + Count * 4", "1 + len(Name)", "", `length:"uint8"`},
+			{"Extra", "uint32", "12 + Count * 4 + len(Name)", "4", "le", `if:"Version > 1"`},
+			{"Flags", "uint8", "12 + Count * 4 + len(Name) + sizeof(Extra)", "1", "", `align:"4"`},
+			{"Scale", "float32", "16 + Count * 4 + len(Name) + sizeof(Extra)", "4", "le", `fixed:"16.16"`},
+			{"Data", "[]uint8", "22 + Count * 4 + len(Name) + sizeof(Extra)", "(Count + 1)", "", `length:"Count + 1" skip:"2"`},
+		}
+	)
+	if len(rows) != len(exp) {
+		t.Fatalf("Expected %d rows, but got %d", len(exp), len(rows))
+	}
+	for i := range exp {
+		if rows[i] != exp[i] {
+			t.Errorf("%d: Expected %+v, but got %+v", i, exp[i], rows[i])
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteLayoutReport(&buf, typ, LittleEndian, LayoutText); err != nil {
+		t.Fatal(err)
+	}
+	if exp := "Total size: 22 + Count * 4 + len(Name) + sizeof(Extra) + (Count + 1)"; !strings.Contains(buf.String(), exp) {
+		t.Errorf("Expected the report to contain %q:\n%s", exp, buf.String())
+	}
+	buf.Reset()
+	if err := WriteLayoutReport(&buf, reflect.PtrTo(typ), BigEndian, LayoutMarkdown); err != nil {
+		t.Fatal(err)
+	}
+	if exp := "| Version | `uint16` | `4` | `2` | be |  |"; !strings.Contains(buf.String(), exp) {
+		t.Errorf("Expected the report to contain %q:\n%s", exp, buf.String())
+	}
+	if err := WriteLayoutReport(&buf, reflect.TypeOf(0), LittleEndian, LayoutText); err == nil {
+		t.Error("Expected an error for a non-struct type")
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The number of bytes read at a time while scanning for a magic
+// byte sequence.
+const resyncChunkSize = 4096
+
+// Parses the magic byte sequence of a `resync` tag, which like the
+// byte constants of the `match` tag is either a 0x prefixed hex string
+// or the literal bytes to look for.
+func parseMagic(tag string) ([]byte, error) {
+	if strings.HasPrefix(tag, "0x") {
+		return hex.DecodeString(tag[2:])
+	} else if tag == "" {
+		return nil, fmt.Errorf("Empty magic byte sequence")
+	}
+	return []byte(tag), nil
+}
+
+// Scans forward from the current position for the first occurrence
+// of magic and positions the reader at its start, returning the
+// number of bytes that were skipped. If the reader already is at an
+// occurrence of magic nothing is skipped.
+//
+// This is useful to find the start of the next frame in streams where
+// frames might be separated by padding or garbage, or to recover after
+// a corrupt record. The `resync` struct tag does the same before a
+// field is read:
+//
+//	type Packet struct {
+//		Sync    uint8 `resync:"0x47"`
+//		Payload [187]byte
+//	}
+//
+// If magic isn't found, the reader is left at the end of the stream
+// and io.EOF is returned.
+func (r *BinaryReader) SeekToMagic(magic []byte) (int64, error) {
+	if len(magic) == 0 {
+		return 0, fmt.Errorf("Empty magic byte sequence")
+	}
+	start, err := r.Seek(0, 1)
+	if err != nil {
+		return 0, err
+	}
+	var (
+		buf = r.alloc(resyncChunkSize + len(magic) - 1)
+		// The position in the stream of buf[0]
+		pos = start
+		n   int
+	)
+	defer r.release(buf)
+	for {
+		m, err := io.ReadFull(r.Reader, buf[n:])
+		n += m
+		if i := bytes.Index(buf[:n], magic); i != -1 {
+			_, err := r.Seek(pos+int64(i), 0)
+			return pos + int64(i) - start, err
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return pos + int64(n) - start, io.EOF
+		} else if err != nil {
+			return pos + int64(n) - start, err
+		}
+		// Keep the tail, as the magic might straddle the boundary
+		keep := len(magic) - 1
+		pos += int64(n - keep)
+		n = copy(buf, buf[n-keep:n])
+	}
+}
+
+// Positions the reader at the magic byte sequence of the `resync`
+// tag of a field about to be read.
+func (r *BinaryReader) resync(fp *fieldPlan) error {
+	magic, err := parseMagic(fp.resync)
+	if err != nil {
+		return fmt.Errorf("Field %s: %s", fp.name, err)
+	}
+	if _, err := r.SeekToMagic(magic); err != nil {
+		return err
+	}
+	return nil
+}
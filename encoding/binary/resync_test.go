@@ -0,0 +1,65 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	sb "encoding/binary"
+	"io"
+	"testing"
+)
+
+func TestSeekToMagic(t *testing.T) {
+	data := make([]byte, 3*resyncChunkSize)
+	// Straddles the first chunk boundary
+	copy(data[resyncChunkSize-2:], "MAGIC")
+	br := BinaryReader{Reader: bytes.NewReader(data), Endianess: sb.LittleEndian}
+	if n, err := br.SeekToMagic([]byte("MAGIC")); err != nil {
+		t.Fatal(err)
+	} else if n != resyncChunkSize-2 || br.Tell() != n {
+		t.Errorf("Expected to skip %d bytes, but skipped %d and ended up at %d", resyncChunkSize-2, n, br.Tell())
+	}
+	if n, err := br.SeekToMagic([]byte("MAGIC")); err != nil || n != 0 {
+		t.Errorf("Expected to stay put at the magic, but skipped %d: %v", n, err)
+	}
+	br.Seek(1, 1)
+	if _, err := br.SeekToMagic([]byte("MAGIC")); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	} else if br.Tell() != int64(len(data)) {
+		t.Errorf("Expected to end up at the end of the stream, not %d", br.Tell())
+	}
+}
+
+func TestBinaryReaderResync(t *testing.T) {
+	type Packet struct {
+		Sync    uint8 `resync:"0x47"`
+		Payload [2]byte
+	}
+	var (
+		data = []byte{0x47, 1, 2, 0xff, 0, 0x47, 3, 4, 0x47, 5, 6}
+		br   = BinaryReader{Reader: bytes.NewReader(data), Endianess: sb.LittleEndian}
+		got  []byte
+	)
+	for {
+		var p Packet
+		if err := br.ReadInterface(&p); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, p.Payload[:]...)
+	}
+	if !bytes.Equal(got, []byte{1, 2, 3, 4, 5, 6}) {
+		t.Errorf("Unexpected payloads: %v", got)
+	}
+
+	type Invalid struct {
+		A uint8 `resync:"0xzz"`
+	}
+	br = BinaryReader{Reader: bytes.NewReader(data), Endianess: sb.LittleEndian}
+	if err := br.ReadInterface(&Invalid{}); err == nil {
+		t.Error("Expected an error for a malformed magic")
+	}
+}
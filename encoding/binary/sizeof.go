@@ -0,0 +1,149 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"context"
+	"fmt"
+	"github.com/quarnster/util/encoding/binary/expression"
+	"log/slog"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+type (
+	// The position and size in bytes of a field read from a stream.
+	FieldSize struct {
+		Path   string
+		Offset int64
+		Size   int64
+	}
+
+	// The fields read by ReadSizeReport, ordered by their offsets.
+	// Struct fields come before the fields they contain.
+	SizeReport []FieldSize
+
+	// A slog.Handler collecting the sizes of the fields traced
+	// by a BinaryReader.
+	sizeHandler struct {
+		report *SizeReport
+	}
+)
+
+// Returns the function looking up the sizes of the fields of the
+// plan's struct read so far, or nil if sizes is nil.
+//
+// A field's size is the number of bytes read from its start to its
+// end, which doesn't include any `skip` before it or `align` padding
+// after it. Fields left out due to their `if` tag have a size of 0.
+// This makes expressions such as the following possible:
+//
+//	type Record struct {
+//		Size   uint32
+//		Fixed  Fixed
+//		Extra  []byte `length:"Size - sizeof(Fixed)"`
+//	}
+func (p *structPlan) sizeFunc(sizes []int) expression.SizeFunc {
+	if sizes == nil {
+		return nil
+	}
+	return func(name string) (int, error) {
+		fp := p.lookup(name)
+		if fp == nil {
+			return 0, fmt.Errorf("No field by name %s to take the size of", name)
+		} else if sizes[fp.index] < 0 {
+			return 0, fmt.Errorf("The size of field %s isn't known until it has been read", name)
+		}
+		return sizes[fp.index], nil
+	}
+}
+
+func (h sizeHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h sizeHandler) Handle(_ context.Context, rec slog.Record) error {
+	var fs FieldSize
+	rec.Attrs(func(a slog.Attr) bool {
+		switch a.Key {
+		case "path":
+			fs.Path = a.Value.String()
+		case "offset":
+			fs.Offset = a.Value.Int64()
+		case "size":
+			fs.Size = a.Value.Int64()
+		}
+		return true
+	})
+	*h.report = append(*h.report, fs)
+	return nil
+}
+
+func (h sizeHandler) WithAttrs([]slog.Attr) slog.Handler {
+	return h
+}
+
+func (h sizeHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// Reads the struct v points to, like ReadInterface does, and reports
+// how many bytes each of the fields read took up. Comparing this to
+// what the format's documentation says, or to what WriteLayoutReport
+// says, makes it easy to find where a struct definition has drifted
+// from the data it is reading.
+func ReadSizeReport(r *BinaryReader, v interface{}) (SizeReport, error) {
+	var (
+		report SizeReport
+		trace  = r.Trace
+	)
+	r.Trace = slog.New(sizeHandler{&report})
+	err := r.ReadInterface(v)
+	r.Trace = trace
+	if err != nil {
+		return nil, err
+	}
+	report.sort()
+	return report, nil
+}
+
+// Sorts the report by offset. Fields are traced once they have been
+// read, which puts the fields of nested structs before the struct
+// itself, so larger fields are put first when offsets are equal.
+func (s SizeReport) sort() {
+	sort.SliceStable(s, func(i, j int) bool {
+		a, b := s[i], s[j]
+		switch {
+		case a.Offset != b.Offset:
+			return a.Offset < b.Offset
+		case a.Size != b.Size:
+			return a.Size > b.Size
+		}
+		return len(a.Path) < len(b.Path)
+	})
+}
+
+// Returns the size of the field at the given path, such as
+// "Header.Entries[3].Offset".
+func (s SizeReport) Lookup(path string) (FieldSize, bool) {
+	for _, fs := range s {
+		if fs.Path == path {
+			return fs, true
+		}
+	}
+	return FieldSize{}, false
+}
+
+func (s SizeReport) String() string {
+	var b strings.Builder
+	tw := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Field\tOffset\tSize\n")
+	for _, fs := range s {
+		fmt.Fprintf(tw, "%s\t%d\t%d\n", fs.Path, fs.Offset, fs.Size)
+	}
+	tw.Flush()
+	return b.String()
+}
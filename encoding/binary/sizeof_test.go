@@ -0,0 +1,93 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	sb "encoding/binary"
+	"strings"
+	"testing"
+)
+
+func TestBinaryReaderSizeOf(t *testing.T) {
+	type Record struct {
+		Size  uint8
+		Name  string
+		Flags uint16 `if:"Size > 4"`
+		Extra []byte `length:"Size - (sizeof(Name) + sizeof(Flags))"`
+	}
+	tests := []struct {
+		data  []byte
+		name  string
+		extra []byte
+	}{
+		{[]byte{8, 'a', 'b', 0, 1, 0, 7, 8, 9}, "ab", []byte{7, 8, 9}},
+		{[]byte{4, 'a', 'b', 'c', 0}, "abc", []byte{}},
+	}
+	for i, test := range tests {
+		var (
+			rec Record
+			br  = BinaryReader{Reader: bytes.NewReader(test.data), Endianess: sb.LittleEndian}
+		)
+		if err := br.ReadInterface(&rec); err != nil {
+			t.Errorf("Test %d: %s", i, err)
+		} else if rec.Name != test.name || !bytes.Equal(rec.Extra, test.extra) {
+			t.Errorf("Test %d: Unexpected record %+v", i, rec)
+		} else if br.Tell() != int64(len(test.data)) {
+			t.Errorf("Test %d: Expected to read all %d bytes, but read %d", i, len(test.data), br.Tell())
+		}
+	}
+
+	type Bad struct {
+		A uint8 `if:"sizeof(B) == 1"`
+		B uint8
+	}
+	var (
+		bad Bad
+		br  = BinaryReader{Reader: bytes.NewReader([]byte{1, 2}), Endianess: sb.LittleEndian}
+	)
+	if err := br.ReadInterface(&bad); err == nil || !strings.Contains(err.Error(), "read") {
+		t.Errorf("Expected an error about B not having been read, got %v", err)
+	}
+}
+
+func TestReadSizeReport(t *testing.T) {
+	type (
+		Header struct {
+			Magic [4]byte
+			Count uint16
+		}
+		File struct {
+			Header  Header
+			Name    string   `align:"4"`
+			Entries []uint32 `length:"Header.Count"`
+		}
+	)
+	data := []byte("FILE\x02\x00ab\x00\x00\x01\x00\x00\x00\x02\x00\x00\x00")
+	var (
+		f  File
+		br = BinaryReader{Reader: bytes.NewReader(data), Endianess: sb.LittleEndian}
+	)
+	report, err := ReadSizeReport(&br, &f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := SizeReport{
+		{"Header", 0, 6},
+		{"Header.Magic", 0, 4},
+		{"Header.Count", 4, 2},
+		{"Name", 6, 3},
+		{"Entries", 10, 8},
+	}
+	if report.String() != exp.String() {
+		t.Errorf("Expected:\n%s\nGot:\n%s", exp, report)
+	}
+	if fs, ok := report.Lookup("Name"); !ok || fs.Size != 3 {
+		t.Errorf("Unexpected size of Name: %+v", fs)
+	}
+	if br.Trace != nil {
+		t.Error("Expected the reader's Trace to be restored")
+	}
+}
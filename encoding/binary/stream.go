@@ -0,0 +1,110 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Repeatedly decodes records of type T from the current position of
+// r until the end of the stream, calling fn with each of them, as is
+// needed for log and telemetry files which are nothing but a sequence
+// of records:
+//
+//	err := binary.ReadEach(&br, func(e Event) error {
+//		...
+//	})
+//
+// Returns nil if the stream ends cleanly between two records, and
+// io.ErrUnexpectedEOF if it ends part way through one. Any other
+// error, including one returned by fn, stops the reading and is
+// returned as is.
+func ReadEach[T any](r *BinaryReader, fn func(T) error) error {
+	for {
+		start := r.Tell()
+		var v T
+		if err := r.ReadInterface(&v); err == io.EOF && r.Tell() == start {
+			return nil
+		} else if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		} else if err != nil {
+			return err
+		} else if r.Tell() == start {
+			return fmt.Errorf("Records of type %T don't advance the stream", v)
+		}
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+}
+
+// Decodes records of type T from r like ReadEach, sending them to ch,
+// which is closed once done so that consumers can simply range over
+// it. The error the reading stopped with is returned after ch has
+// been closed:
+//
+//	ch := make(chan Event, 64)
+//	go func() {
+//		errc <- binary.ReadStream(ctx, &br, ch)
+//	}()
+//	for e := range ch {
+//		...
+//	}
+//	if err := <-errc; err != nil {
+//		...
+//	}
+//
+// Cancelling ctx stops the reading, in which case ctx.Err() is
+// returned, so consumers which stop receiving early don't leave the
+// reading goroutine blocked.
+func ReadStream[T any](ctx context.Context, r *BinaryReader, ch chan<- T) error {
+	defer close(ch)
+	return ReadEach(r, func(v T) error {
+		select {
+		case ch <- v:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// Like ReadStream, but sends the records in batches of size records,
+// which cuts down on the synchronization when records are small. The
+// last batch holds the remaining records and is shorter, unless the
+// stream held a multiple of size records. It's sent even when the
+// reading stops with an error, so that the records decoded before
+// the error aren't lost.
+func ReadBatches[T any](ctx context.Context, r *BinaryReader, size int, ch chan<- []T) error {
+	defer close(ch)
+	if size < 1 {
+		return fmt.Errorf("Invalid batch size: %d", size)
+	}
+	send := func(batch []T) error {
+		select {
+		case ch <- batch:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	batch := make([]T, 0, size)
+	err := ReadEach(r, func(v T) error {
+		if batch = append(batch, v); len(batch) < size {
+			return nil
+		}
+		err := send(batch)
+		batch = make([]T, 0, size)
+		return err
+	})
+	if len(batch) > 0 && ctx.Err() == nil {
+		if err2 := send(batch); err == nil {
+			err = err2
+		}
+	}
+	return err
+}
@@ -0,0 +1,146 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+)
+
+type streamRecord struct {
+	Id    uint8
+	Value uint16
+}
+
+func streamData(n int) []byte {
+	var data []byte
+	for i := 0; i < n; i++ {
+		data = append(data, byte(i), byte(i), 0)
+	}
+	return data
+}
+
+func TestReadEach(t *testing.T) {
+	tests := []struct {
+		data []byte
+		n    int
+		err  error
+	}{
+		{nil, 0, nil},
+		{streamData(5), 5, nil},
+		{streamData(5)[:14], 4, io.ErrUnexpectedEOF},
+	}
+	for i, test := range tests {
+		var (
+			got []streamRecord
+			br  = BinaryReader{Reader: bytes.NewReader(test.data), Endianess: LittleEndian}
+		)
+		err := ReadEach(&br, func(r streamRecord) error {
+			got = append(got, r)
+			return nil
+		})
+		if err != test.err {
+			t.Errorf("Test %d: Expected error %v, but got %v", i, test.err, err)
+		}
+		if len(got) != test.n {
+			t.Errorf("Test %d: Expected %d records, but got %d", i, test.n, len(got))
+		}
+		for j, r := range got {
+			if exp := (streamRecord{uint8(j), uint16(j)}); r != exp {
+				t.Errorf("Test %d: Expected %+v, but got %+v", i, exp, r)
+			}
+		}
+	}
+
+	stop := fmt.Errorf("Stop")
+	br := BinaryReader{Reader: bytes.NewReader(streamData(5)), Endianess: LittleEndian}
+	if err := ReadEach(&br, func(r streamRecord) error {
+		if r.Id == 1 {
+			return stop
+		}
+		return nil
+	}); err != stop {
+		t.Errorf("Expected the callback's error, but got %v", err)
+	} else if br.Tell() != 6 {
+		t.Errorf("Expected to stop after the second record, but stopped at %d", br.Tell())
+	}
+
+	br = BinaryReader{Reader: bytes.NewReader(streamData(1)), Endianess: LittleEndian}
+	if err := ReadEach(&br, func(struct{}) error { return nil }); err == nil {
+		t.Error("Expected an error for records of zero size")
+	}
+}
+
+func TestReadStream(t *testing.T) {
+	var (
+		br  = BinaryReader{Reader: bytes.NewReader(streamData(10)), Endianess: LittleEndian}
+		ch  = make(chan streamRecord)
+		err = make(chan error, 1)
+		got []streamRecord
+	)
+	go func() {
+		err <- ReadStream(context.Background(), &br, ch)
+	}()
+	for r := range ch {
+		got = append(got, r)
+	}
+	if err := <-err; err != nil {
+		t.Error(err)
+	}
+	if len(got) != 10 || got[9].Id != 9 {
+		t.Errorf("Unexpected records %+v", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	br = BinaryReader{Reader: bytes.NewReader(streamData(10)), Endianess: LittleEndian}
+	ch = make(chan streamRecord)
+	go func() {
+		err <- ReadStream(ctx, &br, ch)
+	}()
+	<-ch
+	cancel()
+	if err := <-err; err != context.Canceled {
+		t.Errorf("Expected the reading to be cancelled, but got %v", err)
+	}
+}
+
+func TestReadBatches(t *testing.T) {
+	var (
+		data = streamData(7)
+		br   = BinaryReader{Reader: bytes.NewReader(data[:len(data)-1]), Endianess: LittleEndian}
+		ch   = make(chan []streamRecord, 10)
+	)
+	if err := ReadBatches(context.Background(), &br, 3, ch); err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected %v, but got %v", io.ErrUnexpectedEOF, err)
+	}
+	var sizes []int
+	for b := range ch {
+		sizes = append(sizes, len(b))
+	}
+	if exp := []int{3, 3}; !reflect.DeepEqual(sizes, exp) {
+		t.Errorf("Expected batches of %v, but got %v", exp, sizes)
+	}
+
+	br = BinaryReader{Reader: bytes.NewReader(data), Endianess: LittleEndian}
+	ch = make(chan []streamRecord, 10)
+	if err := ReadBatches(context.Background(), &br, 3, ch); err != nil {
+		t.Error(err)
+	}
+	sizes = nil
+	for b := range ch {
+		sizes = append(sizes, len(b))
+	}
+	if exp := []int{3, 3, 1}; !reflect.DeepEqual(sizes, exp) {
+		t.Errorf("Expected batches of %v, but got %v", exp, sizes)
+	}
+
+	if err := ReadBatches(context.Background(), &br, 0, make(chan []streamRecord)); err == nil {
+		t.Error("Expected an error for an invalid batch size")
+	}
+}
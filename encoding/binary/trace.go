@@ -0,0 +1,63 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"context"
+	"encoding/hex"
+	"log/slog"
+	"reflect"
+	"strings"
+)
+
+// The maximum number of raw bytes included in a trace record.
+const maxTraceBytes = 32
+
+// Returns the dotted path of the field currently being read,
+// such as "Header.Entries[3].Offset".
+func (r *BinaryReader) tracePath() string {
+	var b strings.Builder
+	for i, p := range r.path {
+		if i > 0 && !strings.HasPrefix(p, "[") {
+			b.WriteByte('.')
+		}
+		b.WriteString(p)
+	}
+	return b.String()
+}
+
+// Logs a struct field that was just read, starting at the stream
+// offset start, to the reader's Trace logger.
+func (r *BinaryReader) traceField(start int64, f reflect.Value, fp *fieldPlan) {
+	if r.Trace == nil || !r.Trace.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	var (
+		end = r.Tell()
+		raw []byte
+	)
+	if n := end - start; n > 0 && start >= 0 {
+		if n > maxTraceBytes {
+			n = maxTraceBytes
+		}
+		if _, err := r.Seek(start, 0); err == nil {
+			raw, _ = r.ReadPartial(int(n))
+			defer r.release(raw)
+		}
+		r.Seek(end, 0)
+	}
+	attrs := []interface{}{
+		"path", r.tracePath(),
+		"offset", start,
+		"size", end - start,
+		"raw", hex.EncodeToString(raw),
+		"value", f.Interface(),
+		"tags", string(fp.tag),
+	}
+	if name := fp.enumName(f); name != "" {
+		attrs = append(attrs, "enum", name)
+	}
+	r.Trace.Debug("read", attrs...)
+}
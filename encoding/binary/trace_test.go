@@ -0,0 +1,64 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestBinaryReaderTrace(t *testing.T) {
+	type Elem struct {
+		X uint16
+	}
+	type Test struct {
+		Count uint8
+		Elems []Elem `length:"Count"`
+		Name  string `max:"8"`
+	}
+	var (
+		t2  Test
+		out bytes.Buffer
+		lvl slog.LevelVar
+		br  = BinaryReader{
+			Reader:    bytes.NewReader([]byte{2, 1, 0, 2, 0, 'h', 'i', 0}),
+			Endianess: LittleEndian,
+			Trace:     slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: &lvl})),
+		}
+	)
+	lvl.Set(slog.LevelDebug)
+	if err := br.ReadInterface(&t2); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	exp := []string{
+		"path=Count offset=0 size=1 raw=02 value=2",
+		"path=Elems[0].X offset=1 size=2 raw=0100 value=1",
+		"path=Elems[1].X offset=3 size=2 raw=0200 value=2",
+		"path=Elems offset=1 size=4 raw=01000200",
+		"path=Name offset=5 size=3 raw=686900 value=hi",
+	}
+	if len(lines) != len(exp) {
+		t.Fatalf("Expected %d lines, but got %d:\n%s", len(exp), len(lines), out.String())
+	}
+	for i := range exp {
+		if !strings.Contains(lines[i], exp[i]) {
+			t.Errorf("%d: Expected %q in %q", i, exp[i], lines[i])
+		}
+	}
+
+	// Raising the level turns tracing off
+	out.Reset()
+	lvl.Set(slog.LevelInfo)
+	br.Reader = bytes.NewReader([]byte{0, 0})
+	if err := br.ReadInterface(&t2); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Didn't expect any output, but got %s", out.String())
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+)
+
+// Reads size bytes of raw data, passes it through the named
+// Transform and decodes the field f from the result.
+//
+// Byte slices and strings are set to the transformed data directly,
+// other slices are filled with as many elements as the transformed
+// data holds, and any other type is read as usual.
+//
+// Returns the number of raw bytes read, for the purposes of alignment.
+func (r *BinaryReader) readTransformed(f reflect.Value, name string, size int) (int, error) {
+	if size < 0 {
+		return 0, fmt.Errorf("Transformed fields require a known length")
+	}
+	t, err := lookupTransform(name)
+	if err != nil {
+		return 0, err
+	}
+	raw, err := r.Read(size)
+	if err != nil {
+		return 0, err
+	}
+	data, err := t(raw)
+	if err != nil {
+		r.release(raw)
+		return 0, err
+	}
+	if f.Kind() == reflect.Slice && f.Type().Elem().Kind() == reflect.Uint8 {
+		// The transformed data might share memory with the raw data,
+		// so neither can be released.
+		f.SetBytes(data)
+		return size, nil
+	}
+	defer r.release(raw)
+	if f.Kind() == reflect.String {
+		f.SetString(string(data))
+		return size, nil
+	}
+
+	var (
+		rd  = bytes.NewReader(data)
+		sub = BinaryReader{Reader: rd, Endianess: r.Endianess, Reuse: r.Reuse, Trace: r.Trace, Vars: r.Vars}
+	)
+	if f.Kind() == reflect.Slice {
+		v := reflect.MakeSlice(f.Type(), 0, 0)
+		for rd.Len() > 0 {
+			e := reflect.New(f.Type().Elem())
+			if err := sub.ReadInterface(e.Interface()); err != nil {
+				return 0, err
+			}
+			v = reflect.Append(v, e.Elem())
+		}
+		f.Set(v)
+	} else if err := sub.ReadInterface(f.Addr().Interface()); err != nil {
+		return 0, err
+	} else if rd.Len() > 0 {
+		return 0, fmt.Errorf("%d bytes of transformed data left unread", rd.Len())
+	}
+	return size, nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func init() {
+	RegisterTransform("test-xor", func(data []byte) ([]byte, error) {
+		for i := range data {
+			data[i] ^= 0x5a
+		}
+		return data, nil
+	})
+	RegisterTransform("test-fail", func(data []byte) ([]byte, error) {
+		return nil, errors.New("failed")
+	})
+}
+
+func xor(data ...byte) []byte {
+	for i := range data {
+		data[i] ^= 0x5a
+	}
+	return data
+}
+
+func TestBinaryReaderTransform(t *testing.T) {
+	type Payload struct {
+		A uint16
+		B uint8
+	}
+	type Test struct {
+		Size    uint8
+		Payload Payload  `length:"Size" transform:"test-xor"`
+		Values  []uint16 `length:"uint8" transform:"test-xor"`
+		Raw     []byte   `length:"2" transform:"test-xor"`
+		End     uint8
+	}
+	var (
+		t2   Test
+		data = []byte{3}
+	)
+	data = append(data, xor(1, 2, 3)...)
+	data = append(data, 4)
+	data = append(data, xor(1, 0, 2, 0)...)
+	data = append(data, xor(9, 8)...)
+	data = append(data, 0xff)
+	exp := Test{3, Payload{0x201, 3}, []uint16{1, 2}, []byte{9, 8}, 0xff}
+
+	br := BinaryReader{Reader: bytes.NewReader(data), Endianess: LittleEndian}
+	if err := br.ReadInterface(&t2); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(t2, exp) {
+		t.Errorf("Expected %v, but got %v", exp, t2)
+	}
+}
+
+func TestBinaryReaderTransformErrors(t *testing.T) {
+	tests := []interface{}{
+		&struct {
+			A uint16 `transform:"test-xor"`
+		}{},
+		&struct {
+			A uint16 `length:"2" transform:"missing"`
+		}{},
+		&struct {
+			A uint16 `length:"2" transform:"test-fail"`
+		}{},
+		&struct {
+			A uint16 `length:"3" transform:"test-xor"`
+		}{},
+	}
+	for i, test := range tests {
+		br := BinaryReader{Reader: bytes.NewReader(make([]byte, 8)), Endianess: LittleEndian}
+		if err := br.ReadInterface(test); err == nil {
+			t.Errorf("%d: Expected an error, but didn't get one", i)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"fmt"
+	"github.com/quarnster/util/container"
+)
+
+// The most elements ReadTree allocates room for before reading them.
+const maxTreePrealloc = 4 << 10
+
+func readPreOrder[T any](r *BinaryReader, count *uint32) (*container.Node, error) {
+	if *count == 0 {
+		return nil, fmt.Errorf("The tree structure has more nodes than its count")
+	}
+	*count--
+	var (
+		n    container.Node
+		data T
+	)
+	flags, err := r.Uint8()
+	if err != nil {
+		return nil, err
+	} else if err := r.ReadInterface(&data); err != nil {
+		return nil, err
+	}
+	n.Data = data
+	for i, bit := range []uint8{container.HasLeftChild, container.HasRightChild} {
+		if flags&bit == 0 {
+			continue
+		}
+		if n.Children[i], err = readPreOrder[T](r, count); err != nil {
+			return nil, err
+		}
+	}
+	return &n, nil
+}
+
+// Loads a tree written by the container package's Tree.WriteFormat,
+// with the data read into values of type T. As the data is read with
+// ReadInterface, T may make use of any of the struct tags.
+func ReadTree[T any](r *BinaryReader, cmp container.Compare) (*container.Tree, error) {
+	format, err := r.Uint8()
+	if err != nil {
+		return nil, err
+	}
+	count, err := r.Uint32()
+	if err != nil {
+		return nil, err
+	}
+	switch container.TreeFormat(format) {
+	case container.SortedStream:
+		// The count isn't trusted for allocating up front, as it
+		// might not match the data actually there
+		data := make([]interface{}, 0, min(count, maxTreePrealloc))
+		for i := uint32(0); i < count; i++ {
+			var d T
+			if err := r.ReadInterface(&d); err != nil {
+				return nil, err
+			}
+			data = append(data, d)
+		}
+		return container.NewSortedTree(data, cmp)
+	case container.PreservedStructure:
+		t := &container.Tree{Compare: cmp}
+		if count == 0 {
+			return t, nil
+		}
+		n, err := readPreOrder[T](r, &count)
+		if err != nil {
+			return nil, err
+		} else if count != 0 {
+			return nil, fmt.Errorf("The tree structure has %d nodes less than its count", count)
+		}
+		t.Root = *n
+		return t, nil
+	}
+	return nil, fmt.Errorf("Unknown tree format: %d", format)
+}
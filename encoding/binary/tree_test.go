@@ -0,0 +1,75 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	"github.com/quarnster/util/container"
+	"reflect"
+	"testing"
+)
+
+func compareInt32(a, b interface{}) container.ComparisonResult {
+	switch a, b := a.(int32), b.(int32); {
+	case a < b:
+		return container.Less
+	case a > b:
+		return container.Greater
+	}
+	return container.Equal
+}
+
+func TestReadTree(t *testing.T) {
+	// An unbalanced tree, whose shape loading it with SortedStream
+	// doesn't preserve
+	tree := &container.Tree{Compare: compareInt32}
+	for _, v := range []int32{5, 1, 2, 3, 9, 7} {
+		tree.Add(v)
+	}
+	for _, format := range []container.TreeFormat{container.SortedStream, container.PreservedStructure} {
+		var buf bytes.Buffer
+		if err := tree.WriteFormat(&buf, LittleEndian, format); err != nil {
+			t.Fatal(err)
+		}
+		br := BinaryReader{Reader: bytes.NewReader(buf.Bytes()), Endianess: LittleEndian}
+		t2, err := ReadTree[int32](&br, compareInt32)
+		if err != nil {
+			t.Fatalf("%d: %s", format, err)
+		}
+		if a, b := tree.Slice(), t2.Slice(); !reflect.DeepEqual(a, b) {
+			t.Errorf("%d: Expected %v, but got %v", format, a, b)
+		}
+		if same := reflect.DeepEqual(tree.Root, t2.Root); same != (format == container.PreservedStructure) {
+			t.Errorf("%d: Expected the structure to be preserved: %v, but it was: %v", format, !same, same)
+		}
+	}
+	var empty bytes.Buffer
+	(&container.Tree{Compare: compareInt32}).WriteFormat(&empty, LittleEndian, container.PreservedStructure)
+	br := BinaryReader{Reader: bytes.NewReader(empty.Bytes()), Endianess: LittleEndian}
+	if t2, err := ReadTree[int32](&br, compareInt32); err != nil {
+		t.Error(err)
+	} else if s := t2.Slice(); len(s) != 0 {
+		t.Errorf("Expected an empty tree, but got %v", s)
+	}
+	for _, format := range []byte{0, 1} {
+		// Claims to hold 0xffffffff elements, but only holds one
+		data := []byte{format, 0xff, 0xff, 0xff, 0xff, 1, 0, 0, 0}
+		if format == 1 {
+			data = []byte{format, 0xff, 0xff, 0xff, 0xff, 0, 1, 0, 0, 0}
+		}
+		br = BinaryReader{Reader: bytes.NewReader(data), Endianess: LittleEndian}
+		if _, err := ReadTree[int32](&br, compareInt32); err == nil {
+			t.Errorf("%d: Expected an error for a count larger than the data", format)
+		}
+		br = BinaryReader{Reader: bytes.NewReader(data[:7]), Endianess: LittleEndian}
+		if _, err := ReadTree[int32](&br, compareInt32); err == nil {
+			t.Errorf("%d: Expected an error for truncated data", format)
+		}
+	}
+	br = BinaryReader{Reader: bytes.NewReader([]byte{0, 2, 0, 0, 0, 2, 0, 0, 0, 1, 0, 0, 0}), Endianess: LittleEndian}
+	if _, err := ReadTree[int32](&br, compareInt32); err == nil {
+		t.Error("Expected an error for unsorted data")
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// A UUID, with its bytes in the order defined by RFC 4122, i.e. the
+// order of the hex digits in its canonical string form.
+//
+// Untagged UUID fields are read as is. Formats storing GUIDs the way
+// Microsoft does, with the first three groups in little endian byte
+// order, are read by tagging the field with `uuid:"guid"`. The tag can
+// also be used on string fields, which are then set to the canonical
+// string form of the UUID read:
+//
+//	type Header struct {
+//		Class UUID   `uuid:"guid"`
+//		ID    string `uuid:"rfc4122"`
+//	}
+type UUID [16]byte
+
+var uuidType = reflect.TypeOf(UUID{})
+
+// Parses the canonical string form of a UUID, with or without
+// the surrounding braces used for GUIDs.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "{"), "}")
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, fmt.Errorf("Malformed UUID: %s", s)
+	}
+	if _, err := hex.Decode(u[:], []byte(s[0:8]+s[9:13]+s[14:18]+s[19:23]+s[24:])); err != nil {
+		return u, err
+	}
+	return u, nil
+}
+
+// Returns the canonical string form of the UUID, such as
+// "6ba7b810-9dad-11d1-80b4-00c04fd430c8".
+func (u UUID) String() string {
+	h := hex.EncodeToString(u[:])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+func (u *UUID) UnmarshalText(data []byte) (err error) {
+	*u, err = ParseUUID(string(data))
+	return err
+}
+
+// Swaps the byte order of the first three groups, converting
+// between the RFC 4122 and Microsoft GUID byte orders.
+func (u *UUID) swapGUID() {
+	u[0], u[1], u[2], u[3] = u[3], u[2], u[1], u[0]
+	u[4], u[5] = u[5], u[4]
+	u[6], u[7] = u[7], u[6]
+}
+
+// Reads a UUID in the byte order given by the `uuid` tag into
+// the UUID or string field f.
+func (r *BinaryReader) readUUID(f reflect.Value, order string) (int, error) {
+	var u UUID
+	if err := r.readFull(u[:]); err != nil {
+		return 0, err
+	}
+	switch order {
+	case "rfc4122":
+	case "guid":
+		u.swapGUID()
+	default:
+		return 0, fmt.Errorf("Unknown UUID byte order: %s", order)
+	}
+	switch {
+	case f.Type() == uuidType:
+		f.Set(reflect.ValueOf(u))
+	case f.Kind() == reflect.String:
+		f.SetString(u.String())
+	default:
+		return 0, fmt.Errorf("The uuid tag only applies to UUID and string fields, not %s", f.Type())
+	}
+	return len(u), nil
+}
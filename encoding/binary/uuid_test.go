@@ -0,0 +1,63 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestParseUUID(t *testing.T) {
+	const s = "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+	u, err := ParseUUID(s)
+	if err != nil {
+		t.Fatal(err)
+	} else if u.String() != s {
+		t.Errorf("Expected %s, but got %s", s, u)
+	}
+	if u2, err := ParseUUID("{" + s + "}"); err != nil || u2 != u {
+		t.Errorf("Unexpected result when parsing a braced GUID: %s, %v", u2, err)
+	}
+	for _, in := range []string{"", "6ba7b8109dad11d180b400c04fd430c8", "6ba7b810-9dad-11d1-80b4-00c04fd430cx"} {
+		if _, err := ParseUUID(in); err == nil {
+			t.Errorf("Expected an error parsing %q", in)
+		}
+	}
+	if d, err := json.Marshal(u); err != nil || string(d) != `"`+s+`"` {
+		t.Errorf("Unexpected JSON: %s, %v", d, err)
+	}
+}
+
+func TestBinaryReaderUUID(t *testing.T) {
+	type Test struct {
+		A UUID
+		B UUID   `uuid:"guid"`
+		C string `uuid:"rfc4122"`
+		D string `uuid:"guid"`
+	}
+	var (
+		rfc  = []byte{0x6b, 0xa7, 0xb8, 0x10, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+		guid = []byte{0x10, 0xb8, 0xa7, 0x6b, 0xad, 0x9d, 0xd1, 0x11, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+		data = bytes.Join([][]byte{rfc, guid, rfc, guid}, nil)
+		br   = BinaryReader{Reader: bytes.NewReader(data), Endianess: LittleEndian}
+		v    Test
+	)
+	if err := br.ReadInterface(&v); err != nil {
+		t.Fatal(err)
+	}
+	const exp = "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+	if v.A.String() != exp || v.B.String() != exp || v.C != exp || v.D != exp {
+		t.Errorf("Unexpected result: %+v", v)
+	}
+
+	type Invalid struct {
+		A UUID `uuid:"mixed"`
+	}
+	br = BinaryReader{Reader: bytes.NewReader(data), Endianess: LittleEndian}
+	if err := br.ReadInterface(&Invalid{}); err == nil {
+		t.Error("Expected an error for an unknown byte order")
+	}
+}
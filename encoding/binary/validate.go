@@ -0,0 +1,100 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"github.com/quarnster/util/encoding/binary/expression"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Checks the declarative validation tags of a field that has just
+// been read:
+//
+//	assert:"Version >= 2 && Version <= 4"
+//		An expression which must evaluate to non-zero.
+//	match:"0x89504E47"
+//		The value must equal the given constant. For byte arrays,
+//		byte slices and strings the constant is either a 0x prefixed
+//		hex string or the literal bytes to match.
+//	in:"1,2,4,8"
+//		The value must be one of the comma separated constants.
+//	enum:"Name,strict"
+//		The value must be one of the values of the enumeration
+//		registered with RegisterEnum.
+func validateField(v *reflect.Value, f reflect.Value, fp *fieldPlan, env *expression.Env) error {
+	if m := fp.match; m != "" {
+		if ok, err := matchValue(f, m); err != nil {
+			return fmt.Errorf("Field %s: %s", fp.name, err)
+		} else if !ok {
+			return fmt.Errorf("Field %s: %v doesn't match the expected value %s", fp.name, f.Interface(), m)
+		}
+	}
+	if len(fp.in) != 0 {
+		found := false
+		for _, c := range fp.in {
+			if ok, err := matchValue(f, c); err != nil {
+				return fmt.Errorf("Field %s: %s", fp.name, err)
+			} else if ok {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("Field %s: %v isn't one of the allowed values %s", fp.name, f.Interface(), fp.tag.Get("in"))
+		}
+	}
+	if fp.enum != "" {
+		if err := validateEnum(f, fp); err != nil {
+			return err
+		}
+	}
+	if a := fp.assert; a != nil {
+		if ev, err := a.eval(v, env); err != nil {
+			return err
+		} else if ev == 0 {
+			return fmt.Errorf("Field %s: assertion failed: %s", fp.name, a.src)
+		}
+	}
+	return nil
+}
+
+func matchValue(f reflect.Value, c string) (bool, error) {
+	switch f.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(c, 0, 64)
+		return f.Uint() == u, err
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(c, 0, 64)
+		return f.Int() == i, err
+	case reflect.Bool:
+		b, err := strconv.ParseBool(c)
+		return f.Bool() == b, err
+	case reflect.String:
+		return f.String() == c, nil
+	case reflect.Array, reflect.Slice:
+		if f.Type().Elem().Kind() != reflect.Uint8 {
+			break
+		}
+		exp := []byte(c)
+		if strings.HasPrefix(c, "0x") {
+			var err error
+			if exp, err = hex.DecodeString(c[2:]); err != nil {
+				return false, err
+			}
+		}
+		if f.Len() != len(exp) {
+			return false, nil
+		}
+		data := make([]byte, f.Len())
+		reflect.Copy(reflect.ValueOf(data), f)
+		return bytes.Equal(data, exp), nil
+	}
+	return false, fmt.Errorf("Can't match a value of type %s", f.Type())
+}
@@ -0,0 +1,73 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestBinaryReaderValidationTags(t *testing.T) {
+	type Test struct {
+		Magic   uint32  `match:"0x89504E47"`
+		Tag     [4]byte `match:"RIFF"`
+		Hex     [2]byte `match:"0xCAFE"`
+		Version uint8   `assert:"Version >= 2 && Version <= 4"`
+		Size    uint8   `in:"1, 2, 4, 8"`
+	}
+	tests := []struct {
+		data []byte
+		ok   bool
+	}{
+		{[]byte{0x47, 0x4e, 0x50, 0x89, 'R', 'I', 'F', 'F', 0xca, 0xfe, 2, 4}, true},
+		{[]byte{0x47, 0x4e, 0x50, 0x89, 'R', 'I', 'F', 'F', 0xca, 0xfe, 4, 8}, true},
+		{[]byte{0x47, 0x4e, 0x50, 0x88, 'R', 'I', 'F', 'F', 0xca, 0xfe, 2, 4}, false},
+		{[]byte{0x47, 0x4e, 0x50, 0x89, 'R', 'I', 'F', 'X', 0xca, 0xfe, 2, 4}, false},
+		{[]byte{0x47, 0x4e, 0x50, 0x89, 'R', 'I', 'F', 'F', 0xca, 0xff, 2, 4}, false},
+		{[]byte{0x47, 0x4e, 0x50, 0x89, 'R', 'I', 'F', 'F', 0xca, 0xfe, 5, 4}, false},
+		{[]byte{0x47, 0x4e, 0x50, 0x89, 'R', 'I', 'F', 'F', 0xca, 0xfe, 2, 3}, false},
+	}
+	for i, test := range tests {
+		var t2 Test
+		br := BinaryReader{Reader: bytes.NewReader(test.data), Endianess: LittleEndian}
+		err := br.ReadInterface(&t2)
+		if (err == nil) != test.ok {
+			t.Errorf("%d: Expected success to be %v, but got error %v", i, test.ok, err)
+		} else if err != nil {
+			t.Logf("%d: %s", i, err)
+		}
+	}
+}
+
+type validatedVersion uint16
+
+func (v *validatedVersion) Validate() error {
+	if *v > 4 {
+		return fmt.Errorf("bad version %d", *v)
+	}
+	return nil
+}
+
+func TestBinaryReaderValidatePrimitive(t *testing.T) {
+	type (
+		Field struct {
+			Version validatedVersion
+		}
+		Slice struct {
+			Versions []validatedVersion `length:"2"`
+		}
+	)
+	for _, v := range []interface{}{&Field{}, &Slice{}} {
+		br := BinaryReader{Reader: bytes.NewReader([]byte{9, 0, 9, 0}), Endianess: LittleEndian}
+		if err := br.ReadInterface(v); err == nil {
+			t.Errorf("%T: Expected the invalid version to be rejected", v)
+		}
+		br = BinaryReader{Reader: bytes.NewReader([]byte{2, 0, 3, 0}), Endianess: LittleEndian}
+		if err := br.ReadInterface(v); err != nil {
+			t.Errorf("%T: %s", v, err)
+		}
+	}
+}
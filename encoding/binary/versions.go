@@ -0,0 +1,188 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"fmt"
+	"github.com/quarnster/parser"
+	"github.com/quarnster/util"
+	"github.com/quarnster/util/encoding/binary/expression"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// The expression the minver and maxver tags are compared against
+// when the struct's options don't name one.
+const defaultVersion = "Version"
+
+// The versions of a format a field is part of, as declared by the
+// minver and maxver tags of the field and of the fields containing
+// it:
+//
+//	minver:"2"
+//		The field is only read if the version is at least 2.
+//	maxver:"4"
+//		The field is only read if the version is at most 4.
+//
+// These are shorthands for `if:"Version >= 2 && Version <= 4"`, and
+// are combined with the field's if tag, if any. The version is the
+// field named Version of the struct being read, or the variable by
+// that name in BinaryReader.Vars for structs which don't store the
+// version themselves:
+//
+//	br.Vars = map[string]int{"Version": int(hdr.Version)}
+//
+// The version option of a struct names a different field or
+// expression to compare against.
+type FieldVersions struct {
+	// The path of the field, as in Header.Flags or Records[i].Size
+	Path string
+	// The first and last versions the field is part of, which hold
+	// nothing when unbounded
+	Min, Max util.Option[int]
+}
+
+// Returns whether the field is part of the given version.
+func (fv FieldVersions) In(version int) bool {
+	if min, ok := fv.Min.Get(); ok && version < min {
+		return false
+	} else if max, ok := fv.Max.Get(); ok && version > max {
+		return false
+	}
+	return true
+}
+
+func (fv FieldVersions) String() string {
+	var (
+		min, hasMin = fv.Min.Get()
+		max, hasMax = fv.Max.Get()
+	)
+	switch {
+	case hasMin && hasMax:
+		return fmt.Sprintf("%s: %d-%d", fv.Path, min, max)
+	case hasMin:
+		return fmt.Sprintf("%s: %d-", fv.Path, min)
+	case hasMax:
+		return fmt.Sprintf("%s: -%d", fv.Path, max)
+	}
+	return fv.Path + ": all"
+}
+
+// Returns the version ranges of all the fields of the struct type t,
+// and of the structs read as part of it, in the order they're read.
+// Fields without version tags get the range of the field containing
+// them, so the fields of a version are those for which In returns
+// true. This makes it possible to document which fields apply to
+// which versions of a format directly from its Go definition.
+func Versions(t reflect.Type) ([]FieldVersions, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("Can only report the versions of structs, not %s", t)
+	}
+	return versions(nil, t, "", FieldVersions{}, make(map[reflect.Type]bool))
+}
+
+func versions(ret []FieldVersions, t reflect.Type, prefix string, outer FieldVersions, seen map[reflect.Type]bool) ([]FieldVersions, error) {
+	if seen[t] {
+		// Recursive types are only reported once per path
+		return ret, nil
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	p := getPlan(t)
+	for i := range p.fields {
+		var (
+			fp = &p.fields[i]
+			fv = FieldVersions{Path: prefix + fp.name, Min: outer.Min, Max: outer.Max}
+		)
+		if fp.minver != "" || fp.maxver != "" {
+			if fp.cond.err != nil {
+				return nil, fmt.Errorf("%s field %s: %s", t, fp.name, fp.cond.err)
+			}
+			if n, err := strconv.Atoi(fp.minver); err == nil && n > fv.Min.OrElse(n-1) {
+				fv.Min = util.Some(n)
+			}
+			if n, err := strconv.Atoi(fp.maxver); err == nil && n < fv.Max.OrElse(n+1) {
+				fv.Max = util.Some(n)
+			}
+		}
+		ret = append(ret, fv)
+
+		et, path := fp.typ, fv.Path
+		for et.Kind() == reflect.Ptr || et.Kind() == reflect.Slice || et.Kind() == reflect.Array {
+			if et.Kind() != reflect.Ptr {
+				path += "[i]"
+			}
+			et = et.Elem()
+		}
+		if et.Kind() == reflect.Struct && et != uint128Type && !readsItself(et) {
+			var err error
+			if ret, err = versions(ret, et, path+".", fv, seen); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ret, nil
+}
+
+// Applies the minver and maxver tags of the fields, by folding them
+// into the if conditions of the fields.
+func (p *structPlan) applyVersions() {
+	version := p.version
+	if version == "" {
+		version = defaultVersion
+	}
+	for i := range p.fields {
+		if fp := &p.fields[i]; fp.minver != "" || fp.maxver != "" {
+			fp.cond = versionCond(fp.cond, version, fp.minver, fp.maxver)
+		}
+	}
+}
+
+// Returns the condition cond, which may be nil, extended with the
+// comparisons of the version expression against min and max.
+func versionCond(cond *expr, version, min, max string) *expr {
+	var terms []string
+	if cond != nil {
+		if cond.err != nil {
+			return cond
+		}
+		terms = append(terms, group(cond.node))
+	}
+	v := parseExpr(version)
+	if v.err != nil {
+		return &expr{src: version, err: fmt.Errorf("Invalid version %q: %s", version, v.err)}
+	}
+	bounds := []struct{ tag, op, value string }{
+		{"minver", ">=", min},
+		{"maxver", "<=", max},
+	}
+	for _, b := range bounds {
+		if b.value == "" {
+			continue
+		} else if n, err := strconv.Atoi(b.value); err != nil || n < 0 {
+			return &expr{src: b.value, err: fmt.Errorf("Invalid %s %q, expected a version number", b.tag, b.value)}
+		}
+		terms = append(terms, group(v.node)+" "+b.op+" "+b.value)
+	}
+	return parseExpr(strings.Join(terms, " && "))
+}
+
+// Returns the source form of the parsed expression node, in
+// parentheses unless it's a single operand.
+func group(node *parser.Node) string {
+	s := expression.Format(node)
+	if len(node.Children) > 0 {
+		switch node.Children[0].Name {
+		case "DotIdentifier", "Identifier", "Constant", "First", "Last", "SizeOf":
+			return s
+		}
+	}
+	return "(" + s + ")"
+}
@@ -0,0 +1,120 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+type (
+	versionedEntry struct {
+		Size  uint8
+		Flags uint8 `minver:"3"`
+	}
+	versionedFile struct {
+		Version uint8
+		Count   uint8
+		Extra   uint16           `minver:"2" maxver:"3"`
+		Entries []versionedEntry `length:"Count"`
+		Crc     uint32           `if:"Count > 1" minver:"4"`
+	}
+	versionedHeader struct {
+		_     struct{} `version:"Info >> 4"`
+		Info  uint8
+		Flags uint8 `minver:"1"`
+	}
+)
+
+func TestVersionTags(t *testing.T) {
+	tests := []struct {
+		data []byte
+		exp  versionedFile
+	}{
+		{
+			[]byte{1, 1, 5},
+			versionedFile{Version: 1, Count: 1, Entries: []versionedEntry{{Size: 5}}},
+		},
+		{
+			[]byte{2, 1, 0x34, 0x12, 5},
+			versionedFile{Version: 2, Count: 1, Extra: 0x1234, Entries: []versionedEntry{{Size: 5}}},
+		},
+		{
+			[]byte{3, 1, 0x34, 0x12, 5, 6},
+			versionedFile{Version: 3, Count: 1, Extra: 0x1234, Entries: []versionedEntry{{5, 6}}},
+		},
+		{
+			[]byte{4, 1, 5, 6},
+			versionedFile{Version: 4, Count: 1, Entries: []versionedEntry{{5, 6}}},
+		},
+		{
+			[]byte{4, 2, 5, 6, 7, 8, 1, 0, 0, 0},
+			versionedFile{Version: 4, Count: 2, Entries: []versionedEntry{{5, 6}, {7, 8}}, Crc: 1},
+		},
+	}
+	for _, test := range tests {
+		var (
+			v  versionedFile
+			br = BinaryReader{Reader: bytes.NewReader(test.data), Endianess: LittleEndian}
+		)
+		// The entries don't store the version themselves
+		br.Vars = map[string]int{"Version": int(test.data[0])}
+		if err := br.ReadInterface(&v); err != nil {
+			t.Errorf("Version %d: %s", test.exp.Version, err)
+		} else if !reflect.DeepEqual(v, test.exp) {
+			t.Errorf("Version %d: Expected %+v, but got %+v", test.exp.Version, test.exp, v)
+		} else if br.Tell() != int64(len(test.data)) {
+			t.Errorf("Version %d: Expected to read %d bytes, but read %d", test.exp.Version, len(test.data), br.Tell())
+		}
+	}
+
+	var h versionedHeader
+	br := BinaryReader{Reader: bytes.NewReader([]byte{0x10, 7}), Endianess: LittleEndian}
+	if err := br.ReadInterface(&h); err != nil {
+		t.Fatal(err)
+	} else if h.Flags != 7 {
+		t.Errorf("Expected the version to be taken from Info, but got %+v", h)
+	}
+
+	if err := CheckType(reflect.TypeOf(versionedFile{}), "Version"); err != nil {
+		t.Error(err)
+	}
+	type Invalid struct {
+		A uint8 `minver:"two"`
+	}
+	if err := Precompile(reflect.TypeOf(Invalid{})); err == nil {
+		t.Error("Expected an error for an invalid minver")
+	}
+}
+
+func TestVersions(t *testing.T) {
+	fv, err := Versions(reflect.TypeOf(&versionedFile{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range fv {
+		got = append(got, f.String())
+	}
+	exp := []string{
+		"Version: all",
+		"Count: all",
+		"Extra: 2-3",
+		"Entries: all",
+		"Entries[i].Size: all",
+		"Entries[i].Flags: 3-",
+		"Crc: 4-",
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected %q, but got %q", exp, got)
+	}
+	if fv[2].In(1) || !fv[2].In(2) || !fv[2].In(3) || fv[2].In(4) {
+		t.Errorf("Unexpected versions of %s", fv[2])
+	}
+	if _, err := Versions(reflect.TypeOf(0)); err == nil {
+		t.Error("Expected an error for a non-struct type")
+	}
+}
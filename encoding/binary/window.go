@@ -0,0 +1,117 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"fmt"
+	"io"
+)
+
+// The default rewind limit used by NewWindowReader.
+const DefaultWindowSize = 64 << 10
+
+// WindowReader turns a plain io.Reader, such as a socket or a pipe,
+// into an io.ReadSeeker by keeping the most recently read data
+// around, so that the small backwards seeks needed by `offset` tags,
+// optional fields and the like work on sources that can't seek:
+//
+//	br := BinaryReader{Reader: NewWindowReader(conn, 0), Endianess: BigEndian}
+//
+// Seeking backwards works as long as the target is within the rewind
+// limit of the furthest position read so far. Seeking forwards reads
+// and keeps the data skipped over. Seeking relative to the end isn't
+// possible, as the end of the source isn't known.
+//
+// Positions are counted from where the source was when the
+// WindowReader was created.
+type WindowReader struct {
+	inner io.Reader
+	limit int
+	// The data read from inner, of which buf[0] is at position start
+	buf   []byte
+	start int64
+	// The logical read position
+	pos int64
+}
+
+// Creates a new WindowReader reading from inner, keeping at least
+// limit bytes behind the furthest position read so far. If limit
+// is less than or equal to zero, DefaultWindowSize is used.
+func NewWindowReader(inner io.Reader, limit int) *WindowReader {
+	if limit <= 0 {
+		limit = DefaultWindowSize
+	}
+	return &WindowReader{inner: inner, limit: limit}
+}
+
+// Returns the absolute position of the end of the buffered data.
+func (w *WindowReader) end() int64 {
+	return w.start + int64(len(w.buf))
+}
+
+// Reads up to n more bytes from inner into the buffer, dropping data
+// which has left the window.
+func (w *WindowReader) fill(n int) (int, error) {
+	if len(w.buf) > 2*w.limit {
+		// Only trimmed every so often, so that the data isn't moved
+		// for every read
+		drop := len(w.buf) - w.limit
+		w.start += int64(drop)
+		w.buf = w.buf[:copy(w.buf, w.buf[drop:])]
+	}
+	l := len(w.buf)
+	if cap(w.buf)-l < n {
+		nbuf := make([]byte, l, 2*cap(w.buf)+n)
+		copy(nbuf, w.buf)
+		w.buf = nbuf
+	}
+	m, err := w.inner.Read(w.buf[l : l+n])
+	w.buf = w.buf[:l+m]
+	if m > 0 {
+		return m, nil
+	} else if err == nil {
+		err = io.ErrNoProgress
+	}
+	return 0, err
+}
+
+func (w *WindowReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if w.pos == w.end() {
+		if _, err := w.fill(len(p)); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, w.buf[w.pos-w.start:])
+	w.pos += int64(n)
+	return n, nil
+}
+
+func (w *WindowReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case 0:
+	case 1:
+		offset += w.pos
+	case 2:
+		return w.pos, fmt.Errorf("Can't seek relative to the end of a WindowReader")
+	default:
+		return w.pos, fmt.Errorf("Invalid whence: %d", whence)
+	}
+	if offset < w.start {
+		return w.pos, fmt.Errorf("Can't seek to %d, which has left the rewind window starting at %d", offset, w.start)
+	}
+	for offset > w.end() {
+		if _, err := w.fill(int(min(offset-w.end(), int64(w.limit)))); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return w.pos, err
+		}
+	}
+	w.pos = offset
+	return offset, nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2013 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestWindowReader(t *testing.T) {
+	data := make([]byte, 5000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	var (
+		exp      = bytes.NewReader(data)
+		w        = NewWindowReader(iotest.HalfReader(bytes.NewReader(data)), 64)
+		furthest int64
+	)
+	for i := 0; i < 2000; i++ {
+		switch rand.Intn(4) {
+		case 0:
+			// Anywhere within the window, or up to 100 bytes ahead
+			off := furthest - 64 + rand.Int63n(164)
+			if off < 0 || off > int64(len(data)) {
+				continue
+			}
+			p1, _ := exp.Seek(off, 0)
+			p2, err := w.Seek(off, 0)
+			if p1 != p2 || err != nil {
+				t.Fatalf("%d: Seek(%d, 0): %d != %d %v", i, off, p1, p2, err)
+			}
+		case 1:
+			off := rand.Int63n(40) - 20
+			if p, _ := exp.Seek(0, 1); p+off < furthest-64 || p+off < 0 || p+off > int64(len(data)) {
+				continue
+			}
+			p1, _ := exp.Seek(off, 1)
+			p2, err := w.Seek(off, 1)
+			if p1 != p2 || err != nil {
+				t.Fatalf("%d: Seek(%d, 1): %d != %d %v", i, off, p1, p2, err)
+			}
+		default:
+			var (
+				l      = rand.Intn(150)
+				d1, d2 = make([]byte, l), make([]byte, l)
+			)
+			n1, err1 := io.ReadFull(exp, d1)
+			n2, err2 := io.ReadFull(w, d2)
+			if n1 != n2 || err1 != err2 || !bytes.Equal(d1, d2) {
+				t.Fatalf("%d: Read(%d): %d %v != %d %v", i, l, n1, err1, n2, err2)
+			}
+		}
+		p1, _ := exp.Seek(0, 1)
+		p2, _ := w.Seek(0, 1)
+		if p1 != p2 {
+			t.Fatalf("%d: Position mismatch %d != %d", i, p1, p2)
+		}
+		furthest = max(furthest, p1)
+	}
+}
+
+func TestWindowReaderErrors(t *testing.T) {
+	w := NewWindowReader(strings.NewReader(strings.Repeat("x", 1000)), 16)
+	if _, err := w.Seek(500, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Seek(-16, 1); err != nil {
+		t.Errorf("Expected to be able to rewind within the window, got %s", err)
+	}
+	if _, err := w.Seek(10, 0); err == nil || !strings.Contains(err.Error(), "window") {
+		t.Errorf("Expected an error about the window, got %v", err)
+	}
+	if _, err := w.Seek(0, 2); err == nil {
+		t.Error("Expected seeking relative to the end to fail")
+	}
+	if _, err := w.Seek(2000, 0); err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected %s, but got %v", io.ErrUnexpectedEOF, err)
+	}
+}
+
+func TestWindowReaderOffset(t *testing.T) {
+	type Record struct {
+		Offset uint8
+		Size   uint8
+		Name   string `offset:"Offset" length:"Size"`
+		Value  uint16
+	}
+	var (
+		r  Record
+		br = BinaryReader{Reader: NewWindowReader(iotest.OneByteReader(bytes.NewReader([]byte{4, 3, 0x34, 0x12, 'a', 'b', 'c'})), 0), Endianess: LittleEndian}
+	)
+	if err := br.ReadInterface(&r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Name != "abc" || r.Value != 0x1234 {
+		t.Errorf("Unexpected record %+v", r)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+type (
+	// An Event is what gets delivered to the Observers subscribed
+	// to an EventBus. The Data field holds whatever the publisher
+	// passed in, which allows for publishing typed events and
+	// having subscribers filter on the type.
+	Event struct {
+		Topic string
+		Data  interface{}
+	}
+
+	// An EventFilter decides whether an Event should be delivered
+	// to a subscriber or not.
+	EventFilter func(ev Event) bool
+
+	// The EventBus type connects publishers and subscribers via
+	// named topics. Topics are dot separated, for example
+	// "container.array.inserted".
+	//
+	// When subscribing, a pattern is given rather than a topic name,
+	// where "*" matches exactly one topic segment and "**" matches
+	// any number of segments (including none). The pattern
+	// "container.*.inserted" would thus match the topic above, as
+	// would "container.**".
+	//
+	// The zero value is an empty EventBus ready to use, and it is
+	// safe to use from multiple goroutines.
+	EventBus struct {
+		lock sync.Mutex
+		subs []*subscription
+	}
+
+	subscription struct {
+		pattern []string
+		filter  EventFilter
+		obs     Observer
+		ctx     context.Context
+	}
+
+	busForwarder struct {
+		bus   *EventBus
+		topic string
+	}
+)
+
+// Subscribes the provided Observer to all topics matching the
+// given pattern. The Observer's Changed method is called with
+// an Event as the data argument.
+//
+// If filter is non-nil, only events for which it returns true
+// are delivered. If ctx is non-nil, the subscription is removed
+// automatically once the context is done.
+//
+// The returned function removes the subscription and may be
+// called multiple times.
+func (b *EventBus) Subscribe(ctx context.Context, pattern string, filter EventFilter, obs Observer) (cancel func()) {
+	s := &subscription{
+		pattern: strings.Split(pattern, "."),
+		filter:  filter,
+		obs:     obs,
+		ctx:     ctx,
+	}
+	b.lock.Lock()
+	b.subs = append(b.subs, s)
+	b.lock.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() { b.unsubscribe(s) })
+	}
+	if ctx == nil {
+		return unsubscribe
+	}
+	stop := context.AfterFunc(ctx, unsubscribe)
+	return func() {
+		stop()
+		unsubscribe()
+	}
+}
+
+func (b *EventBus) unsubscribe(s *subscription) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for i, v := range b.subs {
+		if v == s {
+			// Copy rather than modifying in place as Publish might be
+			// iterating over the old slice.
+			nsubs := make([]*subscription, 0, len(b.subs)-1)
+			nsubs = append(nsubs, b.subs[:i]...)
+			b.subs = append(nsubs, b.subs[i+1:]...)
+			return
+		}
+	}
+}
+
+// Publishes data on the given topic, notifying every subscriber
+// whose pattern matches the topic and whose filter accepts the event.
+//
+// Observers are invoked synchronously from the calling goroutine and
+// without any locks held, so they are free to publish further events
+// or to subscribe and unsubscribe.
+func (b *EventBus) Publish(topic string, data interface{}) {
+	b.lock.Lock()
+	subs := b.subs
+	b.lock.Unlock()
+
+	ev := Event{topic, data}
+	parts := strings.Split(topic, ".")
+	for _, s := range subs {
+		if s.ctx != nil && s.ctx.Err() != nil {
+			continue
+		}
+		if !matchTopic(s.pattern, parts) {
+			continue
+		}
+		if s.filter != nil && !s.filter(ev) {
+			continue
+		}
+		s.obs.Changed(ev)
+	}
+}
+
+// Returns an Observer that publishes any data it is notified
+// with on the given topic. This makes it possible to hook up any
+// Observable, such as the container package's ObservableArray,
+// to the EventBus:
+//
+//	arr.AddObserver(bus.Forward("model.items"))
+func (b *EventBus) Forward(topic string) Observer {
+	return &busForwarder{b, topic}
+}
+
+func (f *busForwarder) Changed(data interface{}) {
+	f.bus.Publish(f.topic, data)
+}
+
+func matchTopic(pattern, topic []string) bool {
+	for len(pattern) > 0 {
+		switch p := pattern[0]; p {
+		case "**":
+			for i := 0; i <= len(topic); i++ {
+				if matchTopic(pattern[1:], topic[i:]) {
+					return true
+				}
+			}
+			return false
+		default:
+			if len(topic) == 0 || (p != "*" && p != topic[0]) {
+				return false
+			}
+		}
+		pattern, topic = pattern[1:], topic[1:]
+	}
+	return len(topic) == 0
+}
@@ -0,0 +1,147 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+type eventRecorder struct {
+	events []Event
+}
+
+func (r *eventRecorder) Changed(data interface{}) {
+	r.events = append(r.events, data.(Event))
+}
+
+func TestMatchTopic(t *testing.T) {
+	tests := []struct {
+		pattern, topic string
+		exp            bool
+	}{
+		{"a.b.c", "a.b.c", true},
+		{"a.b.c", "a.b", false},
+		{"a.b", "a.b.c", false},
+		{"a.*.c", "a.b.c", true},
+		{"a.*.c", "a.b.d", false},
+		{"a.*", "a", false},
+		{"a.**", "a", true},
+		{"a.**", "a.b.c", true},
+		{"a.**.c", "a.c", true},
+		{"a.**.c", "a.b.b.c", true},
+		{"a.**.c", "a.b.b.d", false},
+		{"**", "anything.at.all", true},
+	}
+	for i, test := range tests {
+		var b EventBus
+		var r eventRecorder
+		b.Subscribe(nil, test.pattern, nil, &r)
+		b.Publish(test.topic, nil)
+		if got := len(r.events) == 1; got != test.exp {
+			t.Errorf("Test %d: %q ~ %q, expected %v but got %v", i, test.pattern, test.topic, test.exp, got)
+		}
+	}
+}
+
+func TestEventBusFilter(t *testing.T) {
+	var (
+		b    EventBus
+		r    eventRecorder
+		ints = func(ev Event) bool {
+			_, ok := ev.Data.(int)
+			return ok
+		}
+	)
+	b.Subscribe(nil, "values", ints, &r)
+	b.Publish("values", 1)
+	b.Publish("values", "two")
+	b.Publish("values", 3)
+	if len(r.events) != 2 {
+		t.Fatalf("Expected 2 events, but got %d", len(r.events))
+	}
+	if r.events[0].Data != 1 || r.events[1].Data != 3 {
+		t.Errorf("Unexpected events: %v", r.events)
+	}
+}
+
+func TestEventBusCancel(t *testing.T) {
+	var (
+		b EventBus
+		r eventRecorder
+	)
+	cancel := b.Subscribe(nil, "a", nil, &r)
+	b.Publish("a", 1)
+	cancel()
+	cancel()
+	b.Publish("a", 2)
+	if len(r.events) != 1 {
+		t.Errorf("Expected 1 event, but got %d", len(r.events))
+	}
+}
+
+func TestEventBusContext(t *testing.T) {
+	var (
+		b           EventBus
+		r           eventRecorder
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+	b.Subscribe(ctx, "a", nil, &r)
+	b.Publish("a", 1)
+	cancel()
+	b.Publish("a", 2)
+	if len(r.events) != 1 {
+		t.Errorf("Expected 1 event, but got %d", len(r.events))
+	}
+	for i := 0; i < 100; i++ {
+		b.lock.Lock()
+		l := len(b.subs)
+		b.lock.Unlock()
+		if l == 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Error("The subscription wasn't removed after the context was cancelled")
+}
+
+func TestEventBusContextUnsubscribe(t *testing.T) {
+	var (
+		b           EventBus
+		r           eventRecorder
+		ctx, cancel = context.WithCancel(context.Background())
+		before      = runtime.NumGoroutine()
+	)
+	defer cancel()
+	for i := 0; i < 100; i++ {
+		b.Subscribe(ctx, "a", nil, &r)()
+	}
+	if n := runtime.NumGoroutine(); n > before+10 {
+		t.Errorf("Expected cancelled subscriptions not to leave goroutines behind, but went from %d to %d", before, n)
+	}
+	b.Publish("a", 1)
+	if len(r.events) != 0 {
+		t.Errorf("Expected no events, but got %d", len(r.events))
+	}
+}
+
+func TestEventBusForward(t *testing.T) {
+	var (
+		b   EventBus
+		r   eventRecorder
+		obs BasicObservable
+	)
+	b.Subscribe(nil, "model.*", nil, &r)
+	obs.AddObserver(b.Forward("model.items"))
+	obs.NotifyObservers(42)
+	if len(r.events) != 1 {
+		t.Fatalf("Expected 1 event, but got %d", len(r.events))
+	}
+	if ev := r.events[0]; ev.Topic != "model.items" || ev.Data != 42 {
+		t.Errorf("Unexpected event: %+v", ev)
+	}
+}
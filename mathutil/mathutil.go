@@ -0,0 +1,77 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+// Package mathutil provides generic helpers for the numeric types.
+package mathutil
+
+type (
+	Signed interface {
+		~int | ~int8 | ~int16 | ~int32 | ~int64
+	}
+
+	Unsigned interface {
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+	}
+
+	Integer interface {
+		Signed | Unsigned
+	}
+
+	Float interface {
+		~float32 | ~float64
+	}
+
+	// Any of the numeric types, other than the complex ones.
+	Number interface {
+		Integer | Float
+	}
+)
+
+// Returns the smaller of a and b.
+func Min[T Number](a, b T) T {
+	if b < a {
+		return b
+	}
+	return a
+}
+
+// Returns the larger of a and b.
+func Max[T Number](a, b T) T {
+	if b > a {
+		return b
+	}
+	return a
+}
+
+// Returns v limited to the range [lo, hi].
+func Clamp[T Number](v, lo, hi T) T {
+	if v < lo {
+		return lo
+	} else if v > hi {
+		return hi
+	}
+	return v
+}
+
+// Returns the absolute value of v. As with the built in integer
+// negation, the most negative value of a signed type is returned
+// as is.
+func Abs[T Signed | Float](v T) T {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// Returns v rounded up to the nearest multiple of align, which must
+// be a power of two.
+func AlignUp[T Integer](v, align T) T {
+	return (v + (align - 1)) &^ (align - 1)
+}
+
+// Returns v rounded down to the nearest multiple of align, which
+// must be a power of two.
+func AlignDown[T Integer](v, align T) T {
+	return v &^ (align - 1)
+}
@@ -0,0 +1,63 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package mathutil
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMinMaxClamp(t *testing.T) {
+	if Min(3, -1) != -1 || Max(3, -1) != 3 {
+		t.Error("Unexpected Min or Max of ints")
+	}
+	if Min(2.5, 1.5) != 1.5 || Max(uint8(7), 200) != 200 {
+		t.Error("Unexpected Min or Max of other types")
+	}
+	if d := Max(time.Second, time.Minute); d != time.Minute {
+		t.Errorf("Expected a minute, but got %s", d)
+	}
+	tests := [][4]int{
+		{5, 0, 10, 5},
+		{-5, 0, 10, 0},
+		{15, 0, 10, 10},
+		{10, 0, 10, 10},
+	}
+	for _, test := range tests {
+		if v := Clamp(test[0], test[1], test[2]); v != test[3] {
+			t.Errorf("Clamp(%d, %d, %d): Expected %d, but got %d", test[0], test[1], test[2], test[3], v)
+		}
+	}
+}
+
+func TestAbs(t *testing.T) {
+	if Abs(-3) != 3 || Abs(int8(3)) != 3 || Abs(-1.5) != 1.5 {
+		t.Error("Unexpected Abs")
+	}
+}
+
+func TestAlign(t *testing.T) {
+	tests := []struct {
+		v, align, up, down int64
+	}{
+		{0, 4, 0, 0},
+		{1, 4, 4, 0},
+		{4, 4, 4, 4},
+		{5, 8, 8, 0},
+		{17, 16, 32, 16},
+		{3, 1, 3, 3},
+	}
+	for _, test := range tests {
+		if v := AlignUp(test.v, test.align); v != test.up {
+			t.Errorf("AlignUp(%d, %d): Expected %d, but got %d", test.v, test.align, test.up, v)
+		}
+		if v := AlignDown(test.v, test.align); v != test.down {
+			t.Errorf("AlignDown(%d, %d): Expected %d, but got %d", test.v, test.align, test.down, v)
+		}
+	}
+	if v := AlignUp(uint8(9), 8); v != 16 {
+		t.Errorf("Expected 16, but got %d", v)
+	}
+}
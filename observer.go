@@ -27,6 +27,16 @@ type (
 		Changed(data interface{})
 	}
 
+	// An observer which may consume the notifications it's sent,
+	// keeping them from the observers with a lower priority, such as
+	// one validating a change before the UI observers get to see it.
+	// BasicObservable calls Consume rather than Changed for Observers
+	// which also implement Consumer.
+	Consumer interface {
+		// Returns whether data was consumed.
+		Consume(data interface{}) (consumed bool)
+	}
+
 	// A type that is observable keeps its own list of
 	// active observers which should be notified upon
 	// changes being made to this type
@@ -38,8 +48,26 @@ type (
 
 	// BasicObservable implements the Observable interface,
 	// and is suitable to use as an embedded struct.
+	//
+	// Observers are free to add or remove observers and to trigger
+	// further notifications from within their Changed callback.
+	// Such changes to the observer list take effect from the next
+	// notification on; the notification in progress is still
+	// delivered to the observers registered when it started.
+	//
+	// Observers are notified in order of priority, highest first, and
+	// in the order they were added within the same priority.
+	//
+	// Notifications nested deeper than MaxNotifyDepth are assumed to
+	// be caused by a notification cycle, and will cause a panic.
 	BasicObservable struct {
-		observers []Observer
+		observers []prioritizedObserver
+		depth     int
+	}
+
+	prioritizedObserver struct {
+		obs      Observer
+		priority int
 	}
 
 	// The "poll" type deals with observed values that do
@@ -70,26 +98,60 @@ type (
 	}
 )
 
+// The maximum nesting depth of BasicObservable notifications.
+const MaxNotifyDepth = 100
+
 func (o funcObserver) Changed(data interface{}) {
 	o.obs()
 }
+
+// Adds an observer with the default priority of 0.
 func (o *BasicObservable) AddObserver(obs Observer) {
-	o.observers = append(o.observers, obs)
+	o.AddObserverPriority(obs, 0)
+}
+
+// Adds an observer which is notified before the observers with a
+// lower priority, and after those with a higher one.
+//
+// The observer list is never modified in place, but rather
+// replaced with a new copy, so that a NotifyObservers call
+// in progress can keep iterating over its own snapshot.
+func (o *BasicObservable) AddObserverPriority(obs Observer, priority int) {
+	i := len(o.observers)
+	for i > 0 && o.observers[i-1].priority < priority {
+		i--
+	}
+	nobs := make([]prioritizedObserver, 0, len(o.observers)+1)
+	nobs = append(nobs, o.observers[:i]...)
+	nobs = append(nobs, prioritizedObserver{obs, priority})
+	o.observers = append(nobs, o.observers[i:]...)
 }
 
 func (o *BasicObservable) RemoveObserver(obs Observer) {
 	for i, v := range o.observers {
-		if v == obs {
-			copy(o.observers[i:], o.observers[i+1:])
-			o.observers = o.observers[:len(o.observers)-1]
+		if v.obs == obs {
+			nobs := make([]prioritizedObserver, 0, len(o.observers)-1)
+			nobs = append(nobs, o.observers[:i]...)
+			o.observers = append(nobs, o.observers[i+1:]...)
 			return
 		}
 	}
 }
 
 func (o *BasicObservable) NotifyObservers(data interface{}) {
-	for _, obs := range o.observers {
-		obs.Changed(data)
+	if o.depth >= MaxNotifyDepth {
+		panic(fmt.Errorf("Notification cycle detected, notifications nested more than %d levels deep: %v", MaxNotifyDepth, data))
+	}
+	o.depth++
+	defer func() { o.depth-- }()
+	for _, v := range o.observers {
+		if c, ok := v.obs.(Consumer); ok {
+			if c.Consume(data) {
+				return
+			}
+		} else {
+			v.obs.Changed(data)
+		}
 	}
 }
 
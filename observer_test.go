@@ -6,6 +6,7 @@ package util
 
 import (
 	"math/rand"
+	"reflect"
 	"testing"
 )
 
@@ -186,3 +187,153 @@ func TestObseratory6(t *testing.T) {
 		t.Errorf("obsCount's are wrong: %d, %d", obsCount1, obsCount2)
 	}
 }
+
+type reentrantObserver struct {
+	o       *BasicObservable
+	changed func(r *reentrantObserver, data interface{})
+	count   int
+}
+
+func (r *reentrantObserver) Changed(data interface{}) {
+	r.count++
+	if r.changed != nil {
+		r.changed(r, data)
+	}
+}
+
+func TestBasicObservableRemoveDuringNotify(t *testing.T) {
+	var (
+		o   BasicObservable
+		obs = make([]*reentrantObserver, 4)
+	)
+	for i := range obs {
+		obs[i] = &reentrantObserver{o: &o}
+		o.AddObserver(obs[i])
+	}
+	// The first observer removes itself and the second one
+	obs[0].changed = func(r *reentrantObserver, data interface{}) {
+		r.o.RemoveObserver(obs[0])
+		r.o.RemoveObserver(obs[1])
+	}
+	o.NotifyObservers(nil)
+	for i, r := range obs {
+		if r.count != 1 {
+			t.Errorf("%d: Expected 1 notification, but got %d", i, r.count)
+		}
+	}
+	o.NotifyObservers(nil)
+	for i, exp := range []int{1, 1, 2, 2} {
+		if obs[i].count != exp {
+			t.Errorf("%d: Expected %d notifications, but got %d", i, exp, obs[i].count)
+		}
+	}
+}
+
+func TestBasicObservableAddDuringNotify(t *testing.T) {
+	var (
+		o     BasicObservable
+		added = &reentrantObserver{}
+		r     = &reentrantObserver{o: &o, changed: func(r *reentrantObserver, data interface{}) {
+			r.o.AddObserver(added)
+		}}
+	)
+	o.AddObserver(r)
+	o.NotifyObservers(nil)
+	if added.count != 0 {
+		t.Errorf("Observer added during notification shouldn't have been notified")
+	}
+	r.changed = nil
+	o.NotifyObservers(nil)
+	if added.count != 1 {
+		t.Errorf("Expected 1 notification, but got %d", added.count)
+	}
+}
+
+func TestBasicObservableNestedNotify(t *testing.T) {
+	var (
+		o BasicObservable
+		r = &reentrantObserver{o: &o, changed: func(r *reentrantObserver, data interface{}) {
+			if d := data.(int); d > 0 {
+				r.o.NotifyObservers(d - 1)
+			}
+		}}
+	)
+	o.AddObserver(r)
+	o.NotifyObservers(10)
+	if r.count != 11 {
+		t.Errorf("Expected 11 notifications, but got %d", r.count)
+	}
+}
+
+func TestBasicObservableCycle(t *testing.T) {
+	var (
+		a, b BasicObservable
+		ra   = &reentrantObserver{changed: func(r *reentrantObserver, data interface{}) {
+			b.NotifyObservers(data)
+		}}
+		rb = &reentrantObserver{changed: func(r *reentrantObserver, data interface{}) {
+			a.NotifyObservers(data)
+		}}
+	)
+	a.AddObserver(ra)
+	b.AddObserver(rb)
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic, but didn't get one")
+		}
+		if a.depth != 0 || b.depth != 0 {
+			t.Errorf("Depth wasn't restored: %d, %d", a.depth, b.depth)
+		}
+	}()
+	a.NotifyObservers(nil)
+}
+
+type orderObserver struct {
+	name  string
+	order *[]string
+}
+
+func (o *orderObserver) Changed(data interface{}) {
+	*o.order = append(*o.order, o.name)
+}
+
+type consumingObserver struct {
+	orderObserver
+}
+
+func (o *consumingObserver) Consume(data interface{}) bool {
+	o.Changed(data)
+	return data.(int) < 0
+}
+
+func TestBasicObservablePriority(t *testing.T) {
+	var (
+		o     BasicObservable
+		order []string
+		ui    = &orderObserver{name: "ui", order: &order}
+		log   = &orderObserver{name: "log", order: &order}
+		check = &consumingObserver{orderObserver{name: "check", order: &order}}
+		first = &orderObserver{name: "first", order: &order}
+	)
+	o.AddObserver(ui)
+	o.AddObserverPriority(log, -1)
+	o.AddObserverPriority(check, 10)
+	o.AddObserverPriority(first, 10)
+	o.AddObserver(&orderObserver{name: "ui2", order: &order})
+
+	o.NotifyObservers(1)
+	if exp := []string{"check", "first", "ui", "ui2", "log"}; !reflect.DeepEqual(order, exp) {
+		t.Errorf("Expected %v, but got %v", exp, order)
+	}
+	order = nil
+	o.NotifyObservers(-1)
+	if exp := []string{"check"}; !reflect.DeepEqual(order, exp) {
+		t.Errorf("Expected the notification to be consumed, but got %v", order)
+	}
+	order = nil
+	o.RemoveObserver(check)
+	o.NotifyObservers(-1)
+	if exp := []string{"first", "ui", "ui2", "log"}; !reflect.DeepEqual(order, exp) {
+		t.Errorf("Expected %v, but got %v", exp, order)
+	}
+}
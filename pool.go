@@ -0,0 +1,149 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"math/bits"
+	"sync"
+	"sync/atomic"
+)
+
+type (
+	// Statistics on how a pool has been used.
+	PoolStats struct {
+		// The number of Gets satisfied by a pooled object
+		Hits int64
+		// The number of Gets that had to create a new object
+		Misses int64
+		// The number of objects handed out by Get and not yet
+		// returned with Put
+		Live int64
+	}
+
+	// Pool is a typed wrapper around sync.Pool which also keeps
+	// statistics of its use. As with sync.Pool, pooled objects may
+	// be dropped at any time, and a Pool must not be copied after
+	// first use.
+	Pool[T any] struct {
+		// Creates a new object when there is none to reuse.
+		New func() T
+		// If set, called on every object passed to Put to reset it
+		// to a clean state before it's reused.
+		Reset func(T)
+
+		pool               sync.Pool
+		hits, misses, gets atomic.Int64
+		puts               atomic.Int64
+	}
+
+	// BytePool hands out byte slices of any size from a set of pools
+	// with power of two capacities, so that a request is always
+	// satisfied by a slice from the smallest size class fitting it.
+	BytePool struct {
+		classes [maxByteClass + 1]Pool[*[]byte]
+		// The slice headers emptied by Get, reused by Put so that
+		// putting a slice back doesn't allocate a new one
+		headers sync.Pool
+		once    sync.Once
+	}
+)
+
+// Slices larger than 1 << maxByteClass bytes are not pooled.
+const maxByteClass = 24
+
+// Returns an object from the pool, creating a new one with New
+// if there are none to reuse.
+func (p *Pool[T]) Get() T {
+	p.gets.Add(1)
+	if v := p.pool.Get(); v != nil {
+		p.hits.Add(1)
+		return v.(T)
+	}
+	p.misses.Add(1)
+	if p.New == nil {
+		var zero T
+		return zero
+	}
+	return p.New()
+}
+
+// Returns v to the pool, resetting it first if Reset is set.
+func (p *Pool[T]) Put(v T) {
+	p.puts.Add(1)
+	if p.Reset != nil {
+		p.Reset(v)
+	}
+	p.pool.Put(v)
+}
+
+func (p *Pool[T]) Stats() PoolStats {
+	return PoolStats{
+		Hits:   p.hits.Load(),
+		Misses: p.misses.Load(),
+		Live:   p.gets.Load() - p.puts.Load(),
+	}
+}
+
+// Returns the size class of a slice of the given size, i.e.
+// the smallest c such that 1<<c >= size.
+func byteClass(size int) int {
+	if size <= 1 {
+		return 0
+	}
+	return bits.Len(uint(size - 1))
+}
+
+func (p *BytePool) init() {
+	for c := range p.classes {
+		size := 1 << c
+		p.classes[c].New = func() *[]byte {
+			b := make([]byte, size)
+			return &b
+		}
+	}
+}
+
+// Returns a slice of length size. Its contents are not cleared,
+// so the caller must not depend on them.
+func (p *BytePool) Get(size int) []byte {
+	c := byteClass(size)
+	if c > maxByteClass {
+		return make([]byte, size)
+	}
+	p.once.Do(p.init)
+	bp := p.classes[c].Get()
+	b := (*bp)[:size]
+	*bp = nil
+	p.headers.Put(bp)
+	return b
+}
+
+// Returns b to the pool, after which the caller must not use it
+// anymore. Slices that weren't returned by Get are accepted as
+// long as their capacity is a power of two.
+func (p *BytePool) Put(b []byte) {
+	c := byteClass(cap(b))
+	if c > maxByteClass || cap(b) != 1<<c {
+		return
+	}
+	p.once.Do(p.init)
+	bp, _ := p.headers.Get().(*[]byte)
+	if bp == nil {
+		bp = new([]byte)
+	}
+	*bp = b[:cap(b)]
+	p.classes[c].Put(bp)
+}
+
+// Returns the combined statistics of all the size classes.
+func (p *BytePool) Stats() (s PoolStats) {
+	for c := range p.classes {
+		cs := p.classes[c].Stats()
+		s.Hits += cs.Hits
+		s.Misses += cs.Misses
+		s.Live += cs.Live
+	}
+	return s
+}
@@ -0,0 +1,90 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package util
+
+import "testing"
+
+func TestPool(t *testing.T) {
+	type buffer struct {
+		data []int
+	}
+	var (
+		created = 0
+		p       = Pool[*buffer]{
+			New: func() *buffer {
+				created++
+				return &buffer{}
+			},
+			Reset: func(b *buffer) {
+				b.data = b.data[:0]
+			},
+		}
+	)
+	a := p.Get()
+	a.data = append(a.data, 1, 2, 3)
+	b := p.Get()
+	if s := p.Stats(); s != (PoolStats{Misses: 2, Live: 2}) {
+		t.Errorf("Unexpected stats %+v", s)
+	}
+	p.Put(a)
+	if len(a.data) != 0 {
+		t.Error("Expected the buffer to be reset")
+	}
+	// Whether a pooled object is reused is up to sync.Pool, so only
+	// the totals are known
+	if c := p.Get(); c == nil || len(c.data) != 0 {
+		t.Errorf("Expected a reset buffer, but got %v", c)
+	}
+	p.Put(b)
+	if s := p.Stats(); s.Hits+s.Misses != 3 || s.Live != 1 {
+		t.Errorf("Unexpected stats %+v", s)
+	}
+	if s := p.Stats(); int64(created) != s.Misses {
+		t.Errorf("Expected %d buffers to be created, but got %d", s.Misses, created)
+	}
+
+	var empty Pool[*buffer]
+	if v := empty.Get(); v != nil {
+		t.Errorf("Expected the zero value without New, but got %v", v)
+	}
+}
+
+func TestBytePool(t *testing.T) {
+	var p BytePool
+	for _, size := range []int{0, 1, 3, 4, 5, 1000, 1024, 1025} {
+		b := p.Get(size)
+		if len(b) != size {
+			t.Errorf("Expected a length of %d, but got %d", size, len(b))
+		}
+		if c, exp := cap(b), 1<<byteClass(size); c != exp {
+			t.Errorf("Expected a capacity of %d for a size of %d, but got %d", exp, size, c)
+		}
+		p.Put(b)
+	}
+	b := p.Get(100)
+	p.Put(b)
+	if b2 := p.Get(120); len(b2) != 120 || cap(b2) != 128 {
+		t.Errorf("Expected a slice of length 120 and capacity 128, but got %d and %d", len(b2), cap(b2))
+	}
+	if s := p.Stats(); s.Live != 1 || s.Hits+s.Misses != 10 {
+		t.Errorf("Unexpected stats %+v", s)
+	}
+	// Slices that don't fit a size class are dropped
+	p.Put(make([]byte, 100))
+	if s := p.Stats(); s.Live != 1 {
+		t.Errorf("Unexpected stats %+v", s)
+	}
+	if big := p.Get(1<<maxByteClass + 1); len(big) != 1<<maxByteClass+1 {
+		t.Error("Expected large slices to be allocated directly")
+	}
+}
+
+func BenchmarkBytePool(b *testing.B) {
+	var p BytePool
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		p.Put(p.Get(64))
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package util
+
+type (
+	// PropertyChange is the data passed to a Property's
+	// observers whenever its value changes.
+	PropertyChange[T any] struct {
+		Old, New T
+	}
+
+	// A Property is an observable value holder.
+	//
+	// Observers are notified with a PropertyChange whenever Set
+	// changes the value. Setting a property to the value it already
+	// holds is a no-op and does not notify the observers, which also
+	// makes it safe to Bind properties to each other.
+	Property[T comparable] struct {
+		BasicObservable
+		// If non-nil, Validate is called with every new value
+		// before it is stored, and a non-nil error returned from
+		// it aborts the Set.
+		Validate func(v T) error
+		// If non-nil, OnSetError is called with the errors of the
+		// Set calls made by Bind and MapProperty to keep the
+		// property up to date, which have no caller to return
+		// them to. The property keeps its old value in that case.
+		OnSetError func(err error)
+
+		value    T
+		batching int
+		batchOld T
+	}
+
+	propertyObserver struct {
+		fn func(data interface{})
+	}
+)
+
+// Creates a new Property holding the provided initial value.
+func NewProperty[T comparable](v T) *Property[T] {
+	return &Property[T]{value: v}
+}
+
+// Returns the current value of the property.
+func (p *Property[T]) Get() T {
+	return p.value
+}
+
+// Sets the value of the property, notifying observers if
+// the value changed. If the property has a Validate function
+// and it rejects the value, the error is returned and the
+// property is left untouched.
+func (p *Property[T]) Set(v T) error {
+	if v == p.value {
+		return nil
+	}
+	if p.Validate != nil {
+		if err := p.Validate(v); err != nil {
+			return err
+		}
+	}
+	old := p.value
+	p.value = v
+	if p.batching == 0 {
+		p.NotifyObservers(PropertyChange[T]{old, v})
+	}
+	return nil
+}
+
+// Calls fn with change notifications held back, coalescing all
+// the Set calls made within into at most one notification with
+// the value from before the batch as the old value and the
+// final value as the new one. No notification is sent if the
+// value ends up the same as when the batch started.
+//
+// Batches may be nested, in which case the notification is sent
+// once the outermost batch finishes.
+func (p *Property[T]) Batch(fn func()) {
+	if p.batching == 0 {
+		p.batchOld = p.value
+	}
+	p.batching++
+	defer func() {
+		p.batching--
+		if p.batching == 0 && p.batchOld != p.value {
+			p.NotifyObservers(PropertyChange[T]{p.batchOld, p.value})
+		}
+	}()
+	fn()
+}
+
+// Sets the value on behalf of a binding, passing any error on to
+// OnSetError.
+func (p *Property[T]) setBound(v T) {
+	if err := p.Set(v); err != nil && p.OnSetError != nil {
+		p.OnSetError(err)
+	}
+}
+
+func (o *propertyObserver) Changed(data interface{}) {
+	o.fn(data)
+}
+
+// Binds two properties together so that changing either one of
+// them will change the other one to match. The target property
+// is initially set to the value of the source property.
+//
+// A value rejected by either property's Validate function leaves
+// the two properties out of sync, and is reported to the OnSetError
+// function of the property that rejected it.
+//
+// The returned function removes the binding.
+func Bind[T comparable](source, target *Property[T]) (unbind func()) {
+	var (
+		fwd = &propertyObserver{func(data interface{}) {
+			target.setBound(data.(PropertyChange[T]).New)
+		}}
+		back = &propertyObserver{func(data interface{}) {
+			source.setBound(data.(PropertyChange[T]).New)
+		}}
+	)
+	target.setBound(source.Get())
+	source.AddObserver(fwd)
+	target.AddObserver(back)
+	return func() {
+		source.RemoveObserver(fwd)
+		target.RemoveObserver(back)
+	}
+}
+
+// Creates a new Property whose value is the result of calling
+// fn with the source property's value, and which is kept up to
+// date as the source property changes. Values rejected by the
+// returned property's Validate function are reported to its
+// OnSetError function.
+func MapProperty[T, U comparable](source *Property[T], fn func(T) U) *Property[U] {
+	ret := NewProperty(fn(source.Get()))
+	source.AddObserver(&propertyObserver{func(data interface{}) {
+		ret.setBound(fn(data.(PropertyChange[T]).New))
+	}})
+	return ret
+}
@@ -0,0 +1,175 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+type changeRecorder struct {
+	changes []interface{}
+}
+
+func (r *changeRecorder) Changed(data interface{}) {
+	r.changes = append(r.changes, data)
+}
+
+func TestProperty(t *testing.T) {
+	var (
+		p = NewProperty(1)
+		r changeRecorder
+	)
+	p.AddObserver(&r)
+	p.Set(1)
+	if len(r.changes) != 0 {
+		t.Errorf("Setting the same value shouldn't notify: %v", r.changes)
+	}
+	p.Set(2)
+	p.Set(3)
+	exp := []PropertyChange[int]{{1, 2}, {2, 3}}
+	if len(r.changes) != len(exp) {
+		t.Fatalf("Expected %d changes, but got %d", len(exp), len(r.changes))
+	}
+	for i := range exp {
+		if r.changes[i] != exp[i] {
+			t.Errorf("%d: Expected %v, but got %v", i, exp[i], r.changes[i])
+		}
+	}
+	if v := p.Get(); v != 3 {
+		t.Errorf("Expected 3, but got %d", v)
+	}
+}
+
+func TestPropertyValidate(t *testing.T) {
+	var (
+		p = NewProperty(1)
+		r changeRecorder
+	)
+	p.Validate = func(v int) error {
+		if v < 0 {
+			return errors.New("negative")
+		}
+		return nil
+	}
+	p.AddObserver(&r)
+	if err := p.Set(-1); err == nil {
+		t.Error("Expected an error, but didn't get one")
+	}
+	if v := p.Get(); v != 1 {
+		t.Errorf("Expected 1, but got %d", v)
+	}
+	if len(r.changes) != 0 {
+		t.Errorf("Didn't expect any changes, but got %v", r.changes)
+	}
+	if err := p.Set(5); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPropertyBatch(t *testing.T) {
+	var (
+		p = NewProperty(1)
+		r changeRecorder
+	)
+	p.AddObserver(&r)
+	p.Batch(func() {
+		p.Set(2)
+		p.Batch(func() {
+			p.Set(3)
+		})
+		p.Set(4)
+	})
+	if len(r.changes) != 1 {
+		t.Fatalf("Expected 1 change, but got %v", r.changes)
+	}
+	if c := r.changes[0]; c != (PropertyChange[int]{1, 4}) {
+		t.Errorf("Unexpected change: %v", c)
+	}
+	p.Batch(func() {
+		p.Set(5)
+		p.Set(4)
+	})
+	if len(r.changes) != 1 {
+		t.Errorf("Expected no additional changes, but got %v", r.changes)
+	}
+}
+
+func TestPropertyBind(t *testing.T) {
+	var (
+		a = NewProperty("a")
+		b = NewProperty("b")
+	)
+	unbind := Bind(a, b)
+	if b.Get() != "a" {
+		t.Errorf("Expected %q, but got %q", "a", b.Get())
+	}
+	a.Set("hello")
+	if b.Get() != "hello" {
+		t.Errorf("Expected %q, but got %q", "hello", b.Get())
+	}
+	b.Set("world")
+	if a.Get() != "world" {
+		t.Errorf("Expected %q, but got %q", "world", a.Get())
+	}
+	unbind()
+	a.Set("unbound")
+	if b.Get() != "world" {
+		t.Errorf("Expected %q, but got %q", "world", b.Get())
+	}
+}
+
+func TestMapProperty(t *testing.T) {
+	var (
+		a = NewProperty(10)
+		b = MapProperty(a, strconv.Itoa)
+	)
+	if b.Get() != "10" {
+		t.Errorf("Expected %q, but got %q", "10", b.Get())
+	}
+	a.Set(42)
+	if b.Get() != "42" {
+		t.Errorf("Expected %q, but got %q", "42", b.Get())
+	}
+}
+
+func TestPropertyBindErrors(t *testing.T) {
+	var (
+		a    = NewProperty(1)
+		b    = NewProperty(2)
+		errs []error
+		neg  = errors.New("Negative")
+	)
+	b.Validate = func(v int) error {
+		if v < 0 {
+			return neg
+		}
+		return nil
+	}
+	b.OnSetError = func(err error) {
+		errs = append(errs, err)
+	}
+	Bind(a, b)
+	a.Set(-1)
+	if b.Get() != 1 {
+		t.Errorf("Expected %d, but got %d", 1, b.Get())
+	}
+	if len(errs) != 1 || errs[0] != neg {
+		t.Errorf("Expected the validation error to be reported, but got %v", errs)
+	}
+
+	c := MapProperty(a, func(v int) int { return -v })
+	c.Validate = b.Validate
+	c.OnSetError = b.OnSetError
+	errs = nil
+	a.Set(5)
+	if c.Get() != 1 || b.Get() != 5 {
+		t.Errorf("Expected %d and %d, but got %d and %d", 1, 5, c.Get(), b.Get())
+	}
+	if len(errs) != 1 || errs[0] != neg {
+		t.Errorf("Expected the validation error to be reported, but got %v", errs)
+	}
+}
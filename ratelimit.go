@@ -0,0 +1,190 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	// A source of time for Debouncers and Throttlers, which allows
+	// tests to control their timing rather than sleeping.
+	Clock interface {
+		Now() time.Time
+		// Calls f in its own goroutine once d has passed.
+		AfterFunc(d time.Duration, f func()) ClockTimer
+	}
+
+	// A timer started by a Clock's AfterFunc.
+	ClockTimer interface {
+		// Stops the timer, returning false if it had already
+		// fired or been stopped.
+		Stop() bool
+	}
+
+	// The Clock of the system, as given by the time package.
+	systemClock struct{}
+
+	// The state shared by Debouncer and Throttler: the observer to
+	// forward to and the latest notification not yet forwarded.
+	coalescer struct {
+		// The clock used for timing, which is the system's if nil.
+		// Must be set before the first notification.
+		Clock   Clock
+		obs     Observer
+		lock    sync.Mutex
+		deliver sync.Mutex
+		timer   ClockTimer
+		pending bool
+		data    interface{}
+	}
+
+	// Debouncer is an Observer which forwards a notification to the
+	// observer it wraps once no further notifications have arrived
+	// for a given delay. Only the latest notification is forwarded.
+	Debouncer struct {
+		coalescer
+		delay time.Duration
+	}
+
+	// Throttler is an Observer which forwards notifications to the
+	// observer it wraps at most once per interval. A notification
+	// arriving when the interval has passed is forwarded directly,
+	// while the ones arriving sooner are coalesced, and the latest
+	// of them is forwarded once the interval has passed.
+	Throttler struct {
+		coalescer
+		interval time.Duration
+		last     time.Time
+	}
+)
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) ClockTimer {
+	return time.AfterFunc(d, f)
+}
+
+func (c *coalescer) clock() Clock {
+	if c.Clock == nil {
+		return systemClock{}
+	}
+	return c.Clock
+}
+
+// Forwards the pending notification, if any. Called with c.lock
+// held, which is released.
+func (c *coalescer) fire() {
+	c.timer = nil
+	if !c.pending {
+		c.lock.Unlock()
+		return
+	}
+	data := c.data
+	c.pending, c.data = false, nil
+	// Taken before releasing c.lock to keep the order of
+	// deliveries.
+	c.deliver.Lock()
+	c.lock.Unlock()
+	defer c.deliver.Unlock()
+	c.obs.Changed(data)
+}
+
+// Forwards the pending notification right away, if there is one.
+func (c *coalescer) Flush() {
+	c.lock.Lock()
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	c.fire()
+}
+
+// Drops the pending notification, if there is one.
+func (c *coalescer) Stop() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	c.pending, c.data = false, nil
+}
+
+// Wraps obs in a Debouncer with the given delay. Notifications are
+// forwarded from a timer goroutine, never concurrently.
+func Debounce(obs Observer, delay time.Duration) *Debouncer {
+	return &Debouncer{coalescer: coalescer{obs: obs}, delay: delay}
+}
+
+func (d *Debouncer) Changed(data interface{}) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.pending, d.data = true, data
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	var t ClockTimer
+	t = d.clock().AfterFunc(d.delay, func() {
+		d.lock.Lock()
+		if d.timer != t {
+			// Replaced by a later notification
+			d.lock.Unlock()
+			return
+		}
+		d.fire()
+	})
+	d.timer = t
+}
+
+// Wraps obs in a Throttler with the given interval. Notifications
+// are forwarded either directly or from a timer goroutine, but
+// never concurrently.
+func Throttle(obs Observer, interval time.Duration) *Throttler {
+	return &Throttler{coalescer: coalescer{obs: obs}, interval: interval}
+}
+
+func (t *Throttler) Changed(data interface{}) {
+	t.lock.Lock()
+	now := t.clock().Now()
+	t.pending, t.data = true, data
+	if t.timer != nil {
+		t.lock.Unlock()
+		return
+	}
+	if wait := t.last.Add(t.interval).Sub(now); wait > 0 {
+		var timer ClockTimer
+		timer = t.clock().AfterFunc(wait, func() {
+			t.lock.Lock()
+			if t.timer != timer {
+				t.lock.Unlock()
+				return
+			}
+			t.last = t.clock().Now()
+			t.fire()
+		})
+		t.timer = timer
+		t.lock.Unlock()
+		return
+	}
+	t.last = now
+	t.fire()
+}
+
+// Forwards the pending notification right away, if there is one,
+// which starts a new interval just like any other notification
+// forwarded.
+func (t *Throttler) Flush() {
+	t.lock.Lock()
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	if t.pending {
+		t.last = t.clock().Now()
+	}
+	t.fire()
+}
@@ -0,0 +1,160 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingObserver struct {
+	lock sync.Mutex
+	data []interface{}
+}
+
+func (r *recordingObserver) Changed(data interface{}) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.data = append(r.data, data)
+}
+
+func (r *recordingObserver) get() []interface{} {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]interface{}(nil), r.data...)
+}
+
+// A Clock whose time only moves when advanced. Timers fire
+// synchronously from advance.
+type fakeClock struct {
+	lock   sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock *fakeClock
+	at    time.Time
+	f     func()
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) ClockTimer {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	t := &fakeTimer{c, c.now.Add(d), f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.lock.Lock()
+	defer t.clock.lock.Unlock()
+	for i, t2 := range t.clock.timers {
+		if t2 == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Moves the time forward by d, firing the timers due by then.
+func (c *fakeClock) advance(d time.Duration) {
+	c.lock.Lock()
+	c.now = c.now.Add(d)
+	var due, rest []*fakeTimer
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			rest = append(rest, t)
+		} else {
+			due = append(due, t)
+		}
+	}
+	c.timers = rest
+	c.lock.Unlock()
+	for _, t := range due {
+		t.f()
+	}
+}
+
+func TestDebounce(t *testing.T) {
+	var (
+		rec   recordingObserver
+		clock = &fakeClock{now: time.Unix(0, 0)}
+		d     = Debounce(&rec, 20*time.Millisecond)
+	)
+	d.Clock = clock
+	for i := 0; i < 10; i++ {
+		d.Changed(i)
+		clock.advance(10 * time.Millisecond)
+	}
+	if got := rec.get(); len(got) != 0 {
+		t.Errorf("Didn't expect anything to be forwarded yet, but got %v", got)
+	}
+	clock.advance(10 * time.Millisecond)
+	if got := rec.get(); len(got) != 1 || got[0] != 9 {
+		t.Errorf("Expected only the last notification, but got %v", got)
+	}
+	d.Changed(10)
+	d.Flush()
+	d.Changed(11)
+	d.Stop()
+	clock.advance(time.Second)
+	if got := rec.get(); len(got) != 2 || got[1] != 10 {
+		t.Errorf("Expected the flushed notification only, but got %v", got)
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	var (
+		rec   recordingObserver
+		clock = &fakeClock{now: time.Unix(0, 0)}
+		th    = Throttle(&rec, 50*time.Millisecond)
+	)
+	th.Clock = clock
+	for i := 0; i < 10; i++ {
+		th.Changed(i)
+	}
+	if got := rec.get(); len(got) != 1 || got[0] != 0 {
+		t.Errorf("Expected the first notification to be forwarded directly, but got %v", got)
+	}
+	clock.advance(49 * time.Millisecond)
+	if got := rec.get(); len(got) != 1 {
+		t.Errorf("Didn't expect anything to be forwarded within the interval, but got %v", got)
+	}
+	clock.advance(time.Millisecond)
+	if got := rec.get(); len(got) != 2 || got[1] != 9 {
+		t.Errorf("Expected the last notification to be forwarded after the interval, but got %v", got)
+	}
+	clock.advance(100 * time.Millisecond)
+	th.Changed(10)
+	if got := rec.get(); len(got) != 3 || got[2] != 10 {
+		t.Errorf("Expected a notification after a quiet interval to be forwarded directly, but got %v", got)
+	}
+
+	// A flush starts a new interval
+	clock.advance(10 * time.Millisecond)
+	th.Changed(11)
+	th.Flush()
+	th.Changed(12)
+	if got := rec.get(); len(got) != 4 || got[3] != 11 {
+		t.Errorf("Expected only the flushed notification, but got %v", got)
+	}
+	clock.advance(49 * time.Millisecond)
+	if got := rec.get(); len(got) != 4 {
+		t.Errorf("Didn't expect anything to be forwarded within the interval of the flush, but got %v", got)
+	}
+	clock.advance(time.Millisecond)
+	if got := rec.get(); len(got) != 5 || got[4] != 12 {
+		t.Errorf("Expected the notification after the flush to be forwarded after the interval, but got %v", got)
+	}
+}
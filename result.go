@@ -0,0 +1,240 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"fmt"
+	"sync"
+)
+
+var ErrNoValue = fmt.Errorf("The option holds no value")
+
+type (
+	// An Option either holds a value of type T, or nothing. The zero
+	// Option holds nothing.
+	Option[T any] struct {
+		value T
+		ok    bool
+	}
+
+	// A Result is the outcome of an operation, either a value of
+	// type T or the error the operation failed with.
+	Result[T any] struct {
+		value T
+		err   error
+	}
+
+	// An AsyncResult is the Result of an operation which hasn't
+	// necessarily finished yet. It is fulfilled exactly once, by
+	// whoever started the operation, after which its observers are
+	// notified with the Result.
+	//
+	// Observers added after the AsyncResult has been fulfilled are
+	// notified right away. They're notified from whichever goroutine
+	// fulfills the AsyncResult or adds them, and must not add or
+	// remove observers from within their Changed callback.
+	AsyncResult[T any] struct {
+		obs    BasicObservable
+		notify sync.Mutex
+		done   chan struct{}
+		result Result[T]
+	}
+)
+
+// Creates an Option holding v.
+func Some[T any](v T) Option[T] {
+	return Option[T]{v, true}
+}
+
+// Creates an Option holding nothing.
+func None[T any]() Option[T] {
+	return Option[T]{}
+}
+
+// Returns the value of the option, and whether it holds one.
+func (o Option[T]) Get() (T, bool) {
+	return o.value, o.ok
+}
+
+// Returns whether the option holds a value.
+func (o Option[T]) IsSome() bool {
+	return o.ok
+}
+
+// Returns the value of the option, or v if it holds nothing.
+func (o Option[T]) OrElse(v T) T {
+	if o.ok {
+		return o.value
+	}
+	return v
+}
+
+// Returns the option as a Result, failing with ErrNoValue if it
+// holds nothing.
+func (o Option[T]) Result() Result[T] {
+	if o.ok {
+		return Ok(o.value)
+	}
+	return Fail[T](ErrNoValue)
+}
+
+func (o Option[T]) String() string {
+	if o.ok {
+		return fmt.Sprintf("Some(%v)", o.value)
+	}
+	return "None"
+}
+
+// Returns an Option holding the result of calling fn with the value
+// of o, or nothing if o holds nothing.
+func MapOption[T, U any](o Option[T], fn func(T) U) Option[U] {
+	if o.ok {
+		return Some(fn(o.value))
+	}
+	return None[U]()
+}
+
+// Creates a successful Result holding v.
+func Ok[T any](v T) Result[T] {
+	return Result[T]{value: v}
+}
+
+// Creates a Result failed with err.
+func Fail[T any](err error) Result[T] {
+	return Result[T]{err: err}
+}
+
+// Creates a Result from the return values of a function, so that
+// the Result of f() is just ResultOf(f()).
+func ResultOf[T any](v T, err error) Result[T] {
+	if err != nil {
+		return Fail[T](err)
+	}
+	return Ok(v)
+}
+
+// Returns the value and error of the result.
+func (r Result[T]) Get() (T, error) {
+	return r.value, r.err
+}
+
+// Returns the error the result failed with, or nil if it succeeded.
+func (r Result[T]) Err() error {
+	return r.err
+}
+
+// Returns the value of the result, or v if it failed.
+func (r Result[T]) OrElse(v T) T {
+	if r.err == nil {
+		return r.value
+	}
+	return v
+}
+
+// Returns the value of the result as an Option, which holds nothing
+// if the result failed.
+func (r Result[T]) Option() Option[T] {
+	if r.err == nil {
+		return Some(r.value)
+	}
+	return None[T]()
+}
+
+func (r Result[T]) String() string {
+	if r.err == nil {
+		return fmt.Sprintf("Ok(%v)", r.value)
+	}
+	return fmt.Sprintf("Fail(%s)", r.err)
+}
+
+// Returns a Result holding the result of calling fn with the value
+// of r, or the error r failed with, in which case fn isn't called.
+func MapResult[T, U any](r Result[T], fn func(T) (U, error)) Result[U] {
+	if r.err != nil {
+		return Fail[U](r.err)
+	}
+	return ResultOf(fn(r.value))
+}
+
+// Creates a new AsyncResult which has yet to be fulfilled.
+func NewAsyncResult[T any]() *AsyncResult[T] {
+	return &AsyncResult[T]{done: make(chan struct{})}
+}
+
+// Runs fn in a new goroutine, returning the AsyncResult which is
+// fulfilled with what fn returns.
+func Async[T any](fn func() (T, error)) *AsyncResult[T] {
+	a := NewAsyncResult[T]()
+	go func() {
+		a.Fulfill(ResultOf(fn()))
+	}()
+	return a
+}
+
+// Fulfills the AsyncResult with r, notifying its observers. Returns
+// false, leaving the AsyncResult untouched, if it has already been
+// fulfilled.
+func (a *AsyncResult[T]) Fulfill(r Result[T]) bool {
+	a.notify.Lock()
+	defer a.notify.Unlock()
+	select {
+	case <-a.done:
+		return false
+	default:
+	}
+	a.result = r
+	close(a.done)
+	a.obs.NotifyObservers(r)
+	return true
+}
+
+// Returns a channel which is closed once the AsyncResult has been
+// fulfilled.
+func (a *AsyncResult[T]) Done() <-chan struct{} {
+	return a.done
+}
+
+// Blocks until the AsyncResult has been fulfilled, and returns its
+// Result.
+func (a *AsyncResult[T]) Wait() Result[T] {
+	<-a.done
+	return a.result
+}
+
+// Returns the Result, and whether the AsyncResult has been fulfilled
+// yet, without blocking.
+func (a *AsyncResult[T]) Peek() (Result[T], bool) {
+	select {
+	case <-a.done:
+		return a.result, true
+	default:
+		return Result[T]{}, false
+	}
+}
+
+func (a *AsyncResult[T]) AddObserver(obs Observer) {
+	a.notify.Lock()
+	defer a.notify.Unlock()
+	a.obs.AddObserver(obs)
+	select {
+	case <-a.done:
+		obs.Changed(a.result)
+	default:
+	}
+}
+
+func (a *AsyncResult[T]) RemoveObserver(obs Observer) {
+	a.notify.Lock()
+	defer a.notify.Unlock()
+	a.obs.RemoveObserver(obs)
+}
+
+// Notifies the observers with data, which is normally only done
+// with the Result when the AsyncResult is fulfilled.
+func (a *AsyncResult[T]) NotifyObservers(data interface{}) {
+	a.notify.Lock()
+	defer a.notify.Unlock()
+	a.obs.NotifyObservers(data)
+}
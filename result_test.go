@@ -0,0 +1,109 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+)
+
+func TestOption(t *testing.T) {
+	var (
+		some = Some(3)
+		none = None[int]()
+	)
+	if v, ok := some.Get(); !ok || v != 3 {
+		t.Errorf("Unexpected value %d, %v", v, ok)
+	}
+	if none.IsSome() || (Option[int]{}).IsSome() {
+		t.Error("Expected None to hold nothing")
+	}
+	if some.OrElse(5) != 3 || none.OrElse(5) != 5 {
+		t.Error("Unexpected OrElse values")
+	}
+	if s := MapOption(some, strconv.Itoa).String(); s != "Some(3)" {
+		t.Errorf("Unexpected mapped option %s", s)
+	}
+	if s := MapOption(none, strconv.Itoa).String(); s != "None" {
+		t.Errorf("Unexpected mapped option %s", s)
+	}
+	if err := none.Result().Err(); err != ErrNoValue {
+		t.Errorf("Expected ErrNoValue, but got %v", err)
+	}
+}
+
+func TestResult(t *testing.T) {
+	var (
+		ok  = ResultOf(strconv.Atoi("12"))
+		bad = ResultOf(strconv.Atoi("x"))
+	)
+	if v, err := ok.Get(); err != nil || v != 12 {
+		t.Errorf("Unexpected result %d, %v", v, err)
+	}
+	if bad.Err() == nil || bad.OrElse(-1) != -1 || bad.Option().IsSome() {
+		t.Errorf("Expected a failed result, but got %s", bad)
+	}
+	half := func(v int) (int, error) {
+		if v%2 != 0 {
+			return 0, fmt.Errorf("%d is odd", v)
+		}
+		return v / 2, nil
+	}
+	if s := MapResult(ok, half).String(); s != "Ok(6)" {
+		t.Errorf("Unexpected mapped result %s", s)
+	}
+	if s := MapResult(Ok(3), half).String(); s != "Fail(3 is odd)" {
+		t.Errorf("Unexpected mapped result %s", s)
+	}
+	called := false
+	MapResult(bad, func(v int) (int, error) {
+		called = true
+		return v, nil
+	})
+	if called {
+		t.Error("Expected fn not to be called for a failed result")
+	}
+}
+
+type resultRecorder struct {
+	results []Result[int]
+}
+
+func (r *resultRecorder) Changed(data interface{}) {
+	r.results = append(r.results, data.(Result[int]))
+}
+
+func TestAsyncResult(t *testing.T) {
+	var (
+		a      = NewAsyncResult[int]()
+		before resultRecorder
+		after  resultRecorder
+	)
+	a.AddObserver(&before)
+	if _, ok := a.Peek(); ok {
+		t.Error("Expected the result not to be fulfilled yet")
+	}
+	if !a.Fulfill(Ok(1)) || a.Fulfill(Ok(2)) {
+		t.Error("Expected only the first Fulfill to succeed")
+	}
+	a.AddObserver(&after)
+	if r, ok := a.Peek(); !ok || r.OrElse(0) != 1 {
+		t.Errorf("Unexpected result %s, %v", r, ok)
+	}
+	for _, rec := range []resultRecorder{before, after} {
+		if len(rec.results) != 1 || rec.results[0].OrElse(0) != 1 {
+			t.Errorf("Expected a single notification, got %v", rec.results)
+		}
+	}
+
+	b := Async(func() (int, error) {
+		return 0, fmt.Errorf("failed")
+	})
+	<-b.Done()
+	if err := b.Wait().Err(); err == nil || err.Error() != "failed" {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
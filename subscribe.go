@@ -0,0 +1,78 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"context"
+	"sync"
+)
+
+// Forwards notifications to obs until its context is done.
+type ctxObserver struct {
+	ctx  context.Context
+	o    Observable
+	lock sync.Mutex
+	obs  Observer
+}
+
+// Adds obs to the observable o until ctx is done, so that a short
+// lived view observing a long lived model doesn't have to remember
+// to remove itself.
+//
+// Once ctx is done, obs is released right away and is no longer
+// notified. As Observables such as BasicObservable aren't safe for
+// concurrent use, the observer registered in its place is removed
+// from o by o's next notification rather than from the goroutine
+// canceling ctx.
+//
+// The returned function removes obs right away, and may be called
+// multiple times.
+func Subscribe(ctx context.Context, o Observable, obs Observer) (cancel func()) {
+	c := &ctxObserver{ctx: ctx, o: o, obs: obs}
+	o.AddObserver(c)
+	stop := context.AfterFunc(ctx, c.release)
+	return func() {
+		stop()
+		c.release()
+		o.RemoveObserver(c)
+	}
+}
+
+func (c *ctxObserver) release() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.obs = nil
+}
+
+// Returns the observer to forward to, removing c from its
+// observable if there no longer is one.
+func (c *ctxObserver) get() Observer {
+	if c.ctx.Err() != nil {
+		c.release()
+	}
+	c.lock.Lock()
+	obs := c.obs
+	c.lock.Unlock()
+	if obs == nil {
+		c.o.RemoveObserver(c)
+	}
+	return obs
+}
+
+func (c *ctxObserver) Changed(data interface{}) {
+	if obs := c.get(); obs != nil {
+		obs.Changed(data)
+	}
+}
+
+func (c *ctxObserver) Consume(data interface{}) bool {
+	obs := c.get()
+	if cons, ok := obs.(Consumer); ok {
+		return cons.Consume(data)
+	} else if obs != nil {
+		obs.Changed(data)
+	}
+	return false
+}
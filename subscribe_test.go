@@ -0,0 +1,53 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSubscribe(t *testing.T) {
+	var (
+		o           BasicObservable
+		a, b        reentrantObserver
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+	Subscribe(ctx, &o, &a)
+	unsubscribe := Subscribe(context.Background(), &o, &b)
+	o.NotifyObservers(nil)
+	cancel()
+	o.NotifyObservers(nil)
+	if a.count != 1 || b.count != 2 {
+		t.Errorf("Expected 1 and 2 notifications, but got %d and %d", a.count, b.count)
+	}
+	if len(o.observers) != 1 {
+		t.Errorf("Expected the canceled observer to have been removed, %d observers left", len(o.observers))
+	}
+	unsubscribe()
+	unsubscribe()
+	o.NotifyObservers(nil)
+	if b.count != 2 || len(o.observers) != 0 {
+		t.Errorf("Expected no further notifications, but got %d", b.count)
+	}
+}
+
+func TestSubscribeConsumer(t *testing.T) {
+	var (
+		o     BasicObservable
+		order []string
+		check = &consumingObserver{orderObserver{name: "check", order: &order}}
+		ui    = &orderObserver{name: "ui", order: &order}
+	)
+	cancel := Subscribe(context.Background(), &o, check)
+	defer cancel()
+	o.AddObserver(ui)
+	o.NotifyObservers(-1)
+	o.NotifyObservers(1)
+	if !reflect.DeepEqual(order, []string{"check", "check", "ui"}) {
+		t.Errorf("Unexpected notification order %v", order)
+	}
+}
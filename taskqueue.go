@@ -0,0 +1,272 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+const (
+	TaskQueued TaskState = iota
+	TaskStarted
+	TaskFinished
+	TaskFailed
+	// The task was dropped from the queue without being started,
+	// as the queue's context was canceled.
+	TaskCanceled
+)
+
+var (
+	ErrQueueClosed   = fmt.Errorf("The task queue has been closed")
+	ErrQueueDraining = fmt.Errorf("The task queue is being waited on")
+)
+
+type (
+	TaskState int
+
+	// A function to be run by a TaskQueue. The context is canceled
+	// when the queue's context is, and long running tasks should
+	// check it regularly.
+	Task func(ctx context.Context) error
+
+	// TaskEvent is the data passed to a TaskQueue's observers for
+	// every change in a task's state.
+	TaskEvent struct {
+		ID    int64
+		State TaskState
+		// Set for TaskFailed events.
+		Err error
+	}
+
+	// TaskQueue runs submitted tasks on a bounded number of worker
+	// goroutines, in the order they were submitted.
+	//
+	// The lifecycle of every task is reported to the queue's observers
+	// as TaskEvents, in order. The observers are notified one at a time
+	// from a goroutine of the queue's own, so they're free to submit
+	// tasks and query the queue from within their Changed callback, but
+	// must not Wait for or Close the queue, nor add or remove observers.
+	//
+	// The workers and the goroutine notifying the observers are only
+	// started when there's something for them to do, and exit once
+	// they're done, so an idle queue holds no goroutines and needn't
+	// be closed.
+	TaskQueue struct {
+		obs     BasicObservable
+		notify  sync.Mutex
+		ctx     context.Context
+		lock    sync.Mutex
+		pending []queuedTask
+		nextID  int64
+		closed  bool
+		// The number of calls to Wait in progress
+		draining int
+		// The maximum and current number of workers
+		max, running int
+		active       sync.WaitGroup
+		workers      sync.WaitGroup
+
+		// The events yet to be delivered to the observers
+		elock      sync.Mutex
+		events     []TaskEvent
+		delivering bool
+		notifier   sync.WaitGroup
+	}
+
+	queuedTask struct {
+		id   int64
+		task Task
+	}
+)
+
+func (s TaskState) String() string {
+	switch s {
+	case TaskQueued:
+		return "queued"
+	case TaskStarted:
+		return "started"
+	case TaskFinished:
+		return "finished"
+	case TaskFailed:
+		return "failed"
+	case TaskCanceled:
+		return "canceled"
+	}
+	return fmt.Sprintf("TaskState(%d)", int(s))
+}
+
+// Creates a new TaskQueue running at most workers tasks at a time.
+// Canceling ctx cancels the running tasks' contexts and drops the
+// ones not yet started.
+func NewTaskQueue(ctx context.Context, workers int) *TaskQueue {
+	if workers < 1 {
+		workers = 1
+	}
+	return &TaskQueue{ctx: ctx, max: workers}
+}
+
+func (q *TaskQueue) AddObserver(obs Observer) {
+	q.notify.Lock()
+	defer q.notify.Unlock()
+	q.obs.AddObserver(obs)
+}
+
+func (q *TaskQueue) RemoveObserver(obs Observer) {
+	q.notify.Lock()
+	defer q.notify.Unlock()
+	q.obs.RemoveObserver(obs)
+}
+
+func (q *TaskQueue) NotifyObservers(data interface{}) {
+	q.notify.Lock()
+	defer q.notify.Unlock()
+	q.obs.NotifyObservers(data)
+}
+
+// Adds task to the queue, returning the ID its events will carry.
+// Tasks can't be submitted while Wait is in progress, in which case
+// ErrQueueDraining is returned.
+func (q *TaskQueue) Submit(task Task) (int64, error) {
+	q.lock.Lock()
+	if q.closed {
+		q.lock.Unlock()
+		return 0, ErrQueueClosed
+	} else if err := q.ctx.Err(); err != nil {
+		q.lock.Unlock()
+		return 0, err
+	} else if q.draining > 0 {
+		q.lock.Unlock()
+		return 0, ErrQueueDraining
+	}
+	q.nextID++
+	id := q.nextID
+	q.active.Add(1)
+	// Posted before the task can be picked up by a worker, so that
+	// the queued event is delivered before the started event.
+	q.post(TaskEvent{ID: id, State: TaskQueued})
+	q.pending = append(q.pending, queuedTask{id, task})
+	if q.running < q.max {
+		q.running++
+		q.workers.Add(1)
+		go q.work()
+	}
+	q.lock.Unlock()
+	return id, nil
+}
+
+// Runs the pending tasks until there are none left, or until the
+// queue's context is canceled, in which case the tasks not yet
+// started are dropped.
+func (q *TaskQueue) work() {
+	defer q.workers.Done()
+	for {
+		q.lock.Lock()
+		if q.ctx.Err() != nil {
+			dropped := q.pending
+			q.pending = nil
+			q.running--
+			q.lock.Unlock()
+			for _, t := range dropped {
+				q.post(TaskEvent{ID: t.id, State: TaskCanceled})
+			}
+			return
+		}
+		if len(q.pending) == 0 {
+			q.running--
+			q.lock.Unlock()
+			return
+		}
+		t := q.pending[0]
+		q.pending = q.pending[1:]
+		q.lock.Unlock()
+
+		q.post(TaskEvent{ID: t.id, State: TaskStarted})
+		if err := q.run(t.task); err != nil {
+			q.post(TaskEvent{ID: t.id, State: TaskFailed, Err: err})
+		} else {
+			q.post(TaskEvent{ID: t.id, State: TaskFinished})
+		}
+	}
+}
+
+// Queues the event e for delivery to the observers, starting the
+// goroutine delivering them unless it's already running.
+func (q *TaskQueue) post(e TaskEvent) {
+	q.elock.Lock()
+	defer q.elock.Unlock()
+	q.events = append(q.events, e)
+	if !q.delivering {
+		q.delivering = true
+		q.notifier.Add(1)
+		go q.deliver()
+	}
+}
+
+// Delivers the posted events to the observers, in the order they
+// were posted, until there are none left. A task is done once its
+// last event has been delivered.
+func (q *TaskQueue) deliver() {
+	defer q.notifier.Done()
+	for {
+		q.elock.Lock()
+		events := q.events
+		q.events = nil
+		if len(events) == 0 {
+			q.delivering = false
+			q.elock.Unlock()
+			return
+		}
+		q.elock.Unlock()
+		for _, e := range events {
+			q.NotifyObservers(e)
+			if e.State != TaskQueued && e.State != TaskStarted {
+				q.active.Done()
+			}
+		}
+	}
+}
+
+// Runs task, turning a panic into an error.
+func (q *TaskQueue) run(task Task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("Task panicked: %v", r)
+		}
+	}()
+	return task(q.ctx)
+}
+
+// Returns the number of tasks submitted but not yet started.
+func (q *TaskQueue) Pending() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return len(q.pending)
+}
+
+// Blocks until all the tasks submitted so far have either
+// finished, failed or been canceled, and their events have been
+// delivered. No new tasks are accepted in the meantime.
+func (q *TaskQueue) Wait() {
+	q.lock.Lock()
+	q.draining++
+	q.lock.Unlock()
+	q.active.Wait()
+	q.lock.Lock()
+	q.draining--
+	q.lock.Unlock()
+}
+
+// Stops the queue from accepting new tasks, and blocks until the
+// tasks already submitted are done and their events have been
+// delivered.
+func (q *TaskQueue) Close() {
+	q.lock.Lock()
+	q.closed = true
+	q.lock.Unlock()
+	q.workers.Wait()
+	q.notifier.Wait()
+}
@@ -0,0 +1,209 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type taskRecorder struct {
+	events map[int64][]TaskState
+	errors map[int64]error
+}
+
+func (r *taskRecorder) Changed(data interface{}) {
+	e := data.(TaskEvent)
+	r.events[e.ID] = append(r.events[e.ID], e.State)
+	if e.Err != nil {
+		r.errors[e.ID] = e.Err
+	}
+}
+
+func TestTaskQueue(t *testing.T) {
+	var (
+		q       = NewTaskQueue(context.Background(), 3)
+		rec     = taskRecorder{map[int64][]TaskState{}, map[int64]error{}}
+		running atomic.Int32
+		maxRun  atomic.Int32
+		block   = make(chan struct{})
+	)
+	q.AddObserver(&rec)
+	ids := make([]int64, 10)
+	for i := range ids {
+		i := i
+		ids[i], _ = q.Submit(func(ctx context.Context) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				m := maxRun.Load()
+				if n <= m || maxRun.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			<-block
+			switch i {
+			case 3:
+				return fmt.Errorf("Task %d failed", i)
+			case 4:
+				panic("oops")
+			}
+			return nil
+		})
+	}
+	close(block)
+	q.Wait()
+	if m := maxRun.Load(); m > 3 {
+		t.Errorf("Expected at most 3 tasks to run at a time, but got %d", m)
+	}
+	for i, id := range ids {
+		final := TaskFinished
+		if i == 3 || i == 4 {
+			final = TaskFailed
+		}
+		exp := []TaskState{TaskQueued, TaskStarted, final}
+		got := rec.events[id]
+		if fmt.Sprint(got) != fmt.Sprint(exp) {
+			t.Errorf("Task %d: Expected %v, but got %v", i, exp, got)
+		}
+	}
+	if err := rec.errors[ids[4]]; err == nil {
+		t.Error("Expected the panic to be reported as an error")
+	}
+	q.Close()
+	if _, err := q.Submit(func(context.Context) error { return nil }); err != ErrQueueClosed {
+		t.Errorf("Expected %s, but got %v", ErrQueueClosed, err)
+	}
+}
+
+func TestTaskQueueCancel(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		q           = NewTaskQueue(ctx, 1)
+		rec         = taskRecorder{map[int64][]TaskState{}, map[int64]error{}}
+		started     = make(chan struct{})
+	)
+	q.AddObserver(&rec)
+	first, _ := q.Submit(func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	second, _ := q.Submit(func(ctx context.Context) error {
+		t.Error("Didn't expect the second task to run")
+		return nil
+	})
+	<-started
+	cancel()
+	q.Wait()
+	q.Close()
+	if s := rec.events[first]; len(s) != 3 || s[2] != TaskFailed {
+		t.Errorf("Expected the first task to fail, but got %v", s)
+	}
+	if s := rec.events[second]; len(s) != 2 || s[1] != TaskCanceled {
+		t.Errorf("Expected the second task to be canceled, but got %v", s)
+	}
+	if _, err := q.Submit(func(context.Context) error { return nil }); err == nil {
+		t.Error("Expected an error when submitting to a canceled queue")
+	}
+}
+
+// Submits a follow-up task from within Changed when the first task
+// finishes.
+type chainingObserver struct {
+	q       *TaskQueue
+	pending []int
+	done    chan int64
+}
+
+func (o *chainingObserver) Changed(data interface{}) {
+	e := data.(TaskEvent)
+	if e.State != TaskFinished {
+		return
+	}
+	o.pending = append(o.pending, o.q.Pending())
+	if e.ID == 1 {
+		o.q.Submit(func(context.Context) error { return nil })
+	} else {
+		o.done <- e.ID
+	}
+}
+
+func TestTaskQueueObserverSubmit(t *testing.T) {
+	q := NewTaskQueue(context.Background(), 1)
+	defer q.Close()
+	obs := chainingObserver{q: q, done: make(chan int64, 1)}
+	q.AddObserver(&obs)
+	if _, err := q.Submit(func(context.Context) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case id := <-obs.done:
+		if id != 2 {
+			t.Errorf("Expected the follow-up task to have ID 2, but got %d", id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the follow-up task")
+	}
+	if len(obs.pending) != 2 {
+		t.Errorf("Expected Pending to be called twice, but got %v", obs.pending)
+	}
+}
+
+func TestTaskQueueDraining(t *testing.T) {
+	var (
+		q     = NewTaskQueue(context.Background(), 1)
+		block = make(chan struct{})
+		done  = make(chan struct{})
+	)
+	defer q.Close()
+	q.Submit(func(context.Context) error {
+		<-block
+		return nil
+	})
+	go func() {
+		q.Wait()
+		close(done)
+	}()
+	var err error
+	for i := 0; i < 5000 && err == nil; i++ {
+		if _, err = q.Submit(func(context.Context) error { return nil }); err == nil {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	if err != ErrQueueDraining {
+		t.Errorf("Expected %s while waiting, but got %v", ErrQueueDraining, err)
+	}
+	close(block)
+	<-done
+	if _, err := q.Submit(func(context.Context) error { return nil }); err != nil {
+		t.Errorf("Expected tasks to be accepted again after Wait, but got %v", err)
+	}
+}
+
+func TestTaskQueueIdle(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		q := NewTaskQueue(context.Background(), 4)
+		q.AddObserver(&taskRecorder{map[int64][]TaskState{}, map[int64]error{}})
+		for j := 0; j < 10; j++ {
+			q.Submit(func(context.Context) error { return nil })
+		}
+		q.Wait()
+	}
+	// The goroutines exit right after delivering the last event
+	n := runtime.NumGoroutine()
+	for i := 0; i < 500 && n > before; i++ {
+		time.Sleep(time.Millisecond)
+		n = runtime.NumGoroutine()
+	}
+	if n > before {
+		t.Errorf("Expected idle queues to hold no goroutines, but %d were left running", n-before)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"weak"
+)
+
+// Forwards notifications to an observer without keeping it alive.
+type weakObserver[T any, P interface {
+	*T
+	Observer
+}] struct {
+	o   Observable
+	ptr weak.Pointer[T]
+}
+
+// Adds obs to the observable o without keeping obs alive, so that
+// a view which forgets to remove itself from a long lived model can
+// still be garbage collected. Once obs has been collected, it's
+// removed from o by o's next notification.
+//
+// The observer must not be referred to only by o, as it would then
+// be collected right away.
+//
+// The returned function removes obs right away, and may be called
+// multiple times.
+func AddWeakObserver[T any, P interface {
+	*T
+	Observer
+}](o Observable, obs P) (remove func()) {
+	w := &weakObserver[T, P]{o: o, ptr: weak.Make((*T)(obs))}
+	o.AddObserver(w)
+	return func() {
+		o.RemoveObserver(w)
+	}
+}
+
+// Returns the observer to forward to, removing w from its
+// observable if it has been collected.
+func (w *weakObserver[T, P]) get() P {
+	p := w.ptr.Value()
+	if p == nil {
+		w.o.RemoveObserver(w)
+	}
+	return P(p)
+}
+
+func (w *weakObserver[T, P]) Changed(data interface{}) {
+	if obs := w.get(); obs != nil {
+		obs.Changed(data)
+	}
+}
+
+func (w *weakObserver[T, P]) Consume(data interface{}) bool {
+	obs := w.get()
+	if obs == nil {
+		return false
+	} else if c, ok := Observer(obs).(Consumer); ok {
+		return c.Consume(data)
+	}
+	obs.Changed(data)
+	return false
+}
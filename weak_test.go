@@ -0,0 +1,45 @@
+// Copyright 2014 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"runtime"
+	"testing"
+)
+
+type countingView struct {
+	count *int
+}
+
+func (v *countingView) Changed(data interface{}) {
+	*v.count++
+}
+
+func TestAddWeakObserver(t *testing.T) {
+	var (
+		o     BasicObservable
+		count int
+		kept  = &countingView{&count}
+	)
+	func() {
+		AddWeakObserver(&o, &countingView{&count})
+	}()
+	remove := AddWeakObserver(&o, kept)
+	runtime.GC()
+	o.NotifyObservers(nil)
+	if count != 1 {
+		t.Errorf("Expected 1 notification, but got %d", count)
+	}
+	if len(o.observers) != 1 {
+		t.Errorf("Expected the collected observer to have been removed, %d observers left", len(o.observers))
+	}
+	remove()
+	remove()
+	o.NotifyObservers(nil)
+	if count != 1 || len(o.observers) != 0 {
+		t.Errorf("Expected no further notifications, but got %d", count)
+	}
+	runtime.KeepAlive(kept)
+}